@@ -0,0 +1,312 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics/spending"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics/subscriptions"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// REST API  –  the analyzer tools over authenticated HTTP, without the model
+// ============================================================================
+// Each endpoint turns its query string into tool input and runs the same tool
+// the model calls, through the same middleware chain (validation, logging,
+// metrics, audit, timeout), and returns the tool's result as JSON:
+//
+//	GET /api/spending?days=30&currency=USD
+//	GET /api/subscriptions?months=6&min_amount=1&max_amount=999.99&currency=USD
+//	GET /api/transactions/export?from=2025-01-01&to=2025-03-31&format=ofx
+//
+// Requests carry a bearer token (Authorization: Bearer ..., or ?token= like
+// /ws). In live mode it is the user's Liminal JWT, checked with Liminal
+// before anything else happens (see identity.go); the caller is the user
+// the token names, and the request gets an executor of its own that calls
+// Liminal with it, so concurrent callers never share credentials or data.
+// In mock mode it is api_token from the config (API_TOKEN), and X-User-ID
+// picks the mock user (default: the /ws user).
+//
+// Invalid parameters get a 400 listing every problem, in the same shape the
+// model gets; a tool that fails (Liminal unreachable, ...) gets a 502.
+//
+// Browsers on the cors_origins (by default the Vite dev server) may call the
+// API and download exports; preflight requests are answered before
+// authentication, since browsers send them without the token.
+
+// apiEndpoint maps query parameters onto one tool's input. check, if set,
+// reports input problems the schema can't express, so they get a 400 rather
+// than a failed tool call.
+type apiEndpoint struct {
+	path    string
+	newTool func(exec core.ToolExecutor) core.Tool
+	check   func(input map[string]interface{}) []toolkit.InputProblem
+	params  []apiParam
+}
+
+type apiParam struct {
+	query string // query parameter name
+	field string // tool input field
+	kind  string // integer | number | string
+}
+
+// analyzerEndpoints serve the analyzers, over the caller's Liminal
+// transactions plus their imported accounts (see imports.go).
+func analyzerEndpoints(imports *importStore) []apiEndpoint {
+	return []apiEndpoint{
+		{
+			path: "/api/spending",
+			newTool: func(exec core.ToolExecutor) core.Tool {
+				return spending.NewTool(withImportedTransactions(exec, imports))
+			},
+			params: []apiParam{
+				{query: "days", field: "days", kind: "integer"},
+				{query: "currency", field: "currency", kind: "string"},
+			},
+		},
+		{
+			path: "/api/subscriptions",
+			newTool: func(exec core.ToolExecutor) core.Tool {
+				return subscriptions.NewTool(withImportedTransactions(exec, imports))
+			},
+			params: []apiParam{
+				{query: "months", field: "timeframe_months", kind: "integer"},
+				{query: "min_amount", field: "min_amount", kind: "number"},
+				{query: "max_amount", field: "max_amount", kind: "number"},
+				{query: "currency", field: "currency", kind: "string"},
+			},
+		},
+	}
+}
+
+// apiCaller is an authenticated request's user and the executor to act for
+// them with.
+type apiCaller struct {
+	userID string
+	exec   core.ToolExecutor
+}
+
+type analyticsAPI struct {
+	endpoints    []apiEndpoint
+	authenticate func(r *http.Request) (apiCaller, error)
+	middleware   []toolMiddleware
+	corsOrigins  []string
+}
+
+// newAnalyticsAPI authenticates as described above. mockExec is the shared
+// (decorated) mock executor; verifier checks live tokens and builds each
+// live request's executor, which wrap decorates.
+func newAnalyticsAPI(conf *config.Config, endpoints []apiEndpoint, mockExec core.ToolExecutor, verifier *liminalVerifier, wrap func(core.ToolExecutor) core.ToolExecutor, middleware []toolMiddleware) *analyticsAPI {
+	api := &analyticsAPI{endpoints: endpoints, middleware: middleware, corsOrigins: conf.CORSOrigins}
+	if conf.Mock {
+		api.authenticate = func(r *http.Request) (apiCaller, error) {
+			token := bearerToken(r)
+			switch {
+			case conf.APIToken == "":
+				return apiCaller{}, errors.New("set api_token (API_TOKEN) to use the API in mock mode")
+			case subtle.ConstantTimeCompare([]byte(token), []byte(conf.APIToken)) != 1:
+				return apiCaller{}, errors.New("a valid bearer token is required")
+			}
+			userID := strings.TrimSpace(r.Header.Get("X-User-ID"))
+			if userID == "" {
+				userID = "default-user"
+			}
+			return apiCaller{userID: userID, exec: mockExec}, nil
+		}
+		return api
+	}
+	api.authenticate = func(r *http.Request) (apiCaller, error) {
+		token := bearerToken(r)
+		userID, err := verifier.verify(r.Context(), token)
+		if err != nil {
+			return apiCaller{}, err
+		}
+		return apiCaller{userID: userID, exec: wrap(verifier.newExec(token))}, nil
+	}
+	return api
+}
+
+// bearerToken reads the token from the Authorization header, or from the
+// token query parameter.
+func bearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return r.URL.Query().Get("token")
+}
+
+// register adds every endpoint to mux.
+func (api *analyticsAPI) register(mux *http.ServeMux) {
+	for _, ep := range api.endpoints {
+		mux.Handle(ep.path, api.handler(ep))
+	}
+}
+
+// cors lets the configured browser origins call h, and answers their
+// preflight requests.
+func (api *analyticsAPI) cors(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := origin != "" && (slices.Contains(api.corsOrigins, "*") || slices.Contains(api.corsOrigins, origin))
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", "Content-Disposition")
+		}
+		w.Header().Add("Vary", "Origin")
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if !allowed {
+			writeAPIError(w, http.StatusForbidden, "origin_not_allowed", "add this origin to cors_origins (CORS_ORIGINS) to call the API from it", nil)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-User-ID")
+		w.Header().Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// authenticated serves requests with method, and runs h for the ones that
+// authenticate.
+func (api *analyticsAPI) authenticated(method string, h func(w http.ResponseWriter, r *http.Request, caller apiCaller)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use "+method, nil)
+			return
+		}
+		caller, err := api.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAPIError(w, http.StatusUnauthorized, "unauthorized", err.Error(), nil)
+			return
+		}
+		h(w, r, caller)
+	})
+}
+
+func (api *analyticsAPI) handler(ep apiEndpoint) http.Handler {
+	return api.authenticated(http.MethodGet, func(w http.ResponseWriter, r *http.Request, caller apiCaller) {
+		tool := ep.newTool(caller.exec)
+		input, problems := ep.input(r)
+		if len(problems) == 0 {
+			problems = ep.queryProblems(toolkit.ValidateInput(tool.Schema(), input))
+		}
+		if len(problems) > 0 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_input", "fix the parameters listed in problems", problems)
+			return
+		}
+
+		wrapped := applyMiddleware([]core.Tool{tool}, api.middleware...)[0]
+		result, err := wrapped.Execute(r.Context(), &core.ToolParams{
+			UserID:    caller.userID,
+			Input:     input,
+			RequestID: toolkit.NewID("api"),
+		})
+		switch {
+		case err != nil:
+			writeAPIError(w, http.StatusBadGateway, "tool_failed", err.Error(), nil)
+		case !result.Success:
+			writeAPIError(w, http.StatusBadGateway, "tool_failed", result.Error, nil)
+		default:
+			writeJSON(w, http.StatusOK, result.Data)
+		}
+	})
+}
+
+// input builds the tool input from the query string. Parameters the
+// endpoint doesn't know are reported, so a typo isn't silently ignored.
+// Field names in problems are the query names the caller used.
+func (ep apiEndpoint) input(r *http.Request) (json.RawMessage, []toolkit.InputProblem) {
+	query := r.URL.Query()
+	input := make(map[string]interface{})
+	var problems []toolkit.InputProblem
+	known := map[string]bool{"token": true}
+	for _, p := range ep.params {
+		known[p.query] = true
+		raw := strings.TrimSpace(query.Get(p.query))
+		if raw == "" {
+			continue
+		}
+		switch p.kind {
+		case "integer":
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				problems = append(problems, toolkit.InputProblem{Field: p.query, Problem: "must be a whole number", Got: raw})
+				continue
+			}
+			input[p.field] = n
+		case "number":
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				problems = append(problems, toolkit.InputProblem{Field: p.query, Problem: "must be a number", Got: raw})
+				continue
+			}
+			input[p.field] = f
+		default:
+			input[p.field] = raw
+		}
+	}
+	var unknown []string
+	for name := range query {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, toolkit.InputProblem{Field: name, Problem: "is not a parameter of " + ep.path})
+	}
+	if len(problems) == 0 && ep.check != nil {
+		problems = ep.queryProblems(ep.check(input))
+	}
+	raw, _ := json.Marshal(input)
+	return raw, problems
+}
+
+// queryProblems renames the tool input fields in problems to the query
+// parameters they came from.
+func (ep apiEndpoint) queryProblems(problems []toolkit.InputProblem) []toolkit.InputProblem {
+	for i := range problems {
+		for _, p := range ep.params {
+			if problems[i].Field == p.field {
+				problems[i].Field = p.query
+				problems[i].Problem = strings.ReplaceAll(problems[i].Problem, p.field, p.query)
+			}
+		}
+	}
+	return problems
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		raw = []byte(fmt.Sprintf(`{"error":"encode_failed","message":%q}`, err.Error()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(raw, '\n'))
+}
+
+func writeAPIError(w http.ResponseWriter, status int, code, message string, problems []toolkit.InputProblem) {
+	body := map[string]interface{}{"error": code, "message": message}
+	if len(problems) > 0 {
+		body["problems"] = problems
+	}
+	writeJSON(w, status, body)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+const testAPIToken = "test-api-token"
+
+func newAPIServer(t *testing.T, apiToken string) *httptest.Server {
+	t.Helper()
+	captureLogs(t)
+	conf := config.Default()
+	conf.AnthropicKey = "test-key"
+	conf.Mock = true
+	conf.APIToken = apiToken
+	conf.DataDir = t.TempDir()
+	a, err := newApp(conf)
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+	t.Cleanup(func() { a.Close() })
+	srv := httptest.NewServer(a.Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func apiGet(t *testing.T, url, token string) (int, map[string]interface{}) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("%s: body is not JSON: %v", url, err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestAPIAnalyzers(t *testing.T) {
+	srv := newAPIServer(t, testAPIToken)
+
+	status, body := apiGet(t, srv.URL+"/api/spending?days=30", testAPIToken)
+	if status != http.StatusOK {
+		t.Fatalf("spending: %d %v", status, body)
+	}
+	analysis, _ := body["analysis"].(map[string]interface{})
+	if body["period_days"] != 30.0 || analysis["currency"] != "USD" || analysis["total_spent"] == nil {
+		t.Errorf("spending body = %v", body)
+	}
+
+	status, body = apiGet(t, srv.URL+"/api/subscriptions?months=6&currency=usd", testAPIToken)
+	if status != http.StatusOK {
+		t.Fatalf("subscriptions: %d %v", status, body)
+	}
+	if body["analysis_period"] != "6 months" || body["currency"] != "USD" || body["subscriptions"] == nil {
+		t.Errorf("subscriptions body = %v", body)
+	}
+
+	// ?token= works like it does for /ws.
+	if status, body := apiGet(t, srv.URL+"/api/spending?token="+testAPIToken, ""); status != http.StatusOK {
+		t.Errorf("token query parameter: %d %v", status, body)
+	}
+}
+
+func TestAPIRejects(t *testing.T) {
+	srv := newAPIServer(t, testAPIToken)
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		fields []string // fields named in problems
+	}{
+		{"no token", "/api/spending", "", http.StatusUnauthorized, nil},
+		{"wrong token", "/api/spending", "nope", http.StatusUnauthorized, nil},
+		{"out of range", "/api/spending?days=400", testAPIToken, http.StatusBadRequest, []string{"days"}},
+		{"not a number", "/api/subscriptions?months=six&min_amount=NaN", testAPIToken, http.StatusBadRequest, []string{"months", "min_amount"}},
+		{"cross-field", "/api/subscriptions?min_amount=50&max_amount=10", testAPIToken, http.StatusBadRequest, []string{"max_amount"}},
+		{"tool field name", "/api/subscriptions?months=30", testAPIToken, http.StatusBadRequest, []string{"months"}},
+		{"unknown parameter", "/api/spending?day=30", testAPIToken, http.StatusBadRequest, []string{"day"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := apiGet(t, srv.URL+tt.path, tt.token)
+			if status != tt.status {
+				t.Fatalf("status %d, want %d: %v", status, tt.status, body)
+			}
+			problems, _ := body["problems"].([]interface{})
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("problems %v, want fields %v", problems, tt.fields)
+			}
+			for i, p := range problems {
+				if field := p.(map[string]interface{})["field"]; field != tt.fields[i] {
+					t.Errorf("problem %d is about %v, want %s", i, field, tt.fields[i])
+				}
+			}
+		})
+	}
+
+	resp, err := http.Post(srv.URL+"/api/spending", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != "GET" {
+		t.Errorf("POST: %d, Allow %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+}
+
+func TestAPIMockNeedsToken(t *testing.T) {
+	srv := newAPIServer(t, "")
+	status, body := apiGet(t, srv.URL+"/api/spending", "anything")
+	if status != http.StatusUnauthorized || !strings.Contains(body["message"].(string), "API_TOKEN") {
+		t.Errorf("%d %v, want 401 naming API_TOKEN", status, body)
+	}
+}
+
+// testJWT is an unsigned JWT for sub; the fake Liminal servers decide
+// which ones they accept.
+func testJWT(sub string) string {
+	payload := fmt.Sprintf(`{"sub":%q,"exp":%d}`, sub, time.Now().Add(time.Hour).Unix())
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+}
+
+// fakeLiminal serves every Liminal endpoint with an empty transaction list,
+// to the tokens in accept only, and records the tokens it was called with.
+func fakeLiminal(t *testing.T, accept ...string) (*httptest.Server, func() map[string]bool) {
+	t.Helper()
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		mu.Lock()
+		seen[auth] = true
+		mu.Unlock()
+		if !slices.Contains(accept, strings.TrimPrefix(auth, "Bearer ")) {
+			http.Error(w, `{"message":"invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"transactions":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() map[string]bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return maps.Clone(seen)
+	}
+}
+
+// In live mode each request calls Liminal with its own JWT, once Liminal
+// has accepted it.
+func TestAPILiveForwardsEachJWT(t *testing.T) {
+	tokens := []string{testJWT("alice"), testJWT("bob")}
+	forged := testJWT("mallory")
+	liminal, seen := fakeLiminal(t, tokens...)
+
+	conf := config.Default()
+	conf.LiminalBaseURL = liminal.URL
+	api := newAnalyticsAPI(conf, analyzerEndpoints(nil), nil, newLiminalVerifier(liminalExecutors(liminal.URL)), func(exec core.ToolExecutor) core.ToolExecutor { return exec }, nil)
+	mux := http.NewServeMux()
+	api.register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, token := range []string{"not-a-jwt", "a.b.c", forged} {
+		if status, _ := apiGet(t, srv.URL+"/api/spending", token); status != http.StatusUnauthorized {
+			t.Errorf("token %q: %d, want 401", token, status)
+		}
+	}
+	var wg sync.WaitGroup
+	for _, token := range tokens {
+		wg.Add(1)
+		go func(token string) {
+			defer wg.Done()
+			if status, body := apiGet(t, srv.URL+"/api/spending?days=7", token); status != http.StatusOK {
+				t.Errorf("%s: %d %v", token, status, body)
+			}
+		}(token)
+	}
+	wg.Wait()
+	for _, token := range tokens {
+		if !seen()["Bearer "+token] {
+			t.Errorf("Liminal never saw %s; saw %v", token, seen())
+		}
+	}
+}
+
+// The dev frontend's origin gets a preflight answer and CORS headers on the
+// real request; other origins don't.
+func TestAPICORS(t *testing.T) {
+	srv := newAPIServer(t, testAPIToken)
+	request := func(method, origin string, header map[string]string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+"/api/spending?days=30", nil)
+		req.Header.Set("Origin", origin)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	preflight := map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "authorization"}
+
+	resp := request(http.MethodOptions, "http://localhost:5173", preflight)
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "http://localhost:5173" ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Errorf("preflight: %d %v", resp.StatusCode, resp.Header)
+	}
+	resp = request(http.MethodGet, "http://localhost:5173", map[string]string{"Authorization": "Bearer " + testAPIToken})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "http://localhost:5173" {
+		t.Errorf("GET: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	resp = request(http.MethodOptions, "http://evil.example", preflight)
+	if resp.StatusCode != http.StatusForbidden || resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("preflight from another origin: %d %v", resp.StatusCode, resp.Header)
+	}
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// ============================================================================
+// AUDIT LOG  –  append-only, hash-chained record of tool and executor calls
+// ============================================================================
+// Every line of the log is one JSON entry. Each entry carries the hash of the
+// previous one and its own hash over its contents plus that link, so editing,
+// removing or reordering any entry breaks the chain from that point on.
+// `go run . verify-audit` checks it.
+//
+// Entries are written from three places: tool calls (auditMiddleware), executor
+// calls that move money (auditingExecutor), and the confirmation store
+// (auditingConfirmations: requested, confirmed, cancelled). Inputs and
+// results are redacted before they're written.
+
+// auditGenesisHash is the "previous hash" of the first entry.
+const auditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// auditMaxResultBytes caps how much of a result is stored inline; larger
+// results are recorded by size and hash only.
+const auditMaxResultBytes = 4096
+
+type auditEntry struct {
+	Seq            int64           `json:"seq"`
+	Time           time.Time       `json:"time"`
+	Source         string          `json:"source"` // tool | executor | confirmation
+	Event          string          `json:"event"`
+	UserID         string          `json:"user_id,omitempty"`
+	Tool           string          `json:"tool,omitempty"`
+	RequestID      string          `json:"request_id,omitempty"`
+	ConfirmationID string          `json:"confirmation_id,omitempty"`
+	Summary        string          `json:"summary,omitempty"`
+	Input          json.RawMessage `json:"input,omitempty"`
+	Success        *bool           `json:"success,omitempty"`
+	Result         json.RawMessage `json:"result,omitempty"`
+	ResultSHA256   string          `json:"result_sha256,omitempty"`
+	ResultBytes    int             `json:"result_bytes,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	DurationMs     int64           `json:"duration_ms,omitempty"`
+	PrevHash       string          `json:"prev_hash"`
+	Hash           string          `json:"hash"`
+}
+
+// computeHash hashes the entry with Hash cleared. PrevHash is part of the
+// hashed content, which is what links the chain.
+func (e auditEntry) computeHash() (string, error) {
+	e.Hash = ""
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setResult stores a (redacted) result, inline if it's small enough.
+func (e *auditEntry) setResult(data json.RawMessage) {
+	if len(data) == 0 {
+		return
+	}
+	redacted := redactJSON(data)
+	if len(redacted) <= auditMaxResultBytes {
+		e.Result = redacted
+		return
+	}
+	sum := sha256.Sum256(redacted)
+	e.ResultSHA256 = hex.EncodeToString(sum[:])
+	e.ResultBytes = len(redacted)
+}
+
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  int64
+	last string
+}
+
+// openAuditLog opens (or creates) the log for appending and picks the chain
+// up from its last entry. A final line that doesn't parse is what a crash
+// in the middle of a write leaves behind; it's cut off with a warning so the
+// server can start. Anything unreadable earlier in the log is an error.
+// verify-audit doesn't forgive either.
+func openAuditLog(path string) (*auditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	l := &auditLog{last: auditGenesisHash}
+	last, end, torn, err := readAuditTail(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read audit log %s: %w", path, err)
+	}
+	if last != nil {
+		l.seq = last.Seq
+		l.last = last.Hash
+	}
+	if torn > 0 {
+		slog.Warn("audit: dropping a torn final entry", "path", path, "bytes", torn)
+		if err := os.Truncate(path, end); err != nil {
+			return nil, fmt.Errorf("truncate torn audit log %s: %w", path, err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log %s: %w", path, err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// Append links the entry to the chain and writes it.
+func (l *auditLog) Append(e auditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = l.seq + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.PrevHash = l.last
+	hash, err := e.computeHash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.seq = e.Seq
+	l.last = e.Hash
+	return nil
+}
+
+// record appends an entry, logging and counting rather than failing the
+// call on error: an audit write problem shouldn't turn a completed payment
+// into an error.
+func (l *auditLog) record(ctx context.Context, e auditEntry) {
+	if err := l.Append(e); err != nil {
+		auditWriteFailures.WithLabelValues(e.Source).Inc()
+		slog.ErrorContext(ctx, "audit: failed to write entry", "source", e.Source, "event", e.Event, "tool", e.Tool, "user_id", e.UserID, "request_id", e.RequestID, "error", err)
+	}
+}
+
+func (l *auditLog) Close() error {
+	return l.file.Close()
+}
+
+// scanAuditLog calls fn for each entry with its line number.
+func scanAuditLog(path string, fn func(line int, e auditEntry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("line %d: invalid entry: %w", line, err)
+		}
+		if err := fn(line, e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// readAuditTail returns the log's last entry and the offset just past it.
+// If the final line doesn't parse, torn is the number of bytes after end.
+// Entries aren't checked against each other; that's verifyAuditLog's job.
+func readAuditTail(path string) (last *auditEntry, end, torn int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	line := 0
+	var bad error
+	for {
+		raw, err := r.ReadBytes('\n')
+		if len(raw) > 0 {
+			line++
+			offset += int64(len(raw))
+			if len(strings.TrimSpace(string(raw))) > 0 {
+				if bad != nil {
+					return nil, 0, 0, bad
+				}
+				var e auditEntry
+				if jsonErr := json.Unmarshal(raw, &e); jsonErr != nil {
+					bad = fmt.Errorf("line %d: invalid entry: %w", line, jsonErr)
+				} else {
+					last, end = &e, offset
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	if bad != nil {
+		torn = offset - end
+	}
+	return last, end, torn, nil
+}
+
+// verifyAuditLog checks every link of the chain. It returns the number of
+// entries and the hash of the last one; keep that hash somewhere else to
+// detect entries being cut off the end later.
+func verifyAuditLog(path string) (int, string, error) {
+	count := 0
+	prev := auditGenesisHash
+	var seq int64
+	err := scanAuditLog(path, func(line int, e auditEntry) error {
+		if e.PrevHash != prev {
+			return fmt.Errorf("line %d (seq %d): chain broken: prev_hash %.12s… doesn't match the previous entry's hash %.12s…", line, e.Seq, e.PrevHash, prev)
+		}
+		if e.Seq != seq+1 {
+			return fmt.Errorf("line %d: expected seq %d, found %d", line, seq+1, e.Seq)
+		}
+		hash, err := e.computeHash()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if hash != e.Hash {
+			return fmt.Errorf("line %d (seq %d): entry was modified: hash doesn't match its contents", line, e.Seq)
+		}
+		prev = e.Hash
+		seq = e.Seq
+		count++
+		return nil
+	})
+	return count, prev, err
+}
+
+// runVerifyAudit implements `verify-audit [path]` and returns the exit code.
+func runVerifyAudit(args []string, defaultPath string) int {
+	path := defaultPath
+	if len(args) > 0 {
+		path = args[0]
+	}
+	count, last, err := verifyAuditLog(path)
+	if err != nil {
+		fmt.Printf("❌ Audit log %s failed verification after %d good entries: %v\n", path, count, err)
+		return 1
+	}
+	fmt.Printf("✅ Audit log %s verified: %d entries, chain intact\n", path, count)
+	fmt.Printf("   Last hash: %s\n", last)
+	return 0
+}
+
+// ---------------------------------------------------------------------------
+// tool middleware
+// ---------------------------------------------------------------------------
+
+// auditMiddleware records every call of a tool with its input and outcome.
+func auditMiddleware(log *auditLog) toolMiddleware {
+	return func(tool core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, params)
+
+			entry := auditEntry{
+				Source:         "tool",
+				Event:          "call",
+				UserID:         params.UserID,
+				Tool:           tool.Name(),
+				RequestID:      params.RequestID,
+				ConfirmationID: params.ConfirmationID,
+				Input:          redactJSON(params.Input),
+				DurationMs:     time.Since(start).Milliseconds(),
+			}
+			switch {
+			case err != nil:
+				entry.Error = err.Error()
+			case result != nil:
+				entry.Success = &result.Success
+				entry.Error = result.Error
+				if data, mErr := json.Marshal(result.Data); mErr == nil && result.Data != nil {
+					entry.setResult(data)
+				}
+			}
+			log.record(ctx, entry)
+			return result, err
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// executor decorator
+// ---------------------------------------------------------------------------
+
+// auditingExecutor records writes, confirmations and cancellations sent to
+// the executor. Reads aren't recorded here; the tool that made them is.
+type auditingExecutor struct {
+	next core.ToolExecutor
+	log  *auditLog
+}
+
+var _ core.ToolExecutor = (*auditingExecutor)(nil)
+
+func newAuditingExecutor(next core.ToolExecutor, log *auditLog) *auditingExecutor {
+	return &auditingExecutor{next: next, log: log}
+}
+
+func (e *auditingExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return e.next.Execute(ctx, req)
+}
+
+func (e *auditingExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	start := time.Now()
+	resp, err := e.next.ExecuteWrite(ctx, req)
+	entry := auditEntry{
+		Source:     "executor",
+		Event:      "execute_write",
+		UserID:     req.UserID,
+		Tool:       req.Tool,
+		RequestID:  req.RequestID,
+		Input:      redactJSON(req.Input),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	e.log.record(ctx, withResponse(entry, resp, err))
+	return resp, err
+}
+
+func (e *auditingExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	start := time.Now()
+	resp, err := e.next.Confirm(ctx, userID, confirmationID)
+	entry := auditEntry{
+		Source:         "executor",
+		Event:          "confirm",
+		UserID:         userID,
+		ConfirmationID: confirmationID,
+		DurationMs:     time.Since(start).Milliseconds(),
+	}
+	e.log.record(ctx, withResponse(entry, resp, err))
+	return resp, err
+}
+
+func (e *auditingExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	err := e.next.Cancel(ctx, userID, confirmationID)
+	entry := auditEntry{
+		Source:         "executor",
+		Event:          "cancel",
+		UserID:         userID,
+		ConfirmationID: confirmationID,
+	}
+	ok := err == nil
+	entry.Success = &ok
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	e.log.record(ctx, entry)
+	return err
+}
+
+func withResponse(entry auditEntry, resp *core.ExecuteResponse, err error) auditEntry {
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	if resp == nil {
+		return entry
+	}
+	entry.Success = &resp.Success
+	entry.Error = resp.Error
+	entry.setResult(resp.Data)
+	if resp.RequiresConfirmation && resp.Confirmation != nil {
+		entry.ConfirmationID = resp.Confirmation.ID
+		entry.Summary = resp.Confirmation.Summary
+	}
+	return entry
+}
+
+// ---------------------------------------------------------------------------
+// confirmation store decorator
+// ---------------------------------------------------------------------------
+
+// auditingConfirmations records when a confirmation card is raised, and
+// whether the user confirmed or cancelled it.
+type auditingConfirmations struct {
+	store.Confirmations
+	log *auditLog
+}
+
+func auditConfirmations(next store.Confirmations, log *auditLog) store.Confirmations {
+	return &auditingConfirmations{Confirmations: next, log: log}
+}
+
+func (a *auditingConfirmations) Store(ctx context.Context, action *core.PendingAction) error {
+	err := a.Confirmations.Store(ctx, action)
+	entry := auditEntry{
+		Source:         "confirmation",
+		Event:          "requested",
+		UserID:         action.UserID,
+		Tool:           action.Tool,
+		RequestID:      action.SessionID,
+		ConfirmationID: action.ID,
+		Summary:        action.Summary,
+		Input:          redactJSON(action.Input),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.log.record(ctx, entry)
+	return err
+}
+
+func (a *auditingConfirmations) Confirm(ctx context.Context, userID, actionID string) (*core.PendingAction, error) {
+	action, err := a.Confirmations.Confirm(ctx, userID, actionID)
+	entry := auditEntry{
+		Source:         "confirmation",
+		Event:          "confirmed",
+		UserID:         userID,
+		ConfirmationID: actionID,
+	}
+	if action != nil {
+		entry.Tool = action.Tool
+		entry.Summary = action.Summary
+		entry.RequestID = action.SessionID
+	}
+	ok := err == nil
+	entry.Success = &ok
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.log.record(ctx, entry)
+	return action, err
+}
+
+func (a *auditingConfirmations) Cancel(ctx context.Context, userID, actionID string) error {
+	// Look the action up first so the entry says what was cancelled.
+	action, _ := a.Confirmations.Get(ctx, userID, actionID)
+	err := a.Confirmations.Cancel(ctx, userID, actionID)
+	entry := auditEntry{
+		Source:         "confirmation",
+		Event:          "cancelled",
+		UserID:         userID,
+		ConfirmationID: actionID,
+	}
+	if action != nil {
+		entry.Tool = action.Tool
+		entry.Summary = action.Summary
+		entry.RequestID = action.SessionID
+	}
+	ok := err == nil
+	entry.Success = &ok
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	a.log.record(ctx, entry)
+	return err
+}
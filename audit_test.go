@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// A failed write is logged with the entry's IDs and counted, not returned.
+func TestAuditRecordFailure(t *testing.T) {
+	logs := &syncBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	l, err := openAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close() // every write fails from here on
+
+	before := testutil.ToFloat64(auditWriteFailures.WithLabelValues("executor"))
+	l.record(context.Background(), auditEntry{Source: "executor", Event: "execute_write", Tool: "send_money", UserID: "u1", RequestID: "req_1"})
+	if got := testutil.ToFloat64(auditWriteFailures.WithLabelValues("executor")); got != before+1 {
+		t.Errorf("failures = %v, want %v", got, before+1)
+	}
+	for _, want := range []string{"level=ERROR", "audit: failed to write entry", "tool=send_money", "user_id=u1", "request_id=req_1"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs %q don't contain %q", logs.String(), want)
+		}
+	}
+}
+
+// verifyAuditLog finds an edited entry, a removed one and a final line cut
+// short, and reports how many entries were good before the problem.
+func TestVerifyAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, summary := range []string{"Send 10.00 USD to @alice", "Send 20.00 USD to @bob", "Send 30.00 USD to @carol"} {
+		if err := l.Append(auditEntry{Source: "confirmation", Event: "requested", UserID: "u1", Tool: "send_money", Summary: summary}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(string(raw), "\n"), "\n")
+
+	count, last, err := verifyAuditLog(path)
+	if err != nil || count != 3 || last != l.last {
+		t.Fatalf("intact log: %d entries, last %s, %v; want 3, %s", count, last, err, l.last)
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		good  int
+		want  string
+	}{
+		{
+			name:  "tampered entry",
+			lines: []string{lines[0], strings.Replace(lines[1], "20.00", "2.00", 1), lines[2]},
+			good:  1,
+			want:  "line 2 (seq 2): entry was modified",
+		},
+		{
+			name:  "dropped entry",
+			lines: []string{lines[0], lines[2]},
+			good:  1,
+			want:  "line 2 (seq 3): chain broken",
+		},
+		{
+			name:  "truncated final line",
+			lines: []string{lines[0], lines[1], lines[2][:len(lines[2])/2]},
+			good:  2,
+			want:  "line 3: invalid entry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			if err := os.WriteFile(path, []byte(strings.Join(tt.lines, "")), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			count, _, err := verifyAuditLog(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || count != tt.good {
+				t.Errorf("%d good entries, %v; want %d and %q", count, err, tt.good, tt.want)
+			}
+		})
+	}
+}
+
+// A final line cut short by a crash is dropped when the log is reopened, and
+// the chain carries on from the entry before it. Garbage earlier in the log
+// still stops the server.
+func TestOpenAuditLogTornTail(t *testing.T) {
+	captureLogs(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := openAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, summary := range []string{"Send 10.00 USD to @alice", "Send 20.00 USD to @bob"} {
+		if err := l.Append(auditEntry{Source: "confirmation", Event: "requested", UserID: "u1", Tool: "send_money", Summary: summary}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	torn := append(slices.Clone(raw), `{"seq":3,"time":"2026-`...)
+	if err := os.WriteFile(path, torn, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l, err = openAuditLog(path)
+	if err != nil {
+		t.Fatalf("reopen after a torn write: %v", err)
+	}
+	if err := l.Append(auditEntry{Source: "confirmation", Event: "confirmed", UserID: "u1", Tool: "send_money"}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if count, _, err := verifyAuditLog(path); err != nil || count != 3 {
+		t.Errorf("after recovery: %d entries, %v; want 3 and an intact chain", count, err)
+	}
+
+	lines := strings.SplitAfter(string(raw), "\n")
+	garbled := lines[0] + "{not json\n" + lines[1]
+	if err := os.WriteFile(path, []byte(garbled), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openAuditLog(path); err == nil || !strings.Contains(err.Error(), "line 2: invalid entry") {
+		t.Errorf("garbage mid-log: %v, want an error", err)
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// CUSTOM TOOLS: BUDGETS
+// ============================================================================
+
+// budget is a monthly spending limit on either a spending category (see
+// analytics.Categories) or a merchant, matched against transaction
+// descriptions.
+type budget struct {
+	ID        string    `json:"id"`
+	Scope     string    `json:"scope"` // category | merchant
+	Name      string    `json:"name"`
+	Limit     float64   `json:"monthly_limit"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// matches reports whether a spending transaction counts against the budget.
+func (b budget) matches(tx map[string]interface{}) bool {
+	if analytics.TxCurrency(tx) != b.Currency {
+		return false
+	}
+	desc, _ := tx["description"].(string)
+	switch b.Scope {
+	case "category":
+		return analytics.Categorize(desc) == b.Name
+	case "merchant":
+		return strings.Contains(strings.ToLower(analytics.TxMerchant(tx)), strings.ToLower(b.Name))
+	}
+	return false
+}
+
+type budgetStore = jsonStore[[]budget]
+
+func createBudgetTools(liminalExecutor core.ToolExecutor, budgets *budgetStore) []core.Tool {
+	return []core.Tool{
+		createSetBudgetTool(budgets),
+		createDeleteBudgetTool(budgets),
+		createCheckBudgetTool(liminalExecutor, budgets),
+	}
+}
+
+func createSetBudgetTool(budgets *budgetStore) core.Tool {
+	return tools.New("set_budget").
+		Description("Create or update a monthly budget for a spending category or a merchant. Setting a budget that already exists for the same category/merchant updates its limit.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"scope":         tools.StringEnumProperty("Whether the budget applies to a spending category or a merchant", "category", "merchant"),
+			"name":          tools.StringProperty("Category (" + strings.Join(analytics.CategoryNames(), ", ") + ") or merchant name, e.g. 'Starbucks'"),
+			"monthly_limit": toolkit.Positive(tools.NumberProperty("Maximum amount to spend per calendar month")),
+			"currency":      tools.StringProperty("Currency code (default: USD)"),
+		}, "scope", "name", "monthly_limit")).
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				Scope        string  `json:"scope"`
+				Name         string  `json:"name"`
+				MonthlyLimit float64 `json:"monthly_limit"`
+				Currency     string  `json:"currency"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			params.Name = strings.TrimSpace(params.Name)
+			if params.Currency == "" {
+				params.Currency = "USD"
+			}
+			params.Currency = strings.ToUpper(params.Currency)
+
+			switch {
+			case params.Scope != "category" && params.Scope != "merchant":
+				return &core.ToolResult{Success: false, Error: "scope must be 'category' or 'merchant'"}, nil
+			case params.Name == "":
+				return &core.ToolResult{Success: false, Error: "name is required"}, nil
+			case params.MonthlyLimit <= 0:
+				return &core.ToolResult{Success: false, Error: "monthly_limit must be greater than zero"}, nil
+			}
+			if params.Scope == "category" {
+				params.Name = strings.ToLower(params.Name)
+				if !containsString(analytics.CategoryNames(), params.Name) {
+					return &core.ToolResult{
+						Success: false,
+						Error:   fmt.Sprintf("unknown category %q; valid categories: %s", params.Name, strings.Join(analytics.CategoryNames(), ", ")),
+					}, nil
+				}
+			}
+
+			var saved budget
+			created := false
+			err := budgets.Update(toolParams.UserID, func(list *[]budget) error {
+				now := time.Now()
+				for i, b := range *list {
+					if b.Scope == params.Scope && strings.EqualFold(b.Name, params.Name) && b.Currency == params.Currency {
+						(*list)[i].Limit = params.MonthlyLimit
+						(*list)[i].UpdatedAt = now
+						saved = (*list)[i]
+						return nil
+					}
+				}
+				saved = budget{
+					ID:        toolkit.NewID("bud"),
+					Scope:     params.Scope,
+					Name:      params.Name,
+					Limit:     params.MonthlyLimit,
+					Currency:  params.Currency,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+				*list = append(*list, saved)
+				created = true
+				return nil
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to save budget: %v", err),
+				}, nil
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"budget":  saved,
+					"created": created,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+func createDeleteBudgetTool(budgets *budgetStore) core.Tool {
+	return tools.New("delete_budget").
+		Description("Delete one of the user's budgets, by budget ID or by the category/merchant name it tracks.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"budget_id": tools.StringProperty("ID of the budget to delete"),
+			"name":      tools.StringProperty("Category or merchant name of the budget to delete (alternative to budget_id)"),
+		})).
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				BudgetID string `json:"budget_id"`
+				Name     string `json:"name"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+			if params.BudgetID == "" && params.Name == "" {
+				return &core.ToolResult{Success: false, Error: "budget_id or name is required"}, nil
+			}
+
+			var removed []budget
+			err := budgets.Update(toolParams.UserID, func(list *[]budget) error {
+				var kept []budget
+				for _, b := range *list {
+					if b.ID == params.BudgetID || (params.Name != "" && strings.EqualFold(b.Name, params.Name)) {
+						removed = append(removed, b)
+						continue
+					}
+					kept = append(kept, b)
+				}
+				if len(removed) == 0 {
+					return fmt.Errorf("no matching budget found")
+				}
+				*list = kept
+				return nil
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to delete budget: %v", err),
+				}, nil
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"deleted": removed,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+func createCheckBudgetTool(liminalExecutor core.ToolExecutor, budgets *budgetStore) core.Tool {
+	return tools.New("check_budget").
+		Description("Compare month-to-date spending against the user's budgets. Returns spent and remaining amounts, pace versus the calendar, and the projected end-of-month overrun for each budget.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"name": tools.StringProperty("Optional: only check the budget for this category or merchant"),
+		})).
+		Handler(func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			var selected []budget
+			for _, b := range budgets.Get(toolParams.UserID) {
+				if params.Name == "" || strings.EqualFold(b.Name, params.Name) {
+					selected = append(selected, b)
+				}
+			}
+			if len(selected) == 0 {
+				return &core.ToolResult{
+					Success: true,
+					Data: map[string]interface{}{
+						"budgets": []interface{}{},
+						"summary": "No budgets set up yet. Use set_budget to create one.",
+					},
+				}, nil
+			}
+
+			now := time.Now()
+			monthStart := analytics.StartOfMonth(now)
+
+			transactions, err := analytics.FetchTransactions(ctx, liminalExecutor, toolParams, map[string]interface{}{
+				"limit":      500,
+				"start_date": monthStart.Format("2006-01-02"),
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to fetch transactions: %v", err),
+				}, nil
+			}
+
+			statuses := evaluateBudgets(selected, transactions, now)
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"month":         now.Format("2006-01"),
+					"days_elapsed":  now.Day(),
+					"days_in_month": analytics.DaysInMonth(now),
+					"budgets":       statuses,
+					"generated_at":  now.Format(time.RFC3339),
+				},
+			}, nil
+		}).
+		Build()
+}
+
+// evaluateBudgets computes month-to-date status for each budget. Pace compares
+// the share of the budget already spent with the share of the month already
+// elapsed; the projection extrapolates the current daily rate to month end.
+func evaluateBudgets(budgets []budget, transactions []map[string]interface{}, now time.Time) []map[string]interface{} {
+	monthStart := analytics.StartOfMonth(now)
+	totalDays := float64(analytics.DaysInMonth(now))
+	elapsedDays := float64(now.Day())
+	calendarPct := elapsedDays / totalDays * 100
+
+	statuses := make([]map[string]interface{}, 0, len(budgets))
+	for _, b := range budgets {
+		var spent float64
+		var count int
+		for _, tx := range transactions {
+			if txType, _ := tx["type"].(string); txType != "send" {
+				continue
+			}
+			if t, ok := analytics.TxTime(tx); !ok || t.Before(monthStart) || t.After(now) {
+				continue
+			}
+			if !b.matches(tx) {
+				continue
+			}
+			spent += analytics.TxAmount(tx)
+			count++
+		}
+
+		spentPct := spent / b.Limit * 100
+		projected := spent / elapsedDays * totalDays
+		overrun := math.Max(0, projected-b.Limit)
+
+		var pace string
+		switch {
+		case spent > b.Limit:
+			pace = "over_budget"
+		case spentPct > calendarPct+10:
+			pace = "ahead_of_pace"
+		case spentPct < calendarPct-10:
+			pace = "under_pace"
+		default:
+			pace = "on_track"
+		}
+
+		statuses = append(statuses, map[string]interface{}{
+			"budget_id":         b.ID,
+			"scope":             b.Scope,
+			"name":              b.Name,
+			"currency":          b.Currency,
+			"monthly_limit":     b.Limit,
+			"spent":             analytics.RoundMoney(spent),
+			"transactions":      count,
+			"remaining":         analytics.RoundMoney(b.Limit - spent),
+			"percent_used":      math.Round(spentPct*10) / 10,
+			"percent_of_month":  math.Round(calendarPct*10) / 10,
+			"pace":              pace,
+			"projected_spend":   analytics.RoundMoney(projected),
+			"projected_overrun": analytics.RoundMoney(overrun),
+			"safe_daily_spend":  analytics.RoundMoney(math.Max(0, b.Limit-spent) / math.Max(1, totalDays-elapsedDays+1)),
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i]["percent_used"].(float64) > statuses[j]["percent_used"].(float64)
+	})
+	return statuses
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
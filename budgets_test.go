@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Halfway through April, pace compares the share of the budget spent with
+// the 50% of the month gone, with ten points either side counted on track.
+func TestEvaluateBudgetsPace(t *testing.T) {
+	now := time.Date(2026, 4, 15, 18, 0, 0, 0, time.UTC)
+	coffee := budget{ID: "bud_1", Scope: "merchant", Name: "blue bottle", Limit: 100, Currency: "USD"}
+	tx := func(date, desc string, amount float64, currency string) map[string]interface{} {
+		return map[string]interface{}{"type": "send", "date": date, "description": desc, "amount": amount, "currency": currency}
+	}
+	// None of these count: last month, another currency, another merchant,
+	// money coming in, and a payment after now.
+	noise := []map[string]interface{}{
+		tx("2026-03-31", "Blue Bottle Coffee", 500, "USD"),
+		tx("2026-04-02", "Blue Bottle Coffee", 500, "EUR"),
+		tx("2026-04-03", "Whole Foods", 500, "USD"),
+		{"type": "receive", "date": "2026-04-04", "description": "Blue Bottle Coffee", "amount": 500.0},
+		tx("2026-04-20", "Blue Bottle Coffee", 500, "USD"),
+	}
+
+	tests := []struct {
+		spent       float64
+		wantPace    string
+		wantOverrun float64
+	}{
+		{120, "over_budget", 140},
+		{100, "ahead_of_pace", 100},
+		{60.01, "ahead_of_pace", 20.02},
+		{59.99, "on_track", 19.98},
+		{50, "on_track", 0},
+		{40.01, "on_track", 0},
+		{39.99, "under_pace", 0},
+		{0, "under_pace", 0},
+	}
+	for _, tt := range tests {
+		transactions := append([]map[string]interface{}{tx("2026-04-10", "Blue Bottle Coffee", tt.spent, "USD")}, noise...)
+		status := evaluateBudgets([]budget{coffee}, transactions, now)[0]
+
+		if status["spent"] != tt.spent || status["pace"] != tt.wantPace {
+			t.Errorf("spent %.2f: spent = %v, pace = %v, want %s", tt.spent, status["spent"], status["pace"], tt.wantPace)
+		}
+		if status["percent_of_month"] != 50.0 {
+			t.Errorf("spent %.2f: percent_of_month = %v, want 50", tt.spent, status["percent_of_month"])
+		}
+		if status["projected_overrun"] != tt.wantOverrun {
+			t.Errorf("spent %.2f: projected_overrun = %v, want %v", tt.spent, status["projected_overrun"], tt.wantOverrun)
+		}
+	}
+}
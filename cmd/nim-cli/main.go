@@ -0,0 +1,425 @@
+// nim-cli is a terminal client for the agent's WebSocket API, for poking the
+// agent without running the frontend.
+//
+//	go run ./cmd/nim-cli                          # interactive chat
+//	go run ./cmd/nim-cli -resume conv_123         # continue a conversation
+//	go run ./cmd/nim-cli --once "what's my balance?"
+//
+// Replies stream to the terminal as they arrive. A confirm_request becomes a
+// y/n prompt that counts down to the action's expiry; with --once the answer
+// can be piped in (echo y | nim-cli --once ...) or given with -yes, and
+// anything else cancels. --once exits 1 if the agent reports an error.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/server"
+	"github.com/gorilla/websocket"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run is the whole command, with its streams passed in so tests can drive it.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("nim-cli", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	wsURL := fs.String("url", envOr("NIM_URL", "ws://localhost:8080/ws"), "agent WebSocket URL (env NIM_URL)")
+	token := fs.String("token", os.Getenv("NIM_TOKEN"), "Liminal JWT sent as ?token= (env NIM_TOKEN)")
+	resume := fs.String("resume", "", "resume this conversation ID instead of starting a new one")
+	once := fs.String("once", "", "send this message, print the reply and exit")
+	yes := fs.Bool("yes", false, "confirm every action without asking")
+	timeout := fs.Duration("timeout", 2*time.Minute, "how long to wait for the agent between events")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	target, err := dialURL(*wsURL, *token)
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid -url: %v\n", err)
+		return 2
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(target, nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "connect to %s: %v\n", *wsURL, err)
+		return 1
+	}
+	defer conn.Close()
+
+	c := newChatClient(conn, stdin, stdout, *timeout)
+	c.assumeYes = *yes
+	c.countdown = isTerminal(stdout)
+
+	if err := c.start(*resume); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	if *once != "" {
+		if err := c.turn(*once); err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if err := c.repl(); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// dialURL adds the token to the WebSocket URL the way the server's default
+// auth reads it.
+func dialURL(raw, token string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "ws", "wss":
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("%q is not a ws(s) URL", raw)
+	}
+	if token != "" {
+		q := u.Query()
+		q.Set("token", token)
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// isTerminal reports whether w is a character device, so the countdown can
+// redraw its line in place without filling a pipe or file with updates.
+func isTerminal(w interface{}) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ============================================================================
+// CHAT CLIENT  –  one WebSocket conversation driven from the terminal
+// ============================================================================
+// Server events and input lines are each read by their own goroutine into a
+// channel, so a pending confirmation can wait on the user, the clock and the
+// connection at once.
+
+type chatClient struct {
+	conn    *websocket.Conn
+	out     io.Writer
+	timeout time.Duration
+
+	events  <-chan server.ServerMessage
+	readErr <-chan error
+	lines   <-chan string // closed at end of input
+
+	assumeYes bool // -yes
+	countdown bool // redraw the confirmation prompt every second
+
+	conversationID string
+}
+
+func newChatClient(conn *websocket.Conn, in io.Reader, out io.Writer, timeout time.Duration) *chatClient {
+	events := make(chan server.ServerMessage)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			var msg server.ServerMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				readErr <- err
+				close(events)
+				return
+			}
+			events <- msg
+		}
+	}()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	return &chatClient{conn: conn, out: out, timeout: timeout, events: events, readErr: readErr, lines: lines}
+}
+
+// next waits for the next server event.
+func (c *chatClient) next() (server.ServerMessage, error) {
+	select {
+	case msg, ok := <-c.events:
+		if !ok {
+			return msg, fmt.Errorf("connection closed: %v", <-c.readErr)
+		}
+		return msg, nil
+	case <-time.After(c.timeout):
+		return server.ServerMessage{}, fmt.Errorf("no reply from the agent in %s", c.timeout)
+	}
+}
+
+func (c *chatClient) send(msg server.ClientMessage) error {
+	if err := c.conn.WriteJSON(msg); err != nil {
+		return fmt.Errorf("send %s: %w", msg.Type, err)
+	}
+	return nil
+}
+
+// start opens a new conversation, or resumes one and prints its history.
+func (c *chatClient) start(resumeID string) error {
+	msg := server.ClientMessage{Type: "new_conversation"}
+	if resumeID != "" {
+		msg = server.ClientMessage{Type: "resume_conversation", ConversationID: resumeID}
+	}
+	if err := c.send(msg); err != nil {
+		return err
+	}
+	for {
+		ev, err := c.next()
+		if err != nil {
+			return err
+		}
+		switch ev.Type {
+		case "conversation_started":
+			c.conversationID = ev.ConversationID
+			return nil
+		case "conversation_resumed":
+			c.conversationID = ev.ConversationID
+			c.printHistory(ev.Messages)
+			return nil
+		case "error":
+			return fmt.Errorf("agent error: %s", ev.Content)
+		}
+	}
+}
+
+// printHistory shows a resumed conversation's stored messages. They arrive
+// as the SDK's store messages, decoded into generic maps.
+func (c *chatClient) printHistory(messages interface{}) {
+	list, _ := messages.([]interface{})
+	for _, m := range list {
+		fields, _ := m.(map[string]interface{})
+		role, _ := fields["role"].(string)
+		content, _ := fields["content"].(string)
+		if content == "" {
+			continue
+		}
+		if role == "user" {
+			fmt.Fprintf(c.out, "you> %s\n", content)
+		} else {
+			fmt.Fprintf(c.out, "nim> %s\n", content)
+		}
+	}
+	fmt.Fprintf(c.out, "(resumed %s)\n", c.conversationID)
+}
+
+// repl reads messages until end of input or /quit. /new starts a fresh
+// conversation.
+func (c *chatClient) repl() error {
+	fmt.Fprintf(c.out, "Connected (conversation %s). Type /new for a new conversation, /quit to leave.\n", c.conversationID)
+	for {
+		fmt.Fprint(c.out, "you> ")
+		line, ok := <-c.lines
+		if !ok {
+			fmt.Fprintln(c.out)
+			return nil
+		}
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			continue
+		case "/quit", "/exit":
+			return nil
+		case "/new":
+			if err := c.start(""); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "(new conversation %s)\n", c.conversationID)
+			continue
+		}
+		if err := c.turn(line); err != nil {
+			// The conversation carries on after an agent error; only a lost
+			// connection ends the session.
+			var agentErr agentError
+			if !errors.As(err, &agentErr) {
+				return err
+			}
+			fmt.Fprintf(c.out, "error: %s\n", agentErr)
+		}
+	}
+}
+
+// agentError is an error event from the server, as opposed to a failure of
+// the connection.
+type agentError string
+
+func (e agentError) Error() string { return "agent error: " + string(e) }
+
+// turn sends one message and prints the reply until the agent completes, or
+// until a confirmation is answered, declined or expires.
+func (c *chatClient) turn(content string) error {
+	if err := c.send(server.ClientMessage{Type: "message", Content: content}); err != nil {
+		return err
+	}
+	streamed := false // the current reply arrived as text_chunk events
+	for {
+		ev, err := c.next()
+		if err != nil {
+			return err
+		}
+		switch ev.Type {
+		case "text_chunk":
+			if !streamed {
+				fmt.Fprint(c.out, "nim> ")
+				streamed = true
+			}
+			fmt.Fprint(c.out, ev.Content)
+		case "text":
+			// With streaming on, text repeats what the chunks already showed.
+			if streamed {
+				fmt.Fprintln(c.out)
+			} else {
+				fmt.Fprintf(c.out, "nim> %s\n", ev.Content)
+			}
+			streamed = false
+		case "confirm_request":
+			if streamed {
+				fmt.Fprintln(c.out)
+				streamed = false
+			} else if ev.Content != "" {
+				fmt.Fprintf(c.out, "nim> %s\n", ev.Content)
+			}
+			answered, err := c.confirm(ev)
+			if err != nil || !answered {
+				// No answer means no further events: the server's turn ended
+				// with the request.
+				return err
+			}
+		case "complete":
+			if streamed {
+				fmt.Fprintln(c.out)
+			}
+			return nil
+		case "error":
+			if streamed {
+				fmt.Fprintln(c.out)
+			}
+			return agentError(ev.Content)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// confirmations
+// ---------------------------------------------------------------------------
+
+// confirm asks whether to run the pending action and sends the answer. It
+// reports false if the action expired, or input ended, before an answer was
+// sent; an ended input cancels the action.
+func (c *chatClient) confirm(req server.ServerMessage) (bool, error) {
+	question := fmt.Sprintf("Confirm %s?", req.Tool)
+	if req.Summary != "" {
+		question = fmt.Sprintf("Confirm %s: %s?", req.Tool, req.Summary)
+	}
+	if c.assumeYes {
+		fmt.Fprintf(c.out, "%s yes (-yes)\n", question)
+		return true, c.send(server.ClientMessage{Type: "confirm", ActionID: req.ActionID})
+	}
+
+	expires, err := time.Parse(time.RFC3339, req.ExpiresAt)
+	hasExpiry := err == nil
+	prompt := func() {
+		if !hasExpiry {
+			fmt.Fprintf(c.out, "%s [y/N] ", question)
+			return
+		}
+		left := time.Until(expires).Round(time.Second)
+		if left < 0 {
+			left = 0
+		}
+		if c.countdown {
+			// Redraw in place; \033[K clears what's left of a longer
+			// previous prompt.
+			fmt.Fprintf(c.out, "\r%s [y/N] (expires in %s) \033[K", question, formatCountdown(left))
+			return
+		}
+		fmt.Fprintf(c.out, "%s [y/N] (expires in %s) ", question, formatCountdown(left))
+	}
+
+	var expired <-chan time.Time
+	if hasExpiry {
+		timer := time.NewTimer(time.Until(expires))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var tick <-chan time.Time
+	if c.countdown && hasExpiry {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	prompt()
+	for {
+		select {
+		case <-tick:
+			prompt()
+		case <-expired:
+			fmt.Fprintf(c.out, "\nThe confirmation expired; ask again to retry.\n")
+			return false, nil
+		case line, ok := <-c.lines:
+			if !ok {
+				fmt.Fprintln(c.out, "\nNo answer; cancelling.")
+				return true, c.send(server.ClientMessage{Type: "cancel", ActionID: req.ActionID})
+			}
+			if !c.countdown {
+				fmt.Fprintln(c.out)
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				return true, c.send(server.ClientMessage{Type: "confirm", ActionID: req.ActionID})
+			case "", "n", "no":
+				return true, c.send(server.ClientMessage{Type: "cancel", ActionID: req.ActionID})
+			}
+			fmt.Fprintln(c.out, "Please answer y or n.")
+			prompt()
+		}
+	}
+}
+
+// formatCountdown renders a duration as m:ss.
+func formatCountdown(d time.Duration) string {
+	secs := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
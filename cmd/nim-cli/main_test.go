@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/server"
+	"github.com/gorilla/websocket"
+)
+
+// fakeAgent is a /ws endpoint that answers each client frame from a script
+// and records what it received.
+type fakeAgent struct {
+	mu       sync.Mutex
+	received []server.ClientMessage
+	reply    func(server.ClientMessage) []server.ServerMessage
+}
+
+func (f *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	for {
+		var msg server.ClientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.received = append(f.received, msg)
+		f.mu.Unlock()
+		for _, out := range f.reply(msg) {
+			if err := conn.WriteJSON(out); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f *fakeAgent) frames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var types []string
+	for _, m := range f.received {
+		t := m.Type
+		if m.ActionID != "" {
+			t += ":" + m.ActionID
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+// sendMoneyAgent streams a reply, then asks to confirm a payment that
+// expires after expiresIn.
+func sendMoneyAgent(expiresIn time.Duration) *fakeAgent {
+	return &fakeAgent{reply: func(msg server.ClientMessage) []server.ServerMessage {
+		switch msg.Type {
+		case "new_conversation":
+			return []server.ServerMessage{{Type: "conversation_started", ConversationID: "conv_1"}}
+		case "message":
+			return []server.ServerMessage{
+				{Type: "text_chunk", Content: "Sending "},
+				{Type: "text_chunk", Content: "now."},
+				{Type: "confirm_request", ActionID: "act_1", Tool: "send_money", Summary: "Send $20.00 to @alice",
+					ExpiresAt: time.Now().Add(expiresIn).Format(time.RFC3339)},
+			}
+		case "confirm":
+			return []server.ServerMessage{{Type: "text", Content: "Sent!"}, {Type: "complete"}}
+		case "cancel":
+			return []server.ServerMessage{{Type: "text", Content: "Action cancelled."}, {Type: "complete"}}
+		}
+		return []server.ServerMessage{{Type: "error", Content: "unexpected " + msg.Type}}
+	}}
+}
+
+func runCLI(t *testing.T, agent *fakeAgent, stdin io.Reader, args ...string) (int, string, string) {
+	t.Helper()
+	srv := httptest.NewServer(agent)
+	defer srv.Close()
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-url", srv.URL + "/ws", "-timeout", "5s"}, args...), stdin, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestOnceStreamsReply(t *testing.T) {
+	agent := &fakeAgent{reply: func(msg server.ClientMessage) []server.ServerMessage {
+		if msg.Type == "new_conversation" {
+			return []server.ServerMessage{{Type: "conversation_started", ConversationID: "conv_1"}}
+		}
+		return []server.ServerMessage{
+			{Type: "text_chunk", Content: "Your balance "},
+			{Type: "text_chunk", Content: "is $2,847.50."},
+			{Type: "text", Content: "Your balance is $2,847.50."},
+			{Type: "complete"},
+		}
+	}}
+	code, out, errOut := runCLI(t, agent, strings.NewReader(""), "--once", "what's my balance?")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, errOut)
+	}
+	if want := "nim> Your balance is $2,847.50.\n"; out != want {
+		t.Errorf("output %q, want %q", out, want)
+	}
+}
+
+func TestOnceConfirmation(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdin  string
+		args   []string
+		frame  string
+		output string
+	}{
+		{"yes", "y\n", nil, "confirm:act_1", "Sent!"},
+		{"no", "n\n", nil, "cancel:act_1", "Action cancelled."},
+		{"retry after a bad answer", "maybe\nyes\n", nil, "confirm:act_1", "Please answer y or n."},
+		{"end of input cancels", "", nil, "cancel:act_1", "No answer; cancelling."},
+		{"-yes", "", []string{"-yes"}, "confirm:act_1", "yes (-yes)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := sendMoneyAgent(5 * time.Minute)
+			code, out, errOut := runCLI(t, agent, strings.NewReader(tt.stdin), append(tt.args, "--once", "send $20 to @alice")...)
+			if code != 0 {
+				t.Fatalf("exit %d: %s", code, errOut)
+			}
+			frames := agent.frames()
+			if got := frames[len(frames)-1]; got != tt.frame {
+				t.Errorf("last frame %s, want %s (all: %v)", got, tt.frame, frames)
+			}
+			for _, want := range []string{"nim> Sending now.\n", "Confirm send_money: Send $20.00 to @alice?", tt.output} {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q does not contain %q", out, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfirmationExpires(t *testing.T) {
+	agent := sendMoneyAgent(time.Second)
+	stdin, w := io.Pipe() // never answers
+	defer w.Close()
+	code, out, errOut := runCLI(t, agent, stdin, "--once", "send $20 to @alice")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "(expires in 0:0") || !strings.Contains(out, "The confirmation expired") {
+		t.Errorf("output %q, want a countdown and the expiry", out)
+	}
+	if frames := agent.frames(); len(frames) != 2 {
+		t.Errorf("frames %v, want no answer after the expiry", frames)
+	}
+}
+
+func TestOnceAgentError(t *testing.T) {
+	agent := &fakeAgent{reply: func(msg server.ClientMessage) []server.ServerMessage {
+		if msg.Type == "new_conversation" {
+			return []server.ServerMessage{{Type: "conversation_started", ConversationID: "conv_1"}}
+		}
+		return []server.ServerMessage{{Type: "error", Content: "Agent error: overloaded"}}
+	}}
+	code, _, errOut := runCLI(t, agent, strings.NewReader(""), "--once", "hi")
+	if code != 1 || !strings.Contains(errOut, "overloaded") {
+		t.Errorf("exit %d, stderr %q; want 1 and the error", code, errOut)
+	}
+}
+
+func TestResumeAndChat(t *testing.T) {
+	agent := &fakeAgent{reply: func(msg server.ClientMessage) []server.ServerMessage {
+		switch msg.Type {
+		case "resume_conversation":
+			return []server.ServerMessage{{Type: "conversation_resumed", ConversationID: msg.ConversationID, Messages: []map[string]string{
+				{"role": "user", "content": "hi"},
+				{"role": "assistant", "content": "Hello!"},
+			}}}
+		case "new_conversation":
+			return []server.ServerMessage{{Type: "conversation_started", ConversationID: "conv_2"}}
+		case "message":
+			if msg.Content == "boom" {
+				return []server.ServerMessage{{Type: "error", Content: "overloaded"}}
+			}
+			return []server.ServerMessage{{Type: "text", Content: "echo: " + msg.Content}, {Type: "complete"}}
+		}
+		return nil
+	}}
+	code, out, errOut := runCLI(t, agent, strings.NewReader("again\nboom\n/new\nhello\n/quit\nignored\n"), "-resume", "conv_1")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, errOut)
+	}
+	for _, want := range []string{
+		"you> hi\nnim> Hello!\n(resumed conv_1)\n",
+		"nim> echo: again\n",
+		"error: agent error: overloaded\n",
+		"(new conversation conv_2)\n",
+		"nim> echo: hello\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "ignored") {
+		t.Error("input after /quit was sent")
+	}
+}
+
+func TestDialURL(t *testing.T) {
+	tests := []struct{ raw, token, want string }{
+		{"ws://localhost:8080/ws", "", "ws://localhost:8080/ws"},
+		{"http://localhost:8080/ws", "jwt", "ws://localhost:8080/ws?token=jwt"},
+		{"https://nim.example.com/ws?x=1", "a b", "wss://nim.example.com/ws?token=a+b&x=1"},
+	}
+	for _, tt := range tests {
+		if got, err := dialURL(tt.raw, tt.token); err != nil || got != tt.want {
+			t.Errorf("dialURL(%q, %q) = %q, %v; want %q", tt.raw, tt.token, got, err, tt.want)
+		}
+	}
+	if _, err := dialURL("ftp://x/ws", ""); err == nil {
+		t.Error("ftp URL accepted")
+	}
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/server"
+	"github.com/gorilla/websocket"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+// ============================================================================
+// CONVERSATION TESTS  –  scripted WebSocket conversations against the server
+// ============================================================================
+// Each file in testdata/scenarios is one conversation. The server is built
+// with newApp in mock mode, with a fresh data directory, and its model calls
+// are answered by the fake Anthropic API (see fakeanthropic.go) from the
+// scenario's model script. The test then plays the steps over /ws and checks
+// what comes back:
+//
+//	name: send money after confirming
+//	model:
+//	  turns:
+//	    - user_text: send $50 to @alice
+//	      reply:
+//	        - tool_use: send_money
+//	          input: {recipient: "@alice", amount: 50}
+//	steps:
+//	  - send: send $50 to @alice
+//	    expect: {event: confirm_request, tool: send_money}
+//	  - confirm: true
+//	    expect: {wallet_change: -50}
+//
+// Run a single scenario with: go test -run 'TestConversations/<name>'
+
+const scenarioUser = "default-user" // the SDK's user when there's no auth
+
+type scenario struct {
+	Name   string     `json:"name"`
+	DryRun bool       `json:"dry_run"`
+	Model  fakeScript `json:"model"`
+
+	// Schedules are the user's scheduled transfers at the start. Those
+	// without a next_run fall due a minute before the conversation starts.
+	Schedules []scheduledTransfer `json:"schedules"`
+
+	Steps []scenarioStep `json:"steps"`
+}
+
+// scenarioStep sends one frame and describes the replies it should get, or
+// runs the transfer scheduler once, in which case only the balance changes
+// are checked.
+type scenarioStep struct {
+	Send         string         `json:"send,omitempty"`
+	Confirm      bool           `json:"confirm,omitempty"` // the last confirm_request
+	Cancel       bool           `json:"cancel,omitempty"`  // the last confirm_request
+	RunScheduler bool           `json:"run_scheduler,omitempty"`
+	Expect       scenarioExpect `json:"expect"`
+}
+
+type scenarioExpect struct {
+	Event       string   `json:"event"`        // how the step ends: complete (default), confirm_request or error
+	Tool        string   `json:"tool"`         // the confirm_request's tool
+	Summary     string   `json:"summary"`      // text in the confirm_request's summary
+	Text        []string `json:"text"`         // text the replies contain
+	NotText     []string `json:"not_text"`     // text the replies must not contain
+	Streamed    bool     `json:"streamed"`     // the reply arrived as text_chunk events
+	ToolsCalled []string `json:"tools_called"` // tools whose results went back to the model, in order
+
+	// Balance changes since the conversation started.
+	WalletChange  *float64 `json:"wallet_change"`
+	SavingsChange *float64 `json:"savings_change"`
+}
+
+func TestConversations(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "scenarios", "*.yaml"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no scenarios found: %v", err)
+	}
+	for _, path := range paths {
+		sc, err := loadScenario(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(sc.Name, func(t *testing.T) {
+			runScenario(t, sc)
+		})
+	}
+}
+
+// loadScenario reads a YAML scenario. decodeYAML goes through JSON, so the
+// fake script's JSON field names and raw tool inputs work unchanged.
+func loadScenario(path string) (scenario, error) {
+	var sc scenario
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return sc, err
+	}
+	if err := decodeYAML(raw, &sc); err != nil {
+		return sc, fmt.Errorf("%s: %w", path, err)
+	}
+	if sc.Name == "" {
+		sc.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := sc.Model.validate(); err != nil {
+		return sc, fmt.Errorf("%s: model: %w", path, err)
+	}
+	return sc, nil
+}
+
+func runScenario(t *testing.T, sc scenario) {
+	captureLogs(t)
+
+	fake := newFakeAnthropic(sc.Model)
+	model := httptest.NewServer(fake)
+	defer model.Close()
+
+	conf := config.Default()
+	conf.AnthropicKey = "test-key"
+	conf.AnthropicBaseURL = model.URL
+	conf.Mock = true
+	conf.DryRun = sc.DryRun
+	conf.DataDir = t.TempDir()
+	a, err := newApp(conf)
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+	defer a.Close()
+	srv := httptest.NewServer(a.Handler())
+	defer srv.Close()
+
+	startWallet, startSavings := mockBalances(t, a)
+	checkBalances := func(label string, want scenarioExpect) {
+		if want.WalletChange != nil || want.SavingsChange != nil {
+			wallet, savings := mockBalances(t, a)
+			checkChange(t, label, "wallet", want.WalletChange, wallet-startWallet)
+			checkChange(t, label, "savings", want.SavingsChange, savings-startSavings)
+		}
+	}
+
+	if len(sc.Schedules) > 0 {
+		due := time.Now().Add(-time.Minute)
+		err := a.transfers.Update(scenarioUser, func(list *[]scheduledTransfer) error {
+			for _, s := range sc.Schedules {
+				if s.NextRun.IsZero() {
+					s.StartAt, s.NextRun = due, due
+				}
+				*list = append(*list, s)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("seed schedules: %v", err)
+		}
+	}
+
+	c := dialConversation(t, srv.URL)
+	defer c.conn.Close()
+	c.write(server.ClientMessage{Type: "new_conversation"})
+	if events := c.readUntil("conversation_started"); last(events).Type != "conversation_started" {
+		t.Fatalf("expected conversation_started, got %s", describe(events))
+	}
+
+	var pendingAction string
+	for i, step := range sc.Steps {
+		label := fmt.Sprintf("step %d", i+1)
+		requestsBefore := len(fake.Requests())
+
+		switch {
+		case step.RunScheduler:
+			a.scheduler().runDue(context.Background(), time.Now())
+			checkBalances(label+" (run scheduler)", step.Expect)
+			continue
+		case step.Send != "":
+			label += fmt.Sprintf(" (send %q)", step.Send)
+			c.write(server.ClientMessage{Type: "message", Content: step.Send})
+		case step.Confirm, step.Cancel:
+			if pendingAction == "" {
+				t.Fatalf("%s: nothing to confirm or cancel", label)
+			}
+			frame := "confirm"
+			if step.Cancel {
+				frame = "cancel"
+			}
+			label += " (" + frame + ")"
+			c.write(server.ClientMessage{Type: frame, ActionID: pendingAction})
+			pendingAction = ""
+		default:
+			t.Fatalf("%s: set one of send, confirm, cancel and run_scheduler", label)
+		}
+
+		events := c.readUntil("complete", "confirm_request", "error")
+		end := last(events)
+		if end.Type == "confirm_request" {
+			pendingAction = end.ActionID
+		}
+		checkStep(t, label, step.Expect, events, toolsCalled(fake.Requests()[requestsBefore:]))
+		checkBalances(label, step.Expect)
+	}
+}
+
+func checkStep(t *testing.T, label string, want scenarioExpect, events []server.ServerMessage, tools []string) {
+	t.Helper()
+	end := last(events)
+
+	wantEvent := want.Event
+	if wantEvent == "" {
+		wantEvent = "complete"
+	}
+	if end.Type != wantEvent {
+		t.Fatalf("%s: expected the step to end with %s, got %s", label, wantEvent, describe(events))
+	}
+	if want.Tool != "" && end.Tool != want.Tool {
+		t.Errorf("%s: expected confirm_request for %s, got %q", label, want.Tool, end.Tool)
+	}
+	if want.Summary != "" && !strings.Contains(end.Summary, want.Summary) {
+		t.Errorf("%s: expected summary containing %q, got %q", label, want.Summary, end.Summary)
+	}
+
+	var text strings.Builder
+	streamed := false
+	for _, e := range events {
+		switch e.Type {
+		case "text_chunk":
+			streamed = true
+			text.WriteString(e.Content)
+		case "text", "confirm_request", "error":
+			text.WriteString("\n" + e.Content)
+		}
+	}
+	for _, s := range want.Text {
+		if !strings.Contains(text.String(), s) {
+			t.Errorf("%s: expected text containing %q, got %q", label, s, text.String())
+		}
+	}
+	for _, s := range want.NotText {
+		if strings.Contains(text.String(), s) {
+			t.Errorf("%s: expected no %q in text, got %q", label, s, text.String())
+		}
+	}
+	if want.Streamed && !streamed {
+		t.Errorf("%s: expected text_chunk events, got %s", label, describe(events))
+	}
+	if want.ToolsCalled != nil && strings.Join(tools, ",") != strings.Join(want.ToolsCalled, ",") {
+		t.Errorf("%s: expected tools %v to be called, got %v", label, want.ToolsCalled, tools)
+	}
+}
+
+func checkChange(t *testing.T, label, balance string, want *float64, got float64) {
+	t.Helper()
+	if want != nil && math.Abs(*want-got) > 0.005 {
+		t.Errorf("%s: expected %s balance to change by %.2f, got %.2f", label, balance, *want, got)
+	}
+}
+
+// toolsCalled lists the tools whose results were sent to the model, in
+// order.
+func toolsCalled(requests []fakeRequest) []string {
+	var names []string
+	for _, req := range requests {
+		if len(req.Messages) == 0 {
+			continue
+		}
+		for _, c := range req.Messages[len(req.Messages)-1].Content {
+			if c.Type == "tool_result" {
+				names = append(names, toolNameFor(req.Messages, c.ToolUseID))
+			}
+		}
+	}
+	return names
+}
+
+// mockBalances reads the scenario user's balances through the app's executor.
+func mockBalances(t *testing.T, a *app) (wallet, savings float64) {
+	t.Helper()
+	read := func(tool string) float64 {
+		resp, err := a.exec.Execute(context.Background(), &core.ExecuteRequest{UserID: scenarioUser, Tool: tool, Input: json.RawMessage(`{}`)})
+		if err != nil || !resp.Success {
+			t.Fatalf("%s failed: %v %+v", tool, err, resp)
+		}
+		var out struct {
+			Balance float64 `json:"balance"`
+		}
+		if err := json.Unmarshal(resp.Data, &out); err != nil {
+			t.Fatalf("%s: %v", tool, err)
+		}
+		return out.Balance
+	}
+	return read("get_balance"), read("get_savings_balance")
+}
+
+// ---------------------------------------------------------------------------
+// WebSocket client
+// ---------------------------------------------------------------------------
+
+type conversationClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dialConversation(t *testing.T, baseURL string) *conversationClient {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(baseURL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	return &conversationClient{t: t, conn: conn}
+}
+
+func (c *conversationClient) write(msg server.ClientMessage) {
+	c.t.Helper()
+	if err := c.conn.WriteJSON(msg); err != nil {
+		c.t.Fatalf("send %s: %v", msg.Type, err)
+	}
+}
+
+// readUntil reads events up to and including the first of the given types.
+func (c *conversationClient) readUntil(types ...string) []server.ServerMessage {
+	c.t.Helper()
+	var events []server.ServerMessage
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		var msg server.ServerMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			c.t.Fatalf("waiting for %s: %v (got %s)", strings.Join(types, "/"), err, describe(events))
+		}
+		events = append(events, msg)
+		if containsString(types, msg.Type) {
+			return events
+		}
+	}
+}
+
+func last(events []server.ServerMessage) server.ServerMessage {
+	if len(events) == 0 {
+		return server.ServerMessage{}
+	}
+	return events[len(events)-1]
+}
+
+// describe summarizes events for failure messages.
+func describe(events []server.ServerMessage) string {
+	var parts []string
+	for _, e := range events {
+		if e.Type == "text_chunk" {
+			continue
+		}
+		part := e.Type
+		if e.Content != "" {
+			part += fmt.Sprintf(" %q", e.Content)
+		}
+		if e.Tool != "" {
+			part += " tool=" + e.Tool
+		}
+		parts = append(parts, part)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// captureLogs keeps the server's logs and prints them if the test fails.
+func captureLogs(t *testing.T) {
+	logs := &syncBuffer{}
+	previous := slog.Default()
+	setupLogging(logConfig{Level: slog.LevelInfo, Format: "text", Redact: redactNone}, logs)
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		if t.Failed() {
+			t.Logf("server logs:\n%s", logs.String())
+		}
+	})
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+)
+
+// ============================================================================
+// DRY RUN  –  preview a money movement without executing it
+// ============================================================================
+// send_money, deposit_savings and withdraw_savings accept "dry_run": true.
+// A dry run validates the input, resolves the recipient, checks the balance
+// and the spending policy, and reports what would happen, without calling
+// ExecuteWrite. With DRY_RUN=true every call is a dry run, and scheduled
+// transfers are previewed rather than run (see scheduler.go).
+//
+// Confirmation is decided per tool, not per call, so in live mode a single
+// dry run still goes through a confirmation card (its summary says it's a
+// dry run). Under DRY_RUN=true the tools stop asking for confirmation.
+
+// dryRunTools are the write tools that support dry runs.
+var dryRunTools = map[string]bool{
+	"send_money":       true,
+	"deposit_savings":  true,
+	"withdraw_savings": true,
+}
+
+// transferFee is the fee charged on a money movement, and whether it's known.
+// The Liminal API doesn't quote fees before a transfer, so it never is;
+// this is the one place to change if it starts to.
+func transferFee(tool string, amount float64) (float64, bool) {
+	return 0, false
+}
+
+type dryRunTool struct {
+	core.Tool
+	exec   core.ToolExecutor
+	policy *spendingPolicy
+	always bool
+}
+
+// withDryRun wraps the tools in list that support dry runs. always makes
+// every call a dry run (DRY_RUN=true).
+func withDryRun(exec core.ToolExecutor, policy *spendingPolicy, always bool, list []core.Tool) []core.Tool {
+	wrapped := make([]core.Tool, len(list))
+	for i, t := range list {
+		if dryRunTools[t.Name()] {
+			t = &dryRunTool{Tool: t, exec: exec, policy: policy, always: always}
+		}
+		wrapped[i] = t
+	}
+	return wrapped
+}
+
+func (t *dryRunTool) Schema() map[string]interface{} {
+	schema := make(map[string]interface{})
+	for k, v := range t.Tool.Schema() {
+		schema[k] = v
+	}
+	props := make(map[string]interface{})
+	if existing, ok := schema["properties"].(map[string]interface{}); ok {
+		for k, v := range existing {
+			props[k] = v
+		}
+	}
+	props["dry_run"] = tools.BooleanProperty("Preview the result (balances, fees, policy checks) without moving any money")
+	schema["properties"] = props
+	return schema
+}
+
+func (t *dryRunTool) RequiresConfirmation() bool {
+	return !t.always && t.Tool.RequiresConfirmation()
+}
+
+func (t *dryRunTool) GetSummary(input json.RawMessage) string {
+	summary := t.Tool.GetSummary(input)
+	if !t.isDryRun(input) {
+		return summary
+	}
+	if summary == "" {
+		if m, err := parseMoneyMovement(t.Name(), input); err == nil {
+			summary = m.String()
+		}
+	}
+	return "Dry run (no money moves): " + summary
+}
+
+func (t *dryRunTool) isDryRun(input json.RawMessage) bool {
+	if t.always {
+		return true
+	}
+	var p struct {
+		DryRun bool `json:"dry_run"`
+	}
+	_ = json.Unmarshal(input, &p)
+	return p.DryRun
+}
+
+func (t *dryRunTool) Execute(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+	if !t.isDryRun(params.Input) {
+		// Don't pass the flag on to the executor.
+		var fields map[string]interface{}
+		if err := json.Unmarshal(params.Input, &fields); err == nil {
+			if _, ok := fields["dry_run"]; ok {
+				delete(fields, "dry_run")
+				forward := *params
+				forward.Input, _ = json.Marshal(fields)
+				params = &forward
+			}
+		}
+		return t.Tool.Execute(ctx, params)
+	}
+
+	m, err := parseMoneyMovement(t.Name(), params.Input)
+	if err != nil {
+		return &core.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("invalid input: %v", err),
+		}, nil
+	}
+	return &core.ToolResult{
+		Success: true,
+		Data:    previewMovement(ctx, t.exec, t.policy, params, m),
+	}, nil
+}
+
+// previewMovement works out what a money movement would do. Problems that
+// would make it fail are listed rather than returned as an error, so the
+// model can report all of them at once.
+func previewMovement(ctx context.Context, exec core.ToolExecutor, policy *spendingPolicy, params *core.ToolParams, m moneyMovement) map[string]interface{} {
+	var problems []string
+	preview := map[string]interface{}{
+		"dry_run":  true,
+		"action":   m.String(),
+		"tool":     m.Tool,
+		"amount":   m.Amount,
+		"currency": m.Currency,
+	}
+
+	if m.Amount <= 0 {
+		problems = append(problems, "amount must be greater than zero")
+	}
+
+	if m.Tool == "send_money" {
+		if m.Recipient == "" {
+			problems = append(problems, "recipient is required")
+		} else if user, suggestions, err := resolveRecipient(ctx, exec, params, m.Recipient); err != nil {
+			problems = append(problems, fmt.Sprintf("couldn't look up %s: %v", m.Recipient, err))
+		} else if user == nil {
+			msg := fmt.Sprintf("no user found with the tag %s", m.Recipient)
+			if len(suggestions) > 0 {
+				msg += fmt.Sprintf(" (did you mean %s?)", strings.Join(suggestions, ", "))
+			}
+			problems = append(problems, msg)
+		} else {
+			preview["recipient"] = user
+		}
+	}
+
+	fee, feeKnown := transferFee(m.Tool, m.Amount)
+	if feeKnown {
+		preview["fees"] = fee
+	} else {
+		preview["fees"] = "unknown"
+		preview["fees_note"] = "Liminal doesn't quote fees in advance: any fee comes on top of total_debit and the balances shown."
+	}
+	preview["total_debit"] = analytics.RoundMoney(m.Amount + fee)
+
+	// Money leaves the wallet for send/deposit and the savings vault for
+	// withdrawals; deposits and withdrawals land in the other one.
+	needsWallet := m.Tool == "send_money" || m.Tool == "deposit_savings"
+	wallet, walletErr := analytics.ExecuteRead(ctx, exec, params, "get_balance", nil)
+	if walletErr != nil {
+		problems = append(problems, fmt.Sprintf("couldn't fetch wallet balance: %v", walletErr))
+	} else {
+		before := walletBalance(wallet, m.Currency)
+		after := before - m.Amount - fee
+		if m.Tool == "withdraw_savings" {
+			after = before + m.Amount - fee
+		}
+		preview["wallet_balance"] = map[string]interface{}{"before": analytics.RoundMoney(before), "after": analytics.RoundMoney(after)}
+		if needsWallet && after < 0 {
+			problems = append(problems, fmt.Sprintf("insufficient funds: wallet has %.2f %s", before, m.Currency))
+		}
+	}
+
+	if m.Tool != "send_money" {
+		savings, err := analytics.ExecuteRead(ctx, exec, params, "get_savings_balance", nil)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("couldn't fetch savings balance: %v", err))
+		} else {
+			before := savingsBalance(savings, m.Currency)
+			after := before + m.Amount
+			if m.Tool == "withdraw_savings" {
+				after = before - m.Amount
+			}
+			preview["savings_balance"] = map[string]interface{}{"before": analytics.RoundMoney(before), "after": analytics.RoundMoney(after)}
+			if m.Tool == "withdraw_savings" && after < 0 {
+				problems = append(problems, fmt.Sprintf("insufficient savings: vault has %.2f %s", before, m.Currency))
+			}
+		}
+	}
+
+	if policy != nil && policedTools[m.Tool] {
+		policy.seedPayees(ctx, params)
+		d := policy.Evaluate(params.UserID, m, time.Now())
+		preview["policy"] = d
+		switch d.Action {
+		case policyReject:
+			problems = append(problems, "blocked by spending policy: "+strings.Join(d.Reasons, "; "))
+		case policyEscalate:
+			preview["needs_approval"] = escalationMessage(m, d, policy.Limits(params.UserID))
+		}
+	}
+
+	preview["would_succeed"] = len(problems) == 0
+	if len(problems) > 0 {
+		preview["problems"] = problems
+	}
+	preview["message"] = "Preview only: no money was moved."
+	return preview
+}
+
+// resolveRecipient looks a display tag up with search_users. It returns the
+// matching user, or nil plus the tags that were found instead.
+func resolveRecipient(ctx context.Context, exec core.ToolExecutor, params *core.ToolParams, recipient string) (map[string]interface{}, []string, error) {
+	result, err := analytics.ExecuteRead(ctx, exec, params, "search_users", map[string]interface{}{"query": recipient})
+	if err != nil {
+		return nil, nil, err
+	}
+	want := normalizeRecipient(recipient)
+
+	var suggestions []string
+	users, _ := result["users"].([]interface{})
+	for _, u := range users {
+		user, ok := u.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tag, _ := user["tag"].(string)
+		if tag == "" {
+			tag, _ = user["display_tag"].(string)
+		}
+		if normalizeRecipient(tag) == want {
+			return user, nil, nil
+		}
+		if tag != "" {
+			suggestions = append(suggestions, tag)
+		}
+	}
+	return nil, suggestions, nil
+}
+
+// walletBalance reads the balance in one currency from get_balance, which
+// returns either a single balance or a list of per-currency balances.
+func walletBalance(balance map[string]interface{}, currency string) float64 {
+	topCurrency, _ := balance["currency"].(string)
+	if _, ok := balance["balance"]; ok && (topCurrency == "" || strings.EqualFold(topCurrency, currency)) {
+		return analytics.ToFloat(balance["balance"])
+	}
+
+	list, _ := balance["balances"].([]interface{})
+	for _, b := range list {
+		entry, ok := b.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if c, _ := entry["currency"].(string); !strings.EqualFold(c, currency) {
+			continue
+		}
+		for _, key := range []string{"balance", "amount", "available"} {
+			if v, ok := entry[key]; ok {
+				return analytics.ToFloat(v)
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/mock"
+)
+
+func TestPreviewMovement(t *testing.T) {
+	tests := []struct {
+		name         string
+		m            moneyMovement
+		wouldSucceed bool
+		wallet       [2]float64 // before, after
+		savings      *[2]float64
+	}{
+		{
+			name:         "send",
+			m:            moneyMovement{Tool: "send_money", Recipient: "@alice", Amount: 50, Currency: "USD"},
+			wouldSucceed: true,
+			wallet:       [2]float64{2847.50, 2797.50},
+		},
+		{
+			name:         "deposit",
+			m:            moneyMovement{Tool: "deposit_savings", Amount: 100, Currency: "USD"},
+			wouldSucceed: true,
+			wallet:       [2]float64{2847.50, 2747.50},
+			savings:      &[2]float64{15420.30, 15520.30},
+		},
+		{
+			name:         "withdraw",
+			m:            moneyMovement{Tool: "withdraw_savings", Amount: 20.30, Currency: "USD"},
+			wouldSucceed: true,
+			wallet:       [2]float64{2847.50, 2867.80},
+			savings:      &[2]float64{15420.30, 15400.00},
+		},
+		{
+			name:   "insufficient funds",
+			m:      moneyMovement{Tool: "send_money", Recipient: "@alice", Amount: 5000, Currency: "USD"},
+			wallet: [2]float64{2847.50, -2152.50},
+		},
+		{
+			name:   "unknown recipient",
+			m:      moneyMovement{Tool: "send_money", Recipient: "@nobody-here", Amount: 5, Currency: "USD"},
+			wallet: [2]float64{2847.50, 2842.50},
+		},
+		{
+			name:    "zero amount",
+			m:       moneyMovement{Tool: "deposit_savings", Amount: 0, Currency: "USD"},
+			wallet:  [2]float64{2847.50, 2847.50},
+			savings: &[2]float64{15420.30, 15420.30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &writeRecorder{ToolExecutor: mock.NewExecutor()}
+			preview := previewMovement(context.Background(), exec, nil, &core.ToolParams{UserID: "u1"}, tt.m)
+
+			if preview["would_succeed"] != tt.wouldSucceed {
+				t.Errorf("would_succeed = %v, want %v (problems %v)", preview["would_succeed"], tt.wouldSucceed, preview["problems"])
+			}
+			if preview["fees"] != "unknown" || preview["fees_note"] == nil {
+				t.Errorf("fees = %v, want reported as unknown", preview["fees"])
+			}
+			wallet, _ := preview["wallet_balance"].(map[string]interface{})
+			if wallet["before"] != tt.wallet[0] || wallet["after"] != tt.wallet[1] {
+				t.Errorf("wallet = %v, want %v", wallet, tt.wallet)
+			}
+			savings, _ := preview["savings_balance"].(map[string]interface{})
+			if tt.savings != nil && (savings["before"] != tt.savings[0] || savings["after"] != tt.savings[1]) {
+				t.Errorf("savings = %v, want %v", savings, *tt.savings)
+			}
+			if len(exec.writes) != 0 {
+				t.Errorf("writes = %v, want none", exec.writes)
+			}
+		})
+	}
+}
+
+// The preview runs the spending policy too: a rejection is a problem, an
+// escalation is reported as needing approval without failing the preview.
+func TestPreviewMovementPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		m            moneyMovement
+		wouldSucceed bool
+		newPayee     bool
+		wantAction   policyAction
+		wantApproval string
+	}{
+		{
+			name:         "allowed",
+			m:            moneyMovement{Tool: "send_money", Recipient: "@alice", Amount: 50, Currency: "USD"},
+			wouldSucceed: true,
+			wantAction:   policyAllow,
+		},
+		{
+			name:       "rejected",
+			m:          moneyMovement{Tool: "send_money", Recipient: "@alice", Amount: 600, Currency: "USD"},
+			wantAction: policyReject,
+		},
+		{
+			name:         "new payee",
+			m:            moneyMovement{Tool: "send_money", Recipient: "@alice", Amount: 50, Currency: "USD"},
+			wouldSucceed: true,
+			newPayee:     true,
+			wantAction:   policyEscalate,
+			wantApproval: "add_trusted_payee",
+		},
+		{
+			name:         "above the approval threshold",
+			m:            moneyMovement{Tool: "withdraw_savings", Amount: 450, Currency: "USD"},
+			wouldSucceed: true,
+			wantAction:   policyEscalate,
+			wantApproval: "approve_flagged_payment",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := config.DefaultSpendingLimits()
+			limits.PerTransaction = 500
+			limits.EscalateAbove = 400
+			policy, payees := newTestPolicy(t, nil, limits)
+			if tt.newPayee {
+				trustPayees(payees, "u1")
+			} else {
+				trustPayees(payees, "u1", "@alice")
+			}
+			exec := &writeRecorder{ToolExecutor: mock.NewExecutor()}
+			preview := previewMovement(context.Background(), exec, policy, &core.ToolParams{UserID: "u1"}, tt.m)
+
+			if preview["would_succeed"] != tt.wouldSucceed {
+				t.Errorf("would_succeed = %v, want %v (problems %v)", preview["would_succeed"], tt.wouldSucceed, preview["problems"])
+			}
+			if d, _ := preview["policy"].(policyDecision); d.Action != tt.wantAction {
+				t.Errorf("policy = %+v, want %s", preview["policy"], tt.wantAction)
+			}
+			approval, _ := preview["needs_approval"].(string)
+			if tt.wantApproval == "" && approval != "" || !strings.Contains(approval, tt.wantApproval) {
+				t.Errorf("needs_approval = %q, want it to mention %q", approval, tt.wantApproval)
+			}
+			if len(exec.writes) != 0 {
+				t.Errorf("writes = %v, want none", exec.writes)
+			}
+			if ledger := policy.state.Get("u1").Ledger; len(ledger) != 0 {
+				t.Errorf("ledger = %+v, want the preview not recorded", ledger)
+			}
+		})
+	}
+}
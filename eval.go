@@ -0,0 +1,633 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/server"
+	"github.com/gorilla/websocket"
+	"gopkg.in/yaml.v3"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/prompt"
+)
+
+// ============================================================================
+// TOOL-SELECTION EVALS  –  replay utterances and score the tools picked
+// ============================================================================
+// `go run . eval` sends each utterance in a corpus to the agent, as a new
+// conversation against the mock tools, and records which tools the model
+// called with which input. The calls are scored against the case's expected
+// calls and written to a report. Run it after changing prompt.System
+// or a tool description, and pass the previous report as the baseline to list
+// the cases that got worse:
+//
+//	go run . eval -report eval-report.json -baseline last-report.json
+//
+// A corpus case names the calls it expects (in any order) and the tools that
+// must not be called. Args is a subset of the tool input: strings compare
+// case-insensitively, numbers by value, and a list matches any of its values.
+// A case with no expected calls expects no tool to be called.
+//
+//	- name: coffee spending
+//	  utterance: how much did I spend on coffee?
+//	  expect:
+//	    - any_of: [analyze_spending, get_transactions]
+//	  forbid: [send_money]
+//
+// Each run gets its own data directory, and the calls are read back from its
+// audit log (see audit.go): a tool that ran, or, for a tool that needs
+// confirmation, the confirm_request. The eval cancels every confirm_request.
+// Settings come from the environment and CONFIG_FILE; set
+// FAKE_ANTHROPIC_SCRIPT=evals/fake_anthropic.json for an offline dry run of
+// the corpus itself.
+
+const defaultEvalCorpus = "evals/tool_selection.yaml"
+
+type evalCorpus struct {
+	Cases []evalCase `json:"cases"`
+}
+
+type evalCase struct {
+	Name      string         `json:"name"`
+	Utterance string         `json:"utterance"`
+	Expect    []expectedCall `json:"expect"`
+	Forbid    []string       `json:"forbid"`
+}
+
+// expectedCall is a tool call the model should make: Tool, or any of AnyOf,
+// with at least Args in its input.
+type expectedCall struct {
+	Tool  string                 `json:"tool,omitempty"`
+	AnyOf []string               `json:"any_of,omitempty"`
+	Args  map[string]interface{} `json:"args,omitempty"`
+}
+
+func (e expectedCall) tools() []string {
+	if e.Tool != "" {
+		return append([]string{e.Tool}, e.AnyOf...)
+	}
+	return e.AnyOf
+}
+
+func (e expectedCall) String() string {
+	s := strings.Join(e.tools(), "|")
+	if len(e.Args) > 0 {
+		args, _ := json.Marshal(e.Args)
+		s += " " + string(args)
+	}
+	return s
+}
+
+// evalCall is one tool call the model made.
+type evalCall struct {
+	Tool  string          `json:"tool"`
+	Input json.RawMessage `json:"input,omitempty"`
+	// Confirmation is set when the call stopped at a confirm_request.
+	Confirmation bool `json:"confirmation,omitempty"`
+}
+
+// evalRun is one replay of a case and its score.
+type evalRun struct {
+	Calls     []evalCall `json:"calls"`
+	Reply     string     `json:"reply,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	ToolScore float64    `json:"tool_score"` // share of expected calls made to the right tool
+	ArgScore  float64    `json:"arg_score"`  // share of expected calls made with the right input too
+	Missing   []string   `json:"missing,omitempty"`
+	Forbidden []string   `json:"forbidden,omitempty"`
+	Passed    bool       `json:"passed"`
+}
+
+type evalCaseResult struct {
+	Name      string    `json:"name"`
+	Utterance string    `json:"utterance"`
+	Runs      []evalRun `json:"runs"`
+	PassRate  float64   `json:"pass_rate"`
+}
+
+type evalReport struct {
+	GeneratedAt  time.Time        `json:"generated_at"`
+	Model        string           `json:"model"`
+	PromptSHA256 string           `json:"prompt_sha256"` // tells reports from different prompts apart
+	Corpus       string           `json:"corpus"`
+	Cases        []evalCaseResult `json:"cases"`
+	PassRate     float64          `json:"pass_rate"`
+	ToolAccuracy float64          `json:"tool_accuracy"`
+	ArgAccuracy  float64          `json:"arg_accuracy"`
+	Regressions  []string         `json:"regressions,omitempty"`
+}
+
+// decodeYAML decodes YAML into v by way of JSON, so JSON field names and
+// json.RawMessage fields work unchanged and unknown fields are rejected.
+func decodeYAML(raw []byte, v interface{}) error {
+	var doc interface{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	asJSON, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(asJSON))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func loadEvalCorpus(path string) (evalCorpus, error) {
+	var corpus evalCorpus
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return corpus, fmt.Errorf("read eval corpus: %w", err)
+	}
+	if err := decodeYAML(raw, &corpus); err != nil {
+		return corpus, fmt.Errorf("parse eval corpus %s: %w", path, err)
+	}
+	seen := make(map[string]bool)
+	for i, c := range corpus.Cases {
+		switch {
+		case c.Name == "":
+			return corpus, fmt.Errorf("%s: case %d has no name", path, i)
+		case seen[c.Name]:
+			return corpus, fmt.Errorf("%s: case %q appears twice", path, c.Name)
+		case strings.TrimSpace(c.Utterance) == "":
+			return corpus, fmt.Errorf("%s: case %q has no utterance", path, c.Name)
+		}
+		seen[c.Name] = true
+		for j, e := range c.Expect {
+			if len(e.tools()) == 0 {
+				return corpus, fmt.Errorf("%s: case %q: expected call %d names no tool", path, c.Name, j)
+			}
+		}
+	}
+	if len(corpus.Cases) == 0 {
+		return corpus, fmt.Errorf("%s: no cases", path)
+	}
+	return corpus, nil
+}
+
+// ---------------------------------------------------------------------------
+// scoring
+// ---------------------------------------------------------------------------
+
+// scoreEvalRun fills in run's score from its calls.
+func scoreEvalRun(c evalCase, run *evalRun) {
+	var toolHits, argHits int
+	for _, want := range c.Expect {
+		toolHit, argHit := false, false
+		for _, call := range run.Calls {
+			if !containsString(want.tools(), call.Tool) {
+				continue
+			}
+			toolHit = true
+			if argsMatch(want.Args, call.Input) {
+				argHit = true
+				break
+			}
+		}
+		if toolHit {
+			toolHits++
+		}
+		if argHit {
+			argHits++
+		} else {
+			run.Missing = append(run.Missing, want.String())
+		}
+	}
+	for _, call := range run.Calls {
+		if containsString(c.Forbid, call.Tool) && !containsString(run.Forbidden, call.Tool) {
+			run.Forbidden = append(run.Forbidden, call.Tool)
+		}
+	}
+
+	run.ToolScore, run.ArgScore = 1, 1
+	if len(c.Expect) > 0 {
+		run.ToolScore = float64(toolHits) / float64(len(c.Expect))
+		run.ArgScore = float64(argHits) / float64(len(c.Expect))
+	} else if len(run.Calls) > 0 {
+		// Nothing was expected, so any call is a miss.
+		run.ToolScore, run.ArgScore = 0, 0
+		run.Missing = append(run.Missing, "no tool calls")
+	}
+	run.Passed = run.Error == "" && len(run.Missing) == 0 && len(run.Forbidden) == 0
+}
+
+// argsMatch reports whether input has every field in want.
+func argsMatch(want map[string]interface{}, input json.RawMessage) bool {
+	if len(want) == 0 {
+		return true
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(input, &got); err != nil {
+		return false
+	}
+	for k, w := range want {
+		if !argMatches(w, got[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func argMatches(want, got interface{}) bool {
+	switch w := want.(type) {
+	case []interface{}:
+		for _, alt := range w {
+			if argMatches(alt, got) {
+				return true
+			}
+		}
+		return false
+	case string:
+		g, ok := got.(string)
+		return ok && strings.EqualFold(strings.TrimSpace(g), w)
+	case float64:
+		return got != nil && math.Abs(analytics.ToFloat(got)-w) < 0.005
+	case nil:
+		return got == nil
+	}
+	return want == got
+}
+
+// summarize fills in the report's totals from its cases.
+func (r *evalReport) summarize() {
+	var runs, passed int
+	var toolTotal, argTotal float64
+	for i := range r.Cases {
+		c := &r.Cases[i]
+		casePassed := 0
+		for _, run := range c.Runs {
+			if run.Passed {
+				casePassed++
+			}
+			toolTotal += run.ToolScore
+			argTotal += run.ArgScore
+		}
+		if len(c.Runs) > 0 {
+			c.PassRate = float64(casePassed) / float64(len(c.Runs))
+		}
+		runs += len(c.Runs)
+		passed += casePassed
+	}
+	if runs > 0 {
+		r.PassRate = float64(passed) / float64(runs)
+		r.ToolAccuracy = toolTotal / float64(runs)
+		r.ArgAccuracy = argTotal / float64(runs)
+	}
+}
+
+// compareBaseline lists the cases whose pass rate fell since baseline.
+// Cases that are new or were dropped aren't regressions.
+func (r *evalReport) compareBaseline(baseline evalReport) {
+	before := make(map[string]float64)
+	for _, c := range baseline.Cases {
+		before[c.Name] = c.PassRate
+	}
+	for _, c := range r.Cases {
+		if was, ok := before[c.Name]; ok && c.PassRate < was {
+			r.Regressions = append(r.Regressions, fmt.Sprintf("%s: pass rate %.0f%% → %.0f%%", c.Name, was*100, c.PassRate*100))
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// running
+// ---------------------------------------------------------------------------
+
+// evalTurnTimeout bounds one utterance, tool calls and all.
+const evalTurnTimeout = 2 * time.Minute
+
+// runEvalCase replays c once against a fresh mock app.
+func runEvalCase(conf *config.Config, c evalCase) evalRun {
+	run := evalRun{Calls: []evalCall{}}
+	fail := func(err error) evalRun {
+		run.Error = err.Error()
+		scoreEvalRun(c, &run)
+		return run
+	}
+
+	dir, err := os.MkdirTemp("", "nim-eval-*")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.RemoveAll(dir)
+
+	caseConf := *conf
+	caseConf.Mock = true
+	caseConf.DataDir = dir
+	caseConf.AuditLog = ""
+	a, err := newApp(&caseConf)
+	if err != nil {
+		return fail(err)
+	}
+	defer a.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fail(err)
+	}
+	srv := &http.Server{Handler: a.Handler()}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	run.Reply, err = converse("ws://"+ln.Addr().String()+"/ws", c.Utterance, evalTurnTimeout)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if run.Calls, err = readEvalCalls(caseConf.AuditLogPath()); err != nil && run.Error == "" {
+		run.Error = err.Error()
+	}
+	scoreEvalRun(c, &run)
+	return run
+}
+
+// converse sends one utterance as a new conversation and returns the reply,
+// cancelling any confirm_request on the way.
+func converse(url, utterance string, timeout time.Duration) (string, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return "", fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(timeout))
+
+	if err := conn.WriteJSON(server.ClientMessage{Type: "new_conversation"}); err != nil {
+		return "", err
+	}
+	sentUtterance := false
+	var reply, chunks strings.Builder
+	for {
+		var msg server.ServerMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return reply.String(), fmt.Errorf("waiting for the reply: %w", err)
+		}
+		switch msg.Type {
+		case "conversation_started":
+			if !sentUtterance {
+				sentUtterance = true
+				if err := conn.WriteJSON(server.ClientMessage{Type: "message", Content: utterance}); err != nil {
+					return "", err
+				}
+			}
+		case "text_chunk":
+			chunks.WriteString(msg.Content)
+		case "text":
+			chunks.Reset()
+			reply.WriteString(msg.Content)
+		case "confirm_request":
+			reply.WriteString(msg.Content)
+			if err := conn.WriteJSON(server.ClientMessage{Type: "cancel", ActionID: msg.ActionID}); err != nil {
+				return reply.String(), err
+			}
+		case "complete":
+			reply.WriteString(chunks.String())
+			return reply.String(), nil
+		case "error":
+			return reply.String(), errors.New(msg.Content)
+		}
+	}
+}
+
+// readEvalCalls reads the tool calls and confirmation requests from an
+// audit log, in order.
+func readEvalCalls(path string) ([]evalCall, error) {
+	calls := []evalCall{}
+	err := scanAuditLog(path, func(_ int, e auditEntry) error {
+		switch {
+		case e.Source == "tool" && e.Event == "call":
+			calls = append(calls, evalCall{Tool: e.Tool, Input: e.Input})
+		case e.Source == "confirmation" && e.Event == "requested":
+			calls = append(calls, evalCall{Tool: e.Tool, Input: e.Input, Confirmation: true})
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return calls, nil
+	}
+	return calls, err
+}
+
+// runEvalCorpus replays every case runs times, parallel at a time.
+func runEvalCorpus(conf *config.Config, corpus evalCorpus, runs, parallel int) []evalCaseResult {
+	results := make([]evalCaseResult, len(corpus.Cases))
+	type job struct{ c, r int }
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i] = evalCaseResult{
+			Name:      corpus.Cases[i].Name,
+			Utterance: corpus.Cases[i].Utterance,
+			Runs:      make([]evalRun, runs),
+		}
+	}
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j.c].Runs[j.r] = runEvalCase(conf, corpus.Cases[j.c])
+			}
+		}()
+	}
+	for c := range corpus.Cases {
+		for r := 0; r < runs; r++ {
+			jobs <- job{c, r}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// ---------------------------------------------------------------------------
+// command
+// ---------------------------------------------------------------------------
+
+// runEval is the `eval` subcommand. It exits 1 when a case regressed against
+// the baseline or the pass rate is below -min-pass-rate.
+func runEval(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	corpusPath := fs.String("corpus", defaultEvalCorpus, "YAML corpus of utterances and expected tool calls")
+	reportPath := fs.String("report", "", "write the JSON report to this file")
+	baselinePath := fs.String("baseline", "", "earlier JSON report to check for regressions")
+	runs := fs.Int("runs", 1, "times to replay each case")
+	parallel := fs.Int("parallel", 4, "cases to replay at once")
+	minPassRate := fs.Float64("min-pass-rate", 0, "fail if the overall pass rate (0-1) is below this")
+	only := fs.String("run", "", "only replay cases whose name matches this regular expression")
+	model := fs.String("model", "", "model to evaluate (default: the configured model)")
+	verbose := fs.Bool("v", false, "show the server's logs")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *runs < 1 || *parallel < 1 {
+		fmt.Fprintln(os.Stderr, "eval: -runs and -parallel must be at least 1")
+		return 2
+	}
+
+	conf, _, err := config.Load(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		return 1
+	}
+	if *model != "" {
+		conf.Model = *model
+	}
+	if err := conf.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "eval: invalid configuration: %v\n", err)
+		return 1
+	}
+	logCfg, _ := parseLogConfig(conf.Log)
+	if !*verbose {
+		logCfg, _ = parseLogConfig(config.LogSettings{Level: "error", Format: "text", Redact: "financial"})
+	}
+	setupLogging(logCfg, os.Stderr)
+	if conf.FakeAnthropic != "" {
+		if err := useFakeAnthropic(conf); err != nil {
+			fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+			return 1
+		}
+	}
+
+	corpus, err := loadEvalCorpus(*corpusPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		return 1
+	}
+	if *only != "" {
+		re, err := regexp.Compile(*only)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "eval: -run: %v\n", err)
+			return 2
+		}
+		var kept []evalCase
+		for _, c := range corpus.Cases {
+			if re.MatchString(c.Name) {
+				kept = append(kept, c)
+			}
+		}
+		corpus.Cases = kept
+	}
+
+	promptSum := sha256.Sum256([]byte(prompt.System))
+	report := evalReport{
+		GeneratedAt:  time.Now().UTC(),
+		Model:        conf.Model,
+		PromptSHA256: hex.EncodeToString(promptSum[:]),
+		Corpus:       *corpusPath,
+		Cases:        runEvalCorpus(conf, corpus, *runs, *parallel),
+	}
+	report.summarize()
+
+	if *baselinePath != "" {
+		raw, err := os.ReadFile(*baselinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "eval: read baseline: %v\n", err)
+			return 1
+		}
+		var baseline evalReport
+		if err := json.Unmarshal(raw, &baseline); err != nil {
+			fmt.Fprintf(os.Stderr, "eval: parse baseline %s: %v\n", *baselinePath, err)
+			return 1
+		}
+		report.compareBaseline(baseline)
+	}
+
+	printEvalReport(os.Stdout, report)
+	if *reportPath != "" {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*reportPath, append(out, '\n'), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "eval: write report: %v\n", err)
+			return 1
+		}
+	}
+
+	if len(report.Regressions) > 0 || report.PassRate < *minPassRate {
+		return 1
+	}
+	return 0
+}
+
+// printEvalReport writes one line per case, the failures' details and the
+// totals.
+func printEvalReport(w io.Writer, r evalReport) {
+	width := 0
+	for _, c := range r.Cases {
+		if len(c.Name) > width {
+			width = len(c.Name)
+		}
+	}
+	for _, c := range r.Cases {
+		status := "PASS"
+		if c.PassRate < 1 {
+			status = "FAIL"
+		}
+		passed := 0
+		var toolScore, argScore float64
+		for _, run := range c.Runs {
+			if run.Passed {
+				passed++
+			}
+			toolScore += run.ToolScore / float64(len(c.Runs))
+			argScore += run.ArgScore / float64(len(c.Runs))
+		}
+		fmt.Fprintf(w, "%s  %-*s  %d/%d  tools %3.0f%%  args %3.0f%%\n", status, width, c.Name, passed, len(c.Runs), toolScore*100, argScore*100)
+		for i, run := range c.Runs {
+			if run.Passed {
+				continue
+			}
+			var problems []string
+			if len(run.Missing) > 0 {
+				problems = append(problems, "missing "+strings.Join(run.Missing, ", "))
+			}
+			if len(run.Forbidden) > 0 {
+				problems = append(problems, "forbidden "+strings.Join(run.Forbidden, ", "))
+			}
+			if run.Error != "" {
+				problems = append(problems, "error: "+run.Error)
+			}
+			fmt.Fprintf(w, "      run %d: %s; called %s\n", i+1, strings.Join(problems, "; "), describeCalls(run.Calls))
+		}
+	}
+	fmt.Fprintf(w, "\npass rate %.0f%%, tool accuracy %.0f%%, argument accuracy %.0f%% (%s)\n",
+		r.PassRate*100, r.ToolAccuracy*100, r.ArgAccuracy*100, r.Model)
+	if len(r.Regressions) > 0 {
+		sort.Strings(r.Regressions)
+		fmt.Fprintf(w, "\nregressions since the baseline:\n")
+		for _, reg := range r.Regressions {
+			fmt.Fprintf(w, "  %s\n", reg)
+		}
+	}
+}
+
+func describeCalls(calls []evalCall) string {
+	if len(calls) == 0 {
+		return "nothing"
+	}
+	parts := make([]string, len(calls))
+	for i, c := range calls {
+		parts[i] = c.Tool
+		if len(c.Input) > 0 && string(c.Input) != "{}" {
+			parts[i] += " " + string(c.Input)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+func TestScoreEvalRun(t *testing.T) {
+	call := func(tool, input string) evalCall {
+		return evalCall{Tool: tool, Input: json.RawMessage(input)}
+	}
+	send := evalCase{
+		Expect: []expectedCall{{Tool: "send_money", Args: map[string]interface{}{
+			"recipient": []interface{}{"@alice", "alice"},
+			"amount":    20.0,
+		}}},
+		Forbid: []string{"withdraw_savings"},
+	}
+	spending := evalCase{
+		Expect: []expectedCall{
+			{AnyOf: []string{"analyze_spending", "get_transactions"}},
+			{Tool: "get_balance"},
+		},
+	}
+	tests := []struct {
+		name      string
+		c         evalCase
+		calls     []evalCall
+		err       string
+		tool, arg float64
+		missing   []string
+		forbidden []string
+	}{
+		{"exact", send, []evalCall{call("send_money", `{"recipient":"@Alice","amount":20}`)}, "", 1, 1, nil, nil},
+		{"alternative value", send, []evalCall{call("send_money", `{"recipient":"alice","amount":"20.00"}`)}, "", 1, 1, nil, nil},
+		{"wrong amount", send, []evalCall{call("send_money", `{"recipient":"@alice","amount":25}`)}, "", 1, 0,
+			[]string{`send_money {"amount":20,"recipient":["@alice","alice"]}`}, nil},
+		{"second call matches", send, []evalCall{
+			call("send_money", `{"recipient":"@bob","amount":20}`),
+			call("send_money", `{"recipient":"@alice","amount":20}`),
+		}, "", 1, 1, nil, nil},
+		{"forbidden", send, []evalCall{
+			call("withdraw_savings", `{"amount":20}`),
+			call("send_money", `{"recipient":"@alice","amount":20}`),
+		}, "", 1, 1, nil, []string{"withdraw_savings"}},
+		{"any of", spending, []evalCall{call("get_transactions", `{}`), call("get_balance", `{}`)}, "", 1, 1, nil, nil},
+		{"half", spending, []evalCall{call("analyze_spending", `{"days":30}`)}, "", 0.5, 0.5, []string{"get_balance"}, nil},
+		{"error", spending, []evalCall{call("get_transactions", `{}`), call("get_balance", `{}`)}, "model overloaded", 1, 1, nil, nil},
+		{"no tools expected", evalCase{}, nil, "", 1, 1, nil, nil},
+		{"no tools expected but called", evalCase{}, []evalCall{call("get_balance", `{}`)}, "", 0, 0, []string{"no tool calls"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := evalRun{Calls: tt.calls, Error: tt.err}
+			scoreEvalRun(tt.c, &run)
+			if run.ToolScore != tt.tool || run.ArgScore != tt.arg {
+				t.Errorf("scores = %v/%v, want %v/%v", run.ToolScore, run.ArgScore, tt.tool, tt.arg)
+			}
+			if !reflect.DeepEqual(run.Missing, tt.missing) || !reflect.DeepEqual(run.Forbidden, tt.forbidden) {
+				t.Errorf("missing %q forbidden %q, want %q %q", run.Missing, run.Forbidden, tt.missing, tt.forbidden)
+			}
+			wantPass := tt.err == "" && tt.missing == nil && tt.forbidden == nil
+			if run.Passed != wantPass {
+				t.Errorf("passed = %v, want %v", run.Passed, wantPass)
+			}
+		})
+	}
+}
+
+func TestEvalBaselineRegressions(t *testing.T) {
+	report := evalReport{Cases: []evalCaseResult{
+		{Name: "steady", Runs: []evalRun{{Passed: true}, {Passed: true}}},
+		{Name: "worse", Runs: []evalRun{{Passed: true}, {Passed: false}}},
+		{Name: "better", Runs: []evalRun{{Passed: true}}},
+		{Name: "new", Runs: []evalRun{{Passed: false}}},
+	}}
+	report.summarize()
+	report.compareBaseline(evalReport{Cases: []evalCaseResult{
+		{Name: "steady", PassRate: 1},
+		{Name: "worse", PassRate: 1},
+		{Name: "better", PassRate: 0},
+		{Name: "dropped", PassRate: 1},
+	}})
+	want := []string{"worse: pass rate 100% → 50%"}
+	if !reflect.DeepEqual(report.Regressions, want) {
+		t.Errorf("regressions = %q, want %q", report.Regressions, want)
+	}
+	if report.PassRate != 4.0/6 {
+		t.Errorf("pass rate = %v, want 4/6", report.PassRate)
+	}
+}
+
+// The corpus replayed against its scripted model passes in full, which keeps
+// the corpus, the script and the tool names in step.
+func TestEvalCorpusOffline(t *testing.T) {
+	captureLogs(t)
+	corpus, err := loadEvalCorpus(defaultEvalCorpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := config.Default()
+	conf.FakeAnthropic = "evals/fake_anthropic.json"
+	if err := useFakeAnthropic(conf); err != nil {
+		t.Fatal(err)
+	}
+	report := evalReport{Cases: runEvalCorpus(conf, corpus, 1, 4)}
+	report.summarize()
+	for _, c := range report.Cases {
+		for _, run := range c.Runs {
+			if !run.Passed {
+				t.Errorf("%s: missing %q, forbidden %q, error %q, calls %s", c.Name, run.Missing, run.Forbidden, run.Error, describeCalls(run.Calls))
+			}
+		}
+	}
+}
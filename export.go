@@ -0,0 +1,551 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+	"github.com/google/uuid"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics/subscriptions"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// CUSTOM TOOL: TRANSACTION EXPORT
+// ============================================================================
+// export_transactions writes the user's transactions for a date range to a
+// CSV, OFX or QIF file, for an accountant or bookkeeping software, and
+// returns a link to download it. Each outgoing payment carries its spending
+// category and whether it looks like a subscription (as analyze_subscriptions
+// would report it).
+//
+// Files are kept under DATA_DIR/exports for exportTTL. Anyone with the link
+// can download the file, like a shared-document link, so its ID is a full
+// random UUID rather than a short tool ID.
+
+const (
+	// exportTTL is how long a download link works.
+	exportTTL = 7 * 24 * time.Hour
+
+	// exportMaxDays caps the date range of one export.
+	exportMaxDays = 366
+
+	// exportLookbackMonths of history are scanned for subscriptions even when
+	// the export is shorter, so a monthly charge is recognised from the
+	// payments before it.
+	exportLookbackMonths = 6
+
+	// exportFetchLimit is the most transactions one export reads. The
+	// Liminal API has no paging and ignores start_date, so an export that
+	// hits it is reported as truncated with the dates it does cover.
+	exportFetchLimit = 500
+)
+
+var exportFormats = map[string]struct {
+	ext         string
+	contentType string
+}{
+	"csv": {".csv", "text/csv; charset=utf-8"},
+	"ofx": {".ofx", "application/x-ofx"},
+	"qif": {".qif", "application/qif"},
+}
+
+// exportArtifact is one generated file.
+type exportArtifact struct {
+	ID           string    `json:"id"`
+	Format       string    `json:"format"`
+	Filename     string    `json:"filename"`
+	StartDate    string    `json:"start_date"`
+	EndDate      string    `json:"end_date"`
+	Currency     string    `json:"currency"`
+	Transactions int       `json:"transactions"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// exportStore keeps the files on disk and their details in a jsonStore, by
+// user.
+type exportStore struct {
+	dir       string
+	baseURL   string
+	artifacts *jsonStore[[]exportArtifact]
+}
+
+func newExportStore(dataDir, baseURL string) (*exportStore, error) {
+	artifacts, err := newJSONStore[[]exportArtifact](filepath.Join(dataDir, "exports.json"))
+	if err != nil {
+		return nil, err
+	}
+	return &exportStore{
+		dir:       filepath.Join(dataDir, "exports"),
+		baseURL:   baseURL,
+		artifacts: artifacts,
+	}, nil
+}
+
+func (s *exportStore) path(a exportArtifact) string {
+	return filepath.Join(s.dir, a.ID+exportFormats[a.Format].ext)
+}
+
+// link is where a file can be downloaded.
+func (s *exportStore) link(a exportArtifact) string {
+	return s.baseURL + "/exports/" + a.ID
+}
+
+// save writes content as a new artifact of the user's, and deletes the
+// user's expired ones.
+func (s *exportStore) save(userID string, a exportArtifact, content []byte) (exportArtifact, error) {
+	now := time.Now()
+	a.ID = "exp_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	a.CreatedAt = now
+	a.ExpiresAt = now.Add(exportTTL)
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return exportArtifact{}, err
+	}
+	if err := os.WriteFile(s.path(a), content, 0o600); err != nil {
+		return exportArtifact{}, err
+	}
+	var expired []exportArtifact
+	err := s.artifacts.Update(userID, func(list *[]exportArtifact) error {
+		kept := []exportArtifact{a}
+		for _, old := range *list {
+			if now.After(old.ExpiresAt) {
+				expired = append(expired, old)
+				continue
+			}
+			kept = append(kept, old)
+		}
+		*list = kept
+		return nil
+	})
+	if err != nil {
+		os.Remove(s.path(a))
+		return exportArtifact{}, err
+	}
+	for _, old := range expired {
+		os.Remove(s.path(old))
+	}
+	return a, nil
+}
+
+// find returns the unexpired artifact with the given ID.
+func (s *exportStore) find(id string) (exportArtifact, bool) {
+	for _, userID := range s.artifacts.Users() {
+		for _, a := range s.artifacts.Get(userID) {
+			if a.ID == id {
+				return a, time.Now().Before(a.ExpiresAt)
+			}
+		}
+	}
+	return exportArtifact{}, false
+}
+
+// downloadHandler serves GET /exports/{id}. Unknown and expired IDs look the
+// same, so the handler can't be used to probe for IDs that once existed.
+func (s *exportStore) downloadHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		a, ok := s.find(strings.TrimPrefix(r.URL.Path, "/exports/"))
+		if !ok {
+			http.Error(w, "this export doesn't exist or its link has expired", http.StatusNotFound)
+			return
+		}
+		f, err := os.Open(s.path(a))
+		if err != nil {
+			http.Error(w, "this export doesn't exist or its link has expired", http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+		w.Header().Set("Content-Type", exportFormats[a.Format].contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.Filename))
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, a.Filename, a.CreatedAt, f)
+	})
+}
+
+// exportRow is one transaction as written to a file. Amount is signed from
+// the wallet's point of view: money out is negative.
+type exportRow struct {
+	ID           string
+	Date         time.Time
+	Type         string
+	Description  string
+	Counterparty string
+	Amount       float64
+	Currency     string
+	Category     string // outgoing payments only
+	Subscription bool
+	Status       string
+}
+
+// exportEndpoint serves export_transactions at /api/transactions/export.
+func exportEndpoint(exports *exportStore) apiEndpoint {
+	return apiEndpoint{
+		path: "/api/transactions/export",
+		newTool: func(exec core.ToolExecutor) core.Tool {
+			return createExportTransactionsTool(exec, exports)
+		},
+		check: func(input map[string]interface{}) []toolkit.InputProblem {
+			startDate, _ := input["start_date"].(string)
+			endDate, _ := input["end_date"].(string)
+			_, _, problems := exportRange(startDate, endDate, time.Now())
+			return problems
+		},
+		params: []apiParam{
+			{query: "from", field: "start_date", kind: "string"},
+			{query: "to", field: "end_date", kind: "string"},
+			{query: "format", field: "format", kind: "string"},
+			{query: "currency", field: "currency", kind: "string"},
+		},
+	}
+}
+
+func createExportTransactionsTool(liminalExecutor core.ToolExecutor, exports *exportStore) core.Tool {
+	return tools.New("export_transactions").
+		Description("Export the user's transactions for a date range as a CSV, OFX or QIF file, e.g. to send to an accountant or import into bookkeeping software. Outgoing payments include their spending category and whether they look like a subscription. Returns a download link that works for 7 days; share the link rather than pasting the transactions.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"start_date": tools.StringProperty("First day to include, YYYY-MM-DD (default: 30 days before end_date)"),
+			"end_date":   tools.StringProperty("Last day to include, YYYY-MM-DD (default: today)"),
+			"format":     tools.StringEnumProperty("File format: csv for spreadsheets, ofx or qif for bookkeeping software (default: csv)", "csv", "ofx", "qif"),
+			"currency":   tools.StringProperty("Currency code (default: USD)"),
+		})).
+		Handler(toolkit.TypedHandler(func(ctx context.Context, toolParams *core.ToolParams, params struct {
+			StartDate string `json:"start_date"`
+			EndDate   string `json:"end_date"`
+			Format    string `json:"format"`
+			Currency  string `json:"currency"`
+		}) (*core.ToolResult, error) {
+			now := time.Now()
+			if params.Format == "" {
+				params.Format = "csv"
+			}
+			params.Format = strings.ToLower(params.Format)
+			if params.Currency == "" {
+				params.Currency = "USD"
+			}
+			params.Currency = strings.ToUpper(params.Currency)
+			if _, ok := exportFormats[params.Format]; !ok {
+				return toolkit.Error("format must be csv, ofx or qif"), nil
+			}
+
+			start, end, problems := exportRange(params.StartDate, params.EndDate, now)
+			if len(problems) > 0 {
+				return toolkit.Error("%s", toolkit.ValidationError("export_transactions", problems)), nil
+			}
+
+			lookback := start
+			if l := end.AddDate(0, -exportLookbackMonths, 0); l.Before(lookback) {
+				lookback = l
+			}
+			transactions, err := analytics.FetchTransactions(ctx, liminalExecutor, toolParams, map[string]interface{}{
+				"limit":      exportFetchLimit,
+				"start_date": lookback.Format("2006-01-02"),
+			})
+			if err != nil {
+				return toolkit.Error("failed to fetch transactions: %v", err), nil
+			}
+
+			rows, undated := exportRows(transactions, start, end.AddDate(0, 0, 1), params.Currency, lookback)
+			var content []byte
+			switch params.Format {
+			case "csv":
+				content, err = writeExportCSV(rows)
+			case "ofx":
+				content = writeExportOFX(rows, start, end, params.Currency, now)
+			case "qif":
+				content = writeExportQIF(rows)
+			}
+			if err != nil {
+				return toolkit.Error("failed to write the export: %v", err), nil
+			}
+
+			artifact, err := exports.save(toolParams.UserID, exportArtifact{
+				Format:       params.Format,
+				Filename:     fmt.Sprintf("transactions_%s_%s%s", start.Format("2006-01-02"), end.Format("2006-01-02"), exportFormats[params.Format].ext),
+				StartDate:    start.Format("2006-01-02"),
+				EndDate:      end.Format("2006-01-02"),
+				Currency:     params.Currency,
+				Transactions: len(rows),
+			}, content)
+			if err != nil {
+				return toolkit.Error("failed to save the export: %v", err), nil
+			}
+
+			subscriptionPayments := 0
+			for _, row := range rows {
+				if row.Subscription {
+					subscriptionPayments++
+				}
+			}
+			result := map[string]interface{}{
+				"download_url":          exports.link(artifact),
+				"filename":              artifact.Filename,
+				"format":                artifact.Format,
+				"start_date":            artifact.StartDate,
+				"end_date":              artifact.EndDate,
+				"currency":              artifact.Currency,
+				"transactions":          len(rows),
+				"subscription_payments": subscriptionPayments,
+				"expires_at":            artifact.ExpiresAt.Format(time.RFC3339),
+			}
+			if undated > 0 {
+				result["undated_skipped"] = undated
+			}
+			if from, ok := exportCoveredFrom(transactions, start); !ok {
+				result["truncated"] = true
+				result["covered_start_date"] = from.Format("2006-01-02")
+				result["covered_end_date"] = artifact.EndDate
+				result["note"] = fmt.Sprintf("Liminal returned its limit of %d transactions, which only reach back to %s; anything before that is missing from the file.", exportFetchLimit, from.Format("2006-01-02"))
+			}
+			if others := analytics.OtherCurrencies(transactions, params.Currency); len(others) > 0 {
+				result["other_currencies"] = others
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data:    result,
+			}, nil
+		})).
+		Build()
+}
+
+// exportCoveredFrom reports whether the fetched transactions cover the
+// export from start. They don't when the fetch hit exportFetchLimit and the
+// oldest transaction is after start; from is then that oldest date.
+func exportCoveredFrom(transactions []map[string]interface{}, start time.Time) (from time.Time, ok bool) {
+	if len(transactions) < exportFetchLimit {
+		return start, true
+	}
+	for _, tx := range transactions {
+		if date, dated := analytics.TxTime(tx); dated && (from.IsZero() || date.Before(from)) {
+			from = date
+		}
+	}
+	if from.IsZero() || !from.After(start) {
+		return start, true
+	}
+	return from, false
+}
+
+// exportRange resolves the requested dates, defaulting to the 30 days up to
+// today, and reports what's wrong with them.
+func exportRange(startDate, endDate string, now time.Time) (start, end time.Time, problems []toolkit.InputProblem) {
+	end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if endDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", endDate, now.Location())
+		if err != nil {
+			problems = append(problems, toolkit.InputProblem{Field: "end_date", Problem: "must be a date like 2025-01-31", Got: endDate})
+		}
+		end = t
+	}
+	start = end.AddDate(0, 0, -30)
+	if startDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", startDate, now.Location())
+		if err != nil {
+			problems = append(problems, toolkit.InputProblem{Field: "start_date", Problem: "must be a date like 2025-01-01", Got: startDate})
+		}
+		start = t
+	}
+	switch {
+	case len(problems) > 0:
+	case start.After(end):
+		problems = append(problems, toolkit.InputProblem{Field: "start_date", Problem: "must not be after end_date", Got: startDate})
+	case end.Sub(start) > exportMaxDays*24*time.Hour:
+		problems = append(problems, toolkit.InputProblem{Field: "start_date", Problem: fmt.Sprintf("must be at most %d days before end_date; split the range", exportMaxDays), Got: startDate})
+	}
+	return start, end, problems
+}
+
+// exportRows returns the transactions in currency dated from start up to
+// (not including) end, oldest first, and how many were left out for having
+// no date. Subscriptions are detected over everything since lookback.
+func exportRows(transactions []map[string]interface{}, start, end time.Time, currency string, lookback time.Time) ([]exportRow, int) {
+	type paymentKey struct {
+		merchant string
+		cents    int64
+	}
+	recurring := make(map[paymentKey]bool)
+	for _, sub := range subscriptions.Detect(transactions, lookback, 1, 999.99, currency) {
+		merchant, _ := sub["merchant"].(string)
+		amount, _ := sub["amount"].(float64)
+		recurring[paymentKey{merchant, int64(math.Round(amount * 100))}] = true
+	}
+
+	var rows []exportRow
+	undated := 0
+	for _, tx := range transactions {
+		if analytics.TxCurrency(tx) != currency {
+			continue
+		}
+		date, ok := analytics.TxTime(tx)
+		if !ok {
+			undated++
+			continue
+		}
+		if date.Before(start) || !date.Before(end) {
+			continue
+		}
+		id, _ := tx["id"].(string)
+		txType, _ := tx["type"].(string)
+		status, _ := tx["status"].(string)
+		description, _ := tx["description"].(string)
+		amount := analytics.TxAmount(tx)
+		row := exportRow{
+			ID:           id,
+			Date:         date,
+			Type:         txType,
+			Description:  description,
+			Counterparty: analytics.TxCounterparty(tx),
+			Amount:       amount,
+			Currency:     currency,
+			Status:       status,
+		}
+		switch txType {
+		case "send":
+			row.Amount = -amount
+			row.Category = analytics.Categorize(description)
+			row.Subscription = recurring[paymentKey{analytics.TxMerchant(tx), int64(math.Round(amount * 100))}]
+		case "deposit":
+			// Into savings, so out of the wallet.
+			row.Amount = -amount
+		}
+		if row.Description == "" {
+			row.Description = analytics.TxMerchant(tx)
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Date.Before(rows[j].Date)
+	})
+	return rows, undated
+}
+
+func writeExportCSV(rows []exportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"date", "description", "counterparty", "type", "amount", "currency", "category", "subscription", "status", "id"})
+	for _, row := range rows {
+		subscription := "no"
+		if row.Subscription {
+			subscription = "yes"
+		}
+		w.Write([]string{
+			row.Date.Format("2006-01-02"),
+			spreadsheetSafe(row.Description),
+			spreadsheetSafe(row.Counterparty),
+			row.Type,
+			fmt.Sprintf("%.2f", row.Amount),
+			row.Currency,
+			row.Category,
+			subscription,
+			row.Status,
+			row.ID,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// spreadsheetSafe stops a spreadsheet from reading a description such as
+// "=HYPERLINK(...)" as a formula.
+func spreadsheetSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// writeExportOFX writes an OFX 1.02 bank statement, the version bookkeeping
+// software imports most widely. It has no LEDGERBAL: the wallet's balance as
+// of end isn't known, and importers that want one take it from the account.
+func writeExportOFX(rows []exportRow, start, end time.Time, currency string, now time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("OFXHEADER:100\r\nDATA:OFXSGML\r\nVERSION:102\r\nSECURITY:NONE\r\nENCODING:USASCII\r\nCHARSET:1252\r\nCOMPRESSION:NONE\r\nOLDFILEUID:NONE\r\nNEWFILEUID:NONE\r\n\r\n")
+	b.WriteString("<OFX>\r\n<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS>")
+	fmt.Fprintf(&b, "<DTSERVER>%s<LANGUAGE>ENG</SONRS></SIGNONMSGSRSV1>\r\n", now.UTC().Format("20060102150405"))
+	b.WriteString("<BANKMSGSRSV1><STMTTRNRS><TRNUID>0<STATUS><CODE>0<SEVERITY>INFO</STATUS>\r\n")
+	fmt.Fprintf(&b, "<STMTRS><CURDEF>%s<BANKACCTFROM><BANKID>LIMINAL<ACCTID>WALLET-%s<ACCTTYPE>CHECKING</BANKACCTFROM>\r\n", ofxText(currency, 3), ofxText(currency, 3))
+	fmt.Fprintf(&b, "<BANKTRANLIST><DTSTART>%s<DTEND>%s\r\n", start.Format("20060102"), end.Format("20060102"))
+	for _, row := range rows {
+		trnType := "CREDIT"
+		if row.Amount < 0 {
+			trnType = "DEBIT"
+		}
+		fitID := row.ID
+		if fitID == "" {
+			fitID = fmt.Sprintf("%s-%.2f", row.Date.UTC().Format("20060102150405"), row.Amount)
+		}
+		fmt.Fprintf(&b, "<STMTTRN><TRNTYPE>%s<DTPOSTED>%s<TRNAMT>%.2f<FITID>%s<NAME>%s",
+			trnType, row.Date.UTC().Format("20060102150405"), row.Amount, ofxText(fitID, 255), ofxText(row.Description, 32))
+		if memo := exportMemo(row); memo != "" {
+			fmt.Fprintf(&b, "<MEMO>%s", ofxText(memo, 255))
+		}
+		b.WriteString("</STMTTRN>\r\n")
+	}
+	b.WriteString("</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1>\r\n</OFX>\r\n")
+	return []byte(b.String())
+}
+
+// ofxText escapes s for SGML and cuts it to the field's maximum length.
+func ofxText(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max])
+	}
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// writeExportQIF writes a QIF bank register. The category goes in L, where
+// bookkeeping software expects it, and the subscription flag in the memo.
+func writeExportQIF(rows []exportRow) []byte {
+	var b strings.Builder
+	b.WriteString("!Type:Bank\n")
+	for _, row := range rows {
+		fmt.Fprintf(&b, "D%s\nT%.2f\nP%s\n", row.Date.Format("01/02/2006"), row.Amount, qifText(row.Description))
+		if memo := exportMemo(row); memo != "" {
+			fmt.Fprintf(&b, "M%s\n", qifText(memo))
+		}
+		if row.Category != "" {
+			fmt.Fprintf(&b, "L%s\n", qifText(row.Category))
+		}
+		b.WriteString("^\n")
+	}
+	return []byte(b.String())
+}
+
+// qifText keeps a value on one line, since QIF is line-based.
+func qifText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// exportMemo is what OFX and QIF have no field for: the counterparty,
+// category and subscription flag.
+func exportMemo(row exportRow) string {
+	var parts []string
+	if row.Counterparty != "" && row.Counterparty != row.Description {
+		parts = append(parts, row.Counterparty)
+	}
+	if row.Category != "" {
+		parts = append(parts, "category: "+row.Category)
+	}
+	if row.Subscription {
+		parts = append(parts, "subscription")
+	}
+	return strings.Join(parts, "; ")
+}
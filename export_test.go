@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+func TestExportRows(t *testing.T) {
+	start := mustParseDate(t, "2025-03-01")
+	end := mustParseDate(t, "2025-04-01")
+	transactions := []map[string]interface{}{
+		// Netflix recurs from before the range, so its March charge is a
+		// subscription.
+		{"id": "tx1", "type": "send", "amount": 15.99, "description": "Netflix Subscription", "date": "2025-01-05T10:00:00Z"},
+		{"id": "tx2", "type": "send", "amount": "15.99", "description": "Netflix Subscription", "date": "2025-02-05T10:00:00Z"},
+		{"id": "tx3", "type": "send", "amount": 15.99, "description": "Netflix Subscription", "date": "2025-03-05T10:00:00Z"},
+		{"id": "tx4", "type": "receive", "amount": 2500, "description": "Payroll Deposit", "date": "2025-03-01T09:00:00Z"},
+		{"id": "tx5", "type": "deposit", "amount": 200, "description": "Savings Deposit", "date": "2025-03-10T09:00:00Z"},
+		{"id": "tx6", "type": "send", "amount": 8.5, "description": "Starbucks Coffee", "date": "2025-03-31T23:00:00Z"},
+		{"id": "tx7", "type": "send", "amount": 9, "description": "Starbucks Coffee", "date": "2025-04-01T00:00:00Z"},
+		{"id": "tx8", "type": "send", "amount": 40, "currency": "EUR", "description": "Cafe", "date": "2025-03-02T00:00:00Z"},
+		{"id": "tx9", "type": "send", "amount": 5, "description": "Undated"},
+	}
+
+	rows, undated := exportRows(transactions, start, end, "USD", mustParseDate(t, "2024-10-01"))
+	if undated != 1 {
+		t.Errorf("undated = %d, want 1", undated)
+	}
+	var got []string
+	for _, row := range rows {
+		got = append(got, row.ID)
+	}
+	if want := "tx4 tx3 tx5 tx6"; strings.Join(got, " ") != want {
+		t.Fatalf("rows = %v, want %s (in range, USD, oldest first)", got, want)
+	}
+	netflix, payroll, savings, coffee := rows[1], rows[0], rows[2], rows[3]
+	if netflix.Amount != -15.99 || netflix.Category != "subscriptions" || !netflix.Subscription {
+		t.Errorf("netflix = %+v", netflix)
+	}
+	if payroll.Amount != 2500 || payroll.Category != "" || payroll.Subscription {
+		t.Errorf("payroll = %+v", payroll)
+	}
+	if savings.Amount != -200 {
+		t.Errorf("savings deposit amount = %v, want -200", savings.Amount)
+	}
+	if coffee.Category != "coffee" || coffee.Subscription {
+		t.Errorf("coffee = %+v", coffee)
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	rows := []exportRow{
+		{ID: "tx1", Date: mustParseDate(t, "2025-03-05"), Type: "send", Description: "=HYPERLINK(\"x\")", Amount: -15.99, Currency: "USD", Category: "other", Subscription: true, Status: "completed"},
+		{ID: "tx2", Date: mustParseDate(t, "2025-03-06"), Type: "receive", Description: "Pay <&> back", Counterparty: "@alice", Amount: 75, Currency: "USD", Status: "completed"},
+	}
+
+	csv, err := writeExportCSV(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"date,description,counterparty,type,amount,currency,category,subscription,status,id\n",
+		`2025-03-05,"'=HYPERLINK(""x"")",,send,-15.99,USD,other,yes,completed,tx1`,
+		"2025-03-06,Pay <&> back,'@alice,receive,75.00,USD,,no,completed,tx2", // Excel reads a leading @ as a formula too
+	} {
+		if !strings.Contains(string(csv), want) {
+			t.Errorf("CSV is missing %q:\n%s", want, csv)
+		}
+	}
+
+	ofx := string(writeExportOFX(rows, mustParseDate(t, "2025-03-01"), mustParseDate(t, "2025-03-31"), "USD", mustParseDate(t, "2025-04-01")))
+	for _, want := range []string{
+		"OFXHEADER:100\r\n",
+		"<CURDEF>USD",
+		"<DTSTART>20250301<DTEND>20250331",
+		"<TRNTYPE>DEBIT<DTPOSTED>20250305000000<TRNAMT>-15.99<FITID>tx1",
+		"<MEMO>category: other; subscription</STMTTRN>",
+		"<TRNTYPE>CREDIT<DTPOSTED>20250306000000<TRNAMT>75.00<FITID>tx2<NAME>Pay &lt;&amp;&gt; back<MEMO>@alice</STMTTRN>",
+	} {
+		if !strings.Contains(ofx, want) {
+			t.Errorf("OFX is missing %q:\n%s", want, ofx)
+		}
+	}
+
+	qif := string(writeExportQIF(rows))
+	want := "!Type:Bank\n" +
+		"D03/05/2025\nT-15.99\nP=HYPERLINK(\"x\")\nMcategory: other; subscription\nLother\n^\n" +
+		"D03/06/2025\nT75.00\nPPay <&> back\nM@alice\n^\n"
+	if qif != want {
+		t.Errorf("QIF =\n%s\nwant\n%s", qif, want)
+	}
+}
+
+// The API returns a link, and the link serves the file without a token.
+func TestExportDownload(t *testing.T) {
+	srv := newAPIServer(t, testAPIToken)
+
+	status, body := apiGet(t, srv.URL+"/api/transactions/export?format=qif", testAPIToken)
+	if status != http.StatusOK {
+		t.Fatalf("export: %d %v", status, body)
+	}
+	link, _ := body["download_url"].(string)
+	if !strings.HasPrefix(link, "http://localhost:8080/exports/exp_") || body["format"] != "qif" {
+		t.Fatalf("export body = %v", body)
+	}
+
+	resp, err := http.Get(srv.URL + strings.TrimPrefix(link, "http://localhost:8080"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(content), "!Type:Bank\n") {
+		t.Fatalf("download: %d %q", resp.StatusCode, content)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, `attachment; filename="transactions_`) {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	resp, err = http.Get(srv.URL + "/exports/exp_00000000000000000000000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown export: %d, want 404", resp.StatusCode)
+	}
+
+	status, body = apiGet(t, srv.URL+"/api/transactions/export?from=2025-02-01&to=2025-01-01", testAPIToken)
+	problems, _ := body["problems"].([]interface{})
+	if status != http.StatusBadRequest || len(problems) != 1 || problems[0].(map[string]interface{})["field"] != "from" {
+		t.Errorf("reversed range: %d %v, want a 400 about from", status, body)
+	}
+}
+
+// historyExecutor answers get_transactions with a fixed history, ignoring
+// start_date like the live API does.
+type historyExecutor struct {
+	core.ToolExecutor
+	transactions []map[string]interface{}
+}
+
+func (e historyExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	data, _ := json.Marshal(map[string]interface{}{"transactions": e.transactions})
+	return &core.ExecuteResponse{Success: true, Data: data}, nil
+}
+
+// An export whose fetch hits the limit before reaching the start date says
+// it's truncated and which dates it covers.
+func TestExportTruncated(t *testing.T) {
+	today := time.Now().Truncate(24 * time.Hour)
+	history := func(n int) []map[string]interface{} {
+		var txs []map[string]interface{}
+		for i := 0; i < n; i++ {
+			txs = append(txs, map[string]interface{}{
+				"id": fmt.Sprintf("tx_%d", i), "type": "send", "amount": 1.0, "currency": "USD", "status": "completed",
+				"description": "Coffee", "date": today.AddDate(0, 0, -i/50).Add(12 * time.Hour).Format(time.RFC3339),
+			})
+		}
+		return txs
+	}
+	exports, err := newExportStore(t.TempDir(), "http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := today.AddDate(0, 0, -60).Format("2006-01-02")
+	for _, tt := range []struct {
+		transactions int
+		truncated    bool
+	}{{exportFetchLimit, true}, {exportFetchLimit - 1, false}} {
+		tool := createExportTransactionsTool(historyExecutor{transactions: history(tt.transactions)}, exports)
+		result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "u1", Input: json.RawMessage(`{"start_date": "` + start + `"}`)})
+		if err != nil || !result.Success {
+			t.Fatalf("export: %v %+v", err, result)
+		}
+		data := result.Data.(map[string]interface{})
+		want := today.AddDate(0, 0, -(tt.transactions-1)/50).Format("2006-01-02")
+		if (data["truncated"] == true) != tt.truncated || (tt.truncated && data["covered_start_date"] != want) {
+			t.Errorf("%d transactions: truncated %v covered from %v, want %v from %s", tt.transactions, data["truncated"], data["covered_start_date"], tt.truncated, want)
+		}
+	}
+}
+
+func mustParseDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+// ============================================================================
+// FAKE ANTHROPIC  –  scripted stand-in for the Messages API, for offline runs
+// ============================================================================
+// fakeAnthropic answers POST /v1/messages from a script instead of a model,
+// both as plain JSON and as a server-sent event stream, so whole WebSocket
+// conversations can run without network access or an API key. Point the
+// server at it in-process with fake_anthropic (see internal/config), or run it on
+// its own and use anthropic_base_url:
+//
+//	go run . fake-anthropic -script fake_anthropic.example.json -addr 127.0.0.1:9999
+//
+// A script is a list of turns. Each request is answered by the first turn
+// whose conditions all hold for the request's last message and that hasn't
+// been used up. A turn replies with text and/or tool_use blocks; when it
+// calls tools the engine runs them and sends the results back, which the
+// next turn can match with tool_result. Requests that match nothing get the
+// script's default reply.
+
+type fakeScript struct {
+	Turns   []fakeTurn  `json:"turns"`
+	Default []fakeBlock `json:"default,omitempty"`
+}
+
+type fakeTurn struct {
+	Name string `json:"name,omitempty"`
+
+	// Conditions on the request's last message. All that are set must hold.
+	UserText       string `json:"user_text,omitempty"`       // the user's text contains this (any case)
+	ToolResult     string `json:"tool_result,omitempty"`     // it carries a result from this tool
+	ResultContains string `json:"result_contains,omitempty"` // a tool result contains this
+
+	// Times limits how often the turn is used; 0 means no limit.
+	Times int `json:"times,omitempty"`
+
+	Reply []fakeBlock   `json:"reply,omitempty"`
+	Error *fakeAPIError `json:"error,omitempty"` // reply with an API error instead
+}
+
+// fakeBlock is one content block of a reply: text, or a tool call.
+type fakeBlock struct {
+	Text    string          `json:"text,omitempty"`
+	ToolUse string          `json:"tool_use,omitempty"`
+	Input   json.RawMessage `json:"input,omitempty"`
+}
+
+type fakeAPIError struct {
+	Status  int    `json:"status"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// fakeDefaultReply is used when neither a turn nor the script's default
+// matches.
+var fakeDefaultReply = []fakeBlock{{Text: "I don't have a scripted reply for that."}}
+
+func loadFakeScript(path string) (fakeScript, error) {
+	var script fakeScript
+	f, err := os.Open(path)
+	if err != nil {
+		return script, fmt.Errorf("read fake Anthropic script: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&script); err != nil {
+		return script, fmt.Errorf("parse fake Anthropic script %s: %w", path, err)
+	}
+	return script, script.validate()
+}
+
+func (s fakeScript) validate() error {
+	check := func(where string, blocks []fakeBlock) error {
+		for i, b := range blocks {
+			if (b.Text == "") == (b.ToolUse == "") {
+				return fmt.Errorf("%s block %d: set exactly one of text and tool_use", where, i)
+			}
+			if b.ToolUse != "" && len(b.Input) > 0 && !json.Valid(b.Input) {
+				return fmt.Errorf("%s block %d: input is not valid JSON", where, i)
+			}
+		}
+		return nil
+	}
+	for i, t := range s.Turns {
+		where := fmt.Sprintf("turn %d", i)
+		if t.Name != "" {
+			where = fmt.Sprintf("turn %q", t.Name)
+		}
+		if len(t.Reply) == 0 && t.Error == nil {
+			return fmt.Errorf("%s: needs a reply or an error", where)
+		}
+		if err := check(where, t.Reply); err != nil {
+			return err
+		}
+	}
+	return check("default", s.Default)
+}
+
+// ---------------------------------------------------------------------------
+// server
+// ---------------------------------------------------------------------------
+
+type fakeAnthropic struct {
+	mu       sync.Mutex
+	script   fakeScript
+	used     []int
+	requests []fakeRequest
+	nextID   int
+}
+
+func newFakeAnthropic(script fakeScript) *fakeAnthropic {
+	return &fakeAnthropic{script: script, used: make([]int, len(script.Turns))}
+}
+
+// fakeRequest is the part of a Messages API request the fake looks at.
+type fakeRequest struct {
+	Model     string        `json:"model"`
+	MaxTokens int64         `json:"max_tokens"`
+	Stream    bool          `json:"stream"`
+	Messages  []fakeMessage `json:"messages"`
+	Tools     []struct {
+		Name string `json:"name"`
+	} `json:"tools"`
+}
+
+type fakeMessage struct {
+	Role    string        `json:"role"`
+	Content []fakeContent `json:"content"`
+}
+
+type fakeContent struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+}
+
+// UnmarshalJSON accepts content given as a plain string, too.
+func (m *fakeMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Role = raw.Role
+	var text string
+	if json.Unmarshal(raw.Content, &text) == nil {
+		m.Content = []fakeContent{{Type: "text", Text: text}}
+		return nil
+	}
+	return json.Unmarshal(raw.Content, &m.Content)
+}
+
+// Requests returns the requests received so far, oldest first.
+func (f *fakeAnthropic) Requests() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.requests...)
+}
+
+func (f *fakeAnthropic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || strings.TrimSuffix(r.URL.Path, "/") != "/v1/messages" {
+		writeFakeError(w, &fakeAPIError{Status: http.StatusNotFound, Type: "not_found_error", Message: "only POST /v1/messages is supported"})
+		return
+	}
+	var req fakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeFakeError(w, &fakeAPIError{Status: http.StatusBadRequest, Type: "invalid_request_error", Message: err.Error()})
+		return
+	}
+
+	turn, reply := f.respond(req)
+	if turn != nil && turn.Error != nil {
+		writeFakeError(w, turn.Error)
+		return
+	}
+	msg := f.message(req, reply)
+	slog.DebugContext(r.Context(), "fake Anthropic reply", "turn", turnName(turn), "stream", req.Stream, "stop_reason", msg.StopReason)
+	if req.Stream {
+		streamFakeMessage(w, msg)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msg)
+}
+
+// respond picks the turn for req and records the request.
+func (f *fakeAnthropic) respond(req fakeRequest) (*fakeTurn, []fakeBlock) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, req)
+
+	for i := range f.script.Turns {
+		t := &f.script.Turns[i]
+		if t.Times > 0 && f.used[i] >= t.Times {
+			continue
+		}
+		if t.matches(req.Messages) {
+			f.used[i]++
+			return t, t.Reply
+		}
+	}
+	if len(f.script.Default) > 0 {
+		return nil, f.script.Default
+	}
+	return nil, fakeDefaultReply
+}
+
+func turnName(t *fakeTurn) string {
+	switch {
+	case t == nil:
+		return "default"
+	case t.Name != "":
+		return t.Name
+	}
+	return "unnamed"
+}
+
+func (t *fakeTurn) matches(messages []fakeMessage) bool {
+	if len(messages) == 0 {
+		return false
+	}
+	last := messages[len(messages)-1]
+
+	var text []string
+	var results []string
+	var resultTools []string
+	for _, c := range last.Content {
+		switch c.Type {
+		case "text":
+			text = append(text, c.Text)
+		case "tool_result":
+			results = append(results, resultText(c.Content))
+			resultTools = append(resultTools, toolNameFor(messages, c.ToolUseID))
+		}
+	}
+
+	if t.UserText != "" && !strings.Contains(strings.ToLower(strings.Join(text, "\n")), strings.ToLower(t.UserText)) {
+		return false
+	}
+	if t.ToolResult != "" && !containsString(resultTools, t.ToolResult) {
+		return false
+	}
+	if t.ResultContains != "" && !strings.Contains(strings.Join(results, "\n"), t.ResultContains) {
+		return false
+	}
+	return true
+}
+
+// resultText is the text of a tool result, whose content is a string or a
+// list of text blocks.
+func resultText(content json.RawMessage) string {
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		return text
+	}
+	var blocks []fakeContent
+	json.Unmarshal(content, &blocks)
+	var parts []string
+	for _, b := range blocks {
+		parts = append(parts, b.Text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// toolNameFor finds the tool_use block a result answers.
+func toolNameFor(messages []fakeMessage, toolUseID string) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		for _, c := range messages[i].Content {
+			if c.Type == "tool_use" && c.ID == toolUseID {
+				return c.Name
+			}
+		}
+	}
+	return ""
+}
+
+// ---------------------------------------------------------------------------
+// responses
+// ---------------------------------------------------------------------------
+
+type fakeMessageBody struct {
+	ID           string                   `json:"id"`
+	Type         string                   `json:"type"`
+	Role         string                   `json:"role"`
+	Model        string                   `json:"model"`
+	Content      []map[string]interface{} `json:"content"`
+	StopReason   string                   `json:"stop_reason"`
+	StopSequence *string                  `json:"stop_sequence"`
+	Usage        fakeUsage                `json:"usage"`
+}
+
+type fakeUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (f *fakeAnthropic) message(req fakeRequest, reply []fakeBlock) fakeMessageBody {
+	f.mu.Lock()
+	f.nextID++
+	id := f.nextID
+	f.mu.Unlock()
+
+	msg := fakeMessageBody{
+		ID:         fmt.Sprintf("msg_fake_%d", id),
+		Type:       "message",
+		Role:       "assistant",
+		Model:      req.Model,
+		StopReason: "end_turn",
+		Usage:      fakeUsage{InputTokens: fakeTokenCount(req)},
+	}
+	for i, b := range reply {
+		if b.ToolUse == "" {
+			msg.Content = append(msg.Content, map[string]interface{}{"type": "text", "text": b.Text})
+			msg.Usage.OutputTokens += len(strings.Fields(b.Text))
+			continue
+		}
+		input := b.Input
+		if len(input) == 0 {
+			input = json.RawMessage(`{}`)
+		}
+		msg.Content = append(msg.Content, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    fmt.Sprintf("toolu_fake_%d_%d", id, i),
+			"name":  b.ToolUse,
+			"input": input,
+		})
+		msg.StopReason = "tool_use"
+		msg.Usage.OutputTokens += len(input) / 4
+	}
+	if msg.Usage.OutputTokens == 0 {
+		msg.Usage.OutputTokens = 1
+	}
+	return msg
+}
+
+// fakeTokenCount is a rough stand-in for the input token count: a word per
+// token across the text in the request.
+func fakeTokenCount(req fakeRequest) int {
+	n := 0
+	for _, m := range req.Messages {
+		for _, c := range m.Content {
+			n += len(strings.Fields(c.Text)) + len(strings.Fields(resultText(c.Content)))
+		}
+	}
+	return n + 1
+}
+
+func writeFakeError(w http.ResponseWriter, e *fakeAPIError) {
+	status := e.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	typ := e.Type
+	if typ == "" {
+		typ = "api_error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":  "error",
+		"error": map[string]string{"type": typ, "message": e.Message},
+	})
+}
+
+// streamFakeMessage sends msg as the event stream the Messages API produces:
+// text in word-sized deltas and tool input as partial JSON.
+func streamFakeMessage(w http.ResponseWriter, msg fakeMessageBody) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+	send := func(event string, data interface{}) {
+		raw, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, raw)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	start := msg
+	start.Content = []map[string]interface{}{}
+	start.StopReason = ""
+	start.Usage.OutputTokens = 1
+	send("message_start", map[string]interface{}{"type": "message_start", "message": start})
+
+	for i, block := range msg.Content {
+		if block["type"] == "text" {
+			send("content_block_start", map[string]interface{}{
+				"type": "content_block_start", "index": i,
+				"content_block": map[string]interface{}{"type": "text", "text": ""},
+			})
+			for _, chunk := range splitKeepingSpaces(block["text"].(string)) {
+				send("content_block_delta", map[string]interface{}{
+					"type": "content_block_delta", "index": i,
+					"delta": map[string]interface{}{"type": "text_delta", "text": chunk},
+				})
+			}
+		} else {
+			send("content_block_start", map[string]interface{}{
+				"type": "content_block_start", "index": i,
+				"content_block": map[string]interface{}{"type": "tool_use", "id": block["id"], "name": block["name"], "input": map[string]interface{}{}},
+			})
+			input := string(block["input"].(json.RawMessage))
+			for len(input) > 0 {
+				n := min(len(input), 16)
+				send("content_block_delta", map[string]interface{}{
+					"type": "content_block_delta", "index": i,
+					"delta": map[string]interface{}{"type": "input_json_delta", "partial_json": input[:n]},
+				})
+				input = input[n:]
+			}
+		}
+		send("content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": i})
+	}
+
+	send("message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": msg.StopReason, "stop_sequence": nil},
+		"usage": map[string]interface{}{"output_tokens": msg.Usage.OutputTokens},
+	})
+	send("message_stop", map[string]interface{}{"type": "message_stop"})
+}
+
+// splitKeepingSpaces splits text into words, each keeping the spaces that
+// follow it, so the chunks join back to the original.
+func splitKeepingSpaces(text string) []string {
+	var chunks []string
+	start := 0
+	for i := 1; i < len(text); i++ {
+		if text[i-1] == ' ' && text[i] != ' ' {
+			chunks = append(chunks, text[start:i])
+			start = i
+		}
+	}
+	return append(chunks, text[start:])
+}
+
+// ---------------------------------------------------------------------------
+// running it
+// ---------------------------------------------------------------------------
+
+// startFakeAnthropic serves the script on a loopback port and returns its
+// base URL.
+func startFakeAnthropic(path string) (string, error) {
+	script, err := loadFakeScript(path)
+	if err != nil {
+		return "", err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("start fake Anthropic: %w", err)
+	}
+	go http.Serve(ln, newFakeAnthropic(script))
+	return "http://" + ln.Addr().String(), nil
+}
+
+// useFakeAnthropic starts a fake for conf.FakeAnthropic and points conf at
+// it, with a placeholder key if none is set.
+func useFakeAnthropic(conf *config.Config) error {
+	baseURL, err := startFakeAnthropic(conf.FakeAnthropic)
+	if err != nil {
+		return err
+	}
+	conf.AnthropicBaseURL = baseURL
+	if conf.AnthropicKey == "" {
+		conf.AnthropicKey = "fake-anthropic"
+	}
+	return nil
+}
+
+// runFakeAnthropic is the `fake-anthropic` subcommand.
+func runFakeAnthropic(args []string) int {
+	fs := flag.NewFlagSet("fake-anthropic", flag.ContinueOnError)
+	scriptPath := fs.String("script", "", "JSON script of scripted turns (required)")
+	addr := fs.String("addr", "127.0.0.1:9999", "address to listen on")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *scriptPath == "" {
+		fmt.Fprintln(os.Stderr, "fake-anthropic: -script is required")
+		return 2
+	}
+	script, err := loadFakeScript(*scriptPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fake-anthropic: %v\n", err)
+		return 1
+	}
+	fmt.Printf("fake Anthropic API on http://%s (%d turns); set ANTHROPIC_BASE_URL to use it\n", *addr, len(script.Turns))
+	if err := http.ListenAndServe(*addr, newFakeAnthropic(script)); err != nil {
+		fmt.Fprintf(os.Stderr, "fake-anthropic: %v\n", err)
+		return 1
+	}
+	return 0
+}
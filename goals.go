@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// CUSTOM TOOLS: SAVINGS GOALS
+// ============================================================================
+
+// savingsGoal is a named target the user is saving toward. Progress isn't
+// stored: it's derived from the live savings balance each time goals are
+// listed.
+type savingsGoal struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	TargetAmount float64   `json:"target_amount"`
+	Currency     string    `json:"currency"`
+	TargetDate   string    `json:"target_date"` // YYYY-MM-DD
+	Status       string    `json:"status"`      // active | archived
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type goalStore = jsonStore[[]savingsGoal]
+
+func createSavingsGoalTools(liminalExecutor core.ToolExecutor, goals *goalStore) []core.Tool {
+	return []core.Tool{
+		createCreateSavingsGoalTool(goals),
+		createListSavingsGoalsTool(liminalExecutor, goals),
+		createUpdateSavingsGoalTool(goals),
+	}
+}
+
+func createCreateSavingsGoalTool(goals *goalStore) core.Tool {
+	return tools.New("create_savings_goal").
+		Description("Create a savings goal with a target amount and a target date, e.g. 'Save $3000 for Japan by June'.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"name":          tools.StringProperty("Short name for the goal (e.g. 'Japan trip')"),
+			"target_amount": toolkit.Positive(tools.NumberProperty("Amount the user wants to have saved")),
+			"target_date":   tools.StringProperty("Date to reach the goal by (YYYY-MM-DD)"),
+			"currency":      tools.StringProperty("Currency code (default: USD)"),
+		}, "name", "target_amount", "target_date")).
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				Name         string  `json:"name"`
+				TargetAmount float64 `json:"target_amount"`
+				TargetDate   string  `json:"target_date"`
+				Currency     string  `json:"currency"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			params.Name = strings.TrimSpace(params.Name)
+			if params.Currency == "" {
+				params.Currency = "USD"
+			}
+			if params.Name == "" {
+				return &core.ToolResult{Success: false, Error: "name is required"}, nil
+			}
+			if params.TargetAmount <= 0 {
+				return &core.ToolResult{Success: false, Error: "target_amount must be greater than zero"}, nil
+			}
+			if msg := validateGoalDate(params.TargetDate, time.Now()); msg != "" {
+				return &core.ToolResult{Success: false, Error: msg}, nil
+			}
+
+			now := time.Now()
+			goal := savingsGoal{
+				ID:           toolkit.NewID("goal"),
+				Name:         params.Name,
+				TargetAmount: params.TargetAmount,
+				Currency:     strings.ToUpper(params.Currency),
+				TargetDate:   params.TargetDate,
+				Status:       "active",
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}
+			err := goals.Update(toolParams.UserID, func(list *[]savingsGoal) error {
+				*list = append(*list, goal)
+				return nil
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to save goal: %v", err),
+				}, nil
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"goal": goal,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+func createListSavingsGoalsTool(liminalExecutor core.ToolExecutor, goals *goalStore) core.Tool {
+	return tools.New("list_savings_goals").
+		Description("List the user's savings goals with progress based on their current savings balance, the monthly deposit_savings amount needed to hit each goal on time, and whether the user's recent monthly savings pace covers it.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"include_archived": tools.BooleanProperty("Also return archived goals (default: false)"),
+		})).
+		Handler(func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				IncludeArchived bool `json:"include_archived"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			var selected []savingsGoal
+			for _, g := range goals.Get(toolParams.UserID) {
+				if g.Status == "active" || params.IncludeArchived {
+					selected = append(selected, g)
+				}
+			}
+			if len(selected) == 0 {
+				return &core.ToolResult{
+					Success: true,
+					Data: map[string]interface{}{
+						"goals":   []interface{}{},
+						"summary": "No savings goals yet. Use create_savings_goal to add one.",
+					},
+				}, nil
+			}
+
+			savings, err := analytics.ExecuteRead(ctx, liminalExecutor, toolParams, "get_savings_balance", nil)
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to fetch savings balance: %v", err),
+				}, nil
+			}
+
+			now := time.Now()
+			transactions, err := analytics.FetchTransactions(ctx, liminalExecutor, toolParams, map[string]interface{}{
+				"limit":      500,
+				"start_date": now.AddDate(0, 0, -savingsPaceDays).Format("2006-01-02"),
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to fetch transactions: %v", err),
+				}, nil
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"goals":        goalProgress(selected, savings, savingsPace(transactions, now), now),
+					"generated_at": now.Format(time.RFC3339),
+				},
+			}, nil
+		}).
+		Build()
+}
+
+func createUpdateSavingsGoalTool(goals *goalStore) core.Tool {
+	return tools.New("update_savings_goal").
+		Description("Change a savings goal's name, target amount or target date, or archive it once the user no longer wants to track it.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"goal_id":       tools.StringProperty("ID of the goal to update"),
+			"name":          tools.StringProperty("New name"),
+			"target_amount": toolkit.Positive(tools.NumberProperty("New target amount")),
+			"target_date":   tools.StringProperty("New target date (YYYY-MM-DD)"),
+			"status":        tools.StringEnumProperty("Set to 'archived' to stop tracking, 'active' to resume", "active", "archived"),
+		}, "goal_id")).
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				GoalID       string   `json:"goal_id"`
+				Name         string   `json:"name"`
+				TargetAmount *float64 `json:"target_amount"`
+				TargetDate   string   `json:"target_date"`
+				Status       string   `json:"status"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+			if params.TargetAmount != nil && *params.TargetAmount <= 0 {
+				return &core.ToolResult{Success: false, Error: "target_amount must be greater than zero"}, nil
+			}
+			if params.TargetDate != "" {
+				if msg := validateGoalDate(params.TargetDate, time.Now()); msg != "" {
+					return &core.ToolResult{Success: false, Error: msg}, nil
+				}
+			}
+			if params.Status != "" && params.Status != "active" && params.Status != "archived" {
+				return &core.ToolResult{Success: false, Error: "status must be 'active' or 'archived'"}, nil
+			}
+
+			var updated savingsGoal
+			err := goals.Update(toolParams.UserID, func(list *[]savingsGoal) error {
+				for i := range *list {
+					g := &(*list)[i]
+					if g.ID != params.GoalID {
+						continue
+					}
+					if name := strings.TrimSpace(params.Name); name != "" {
+						g.Name = name
+					}
+					if params.TargetAmount != nil {
+						g.TargetAmount = *params.TargetAmount
+					}
+					if params.TargetDate != "" {
+						g.TargetDate = params.TargetDate
+					}
+					if params.Status != "" {
+						g.Status = params.Status
+					}
+					g.UpdatedAt = time.Now()
+					updated = *g
+					return nil
+				}
+				return fmt.Errorf("no goal with id %q", params.GoalID)
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to update goal: %v", err),
+				}, nil
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"goal": updated,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+func validateGoalDate(date string, now time.Time) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "target_date must be a date in YYYY-MM-DD format"
+	}
+	if !t.After(now) {
+		return "target_date must be in the future"
+	}
+	return ""
+}
+
+// savingsPaceDays of savings deposits and withdrawals set the user's
+// monthly savings pace.
+const savingsPaceDays = 90
+
+// savingsPace is the average net amount moved into savings per month over
+// the last savingsPaceDays, by currency.
+func savingsPace(transactions []map[string]interface{}, now time.Time) map[string]float64 {
+	since := now.AddDate(0, 0, -savingsPaceDays)
+	pace := make(map[string]float64)
+	for _, tx := range transactions {
+		t, ok := analytics.TxTime(tx)
+		if !ok || t.Before(since) || t.After(now) {
+			continue
+		}
+		txType, _ := tx["type"].(string)
+		switch txType = strings.ToLower(txType); {
+		case strings.Contains(txType, "deposit"):
+			pace[analytics.TxCurrency(tx)] += analytics.TxAmount(tx)
+		case strings.Contains(txType, "withdraw"):
+			pace[analytics.TxCurrency(tx)] -= analytics.TxAmount(tx)
+		}
+	}
+	for currency, net := range pace {
+		pace[currency] = net / monthsUntil(since, now)
+	}
+	return pace
+}
+
+// goalProgress allocates the savings balance across goals, earliest target
+// date first, so several goals don't all claim the same dollars. For each
+// goal it reports the monthly deposit needed to close the gap on time, and
+// whether the monthly savings pace covers it; the pace is allocated the same
+// way, so two goals can't both count on the same deposits.
+func goalProgress(goals []savingsGoal, savings map[string]interface{}, pace map[string]float64, now time.Time) []map[string]interface{} {
+	sorted := make([]savingsGoal, len(goals))
+	copy(sorted, goals)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TargetDate < sorted[j].TargetDate
+	})
+
+	available := make(map[string]float64)
+	paceLeft := make(map[string]float64)
+	for currency, monthly := range pace {
+		paceLeft[currency] = math.Max(0, monthly)
+	}
+	results := make([]map[string]interface{}, 0, len(sorted))
+	for _, g := range sorted {
+		if _, seen := available[g.Currency]; !seen {
+			available[g.Currency] = savingsBalance(savings, g.Currency)
+		}
+
+		allocated := 0.0
+		if g.Status == "active" {
+			allocated = math.Min(available[g.Currency], g.TargetAmount)
+			available[g.Currency] -= allocated
+		}
+		remaining := g.TargetAmount - allocated
+
+		result := map[string]interface{}{
+			"goal_id":          g.ID,
+			"name":             g.Name,
+			"status":           g.Status,
+			"currency":         g.Currency,
+			"target_amount":    g.TargetAmount,
+			"target_date":      g.TargetDate,
+			"saved":            analytics.RoundMoney(allocated),
+			"remaining":        analytics.RoundMoney(remaining),
+			"percent_complete": math.Round(allocated/g.TargetAmount*1000) / 10,
+		}
+
+		target, err := time.Parse("2006-01-02", g.TargetDate)
+		if err == nil {
+			months := monthsUntil(now, target)
+			result["months_left"] = math.Round(months*10) / 10
+			switch {
+			case remaining <= 0:
+				result["on_track"] = true
+				result["monthly_deposit_needed"] = 0.0
+			case months <= 0:
+				result["on_track"] = false
+				result["monthly_deposit_needed"] = analytics.RoundMoney(remaining)
+				result["note"] = "Target date has passed; the full remaining amount is still needed."
+			default:
+				needed := analytics.RoundMoney(remaining / math.Max(1, months))
+				result["monthly_deposit_needed"] = needed
+				result["monthly_savings_pace"] = analytics.RoundMoney(paceLeft[g.Currency])
+				if g.Status == "active" {
+					result["on_track"] = paceLeft[g.Currency] >= needed
+					paceLeft[g.Currency] -= math.Min(paceLeft[g.Currency], needed)
+				}
+			}
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// monthsUntil returns the (fractional) number of months between two dates
+// using the average month length.
+func monthsUntil(from, to time.Time) float64 {
+	return to.Sub(from).Hours() / 24 / 30.44
+}
+
+// savingsBalance totals the user's savings in one currency. The mock returns
+// a top-level balance; positions are summed when there is no top-level value
+// (the live API only has totalUsd) or its currency doesn't match.
+func savingsBalance(savings map[string]interface{}, currency string) float64 {
+	topCurrency, _ := savings["currency"].(string)
+	if _, ok := savings["balance"]; ok && (topCurrency == "" || strings.EqualFold(topCurrency, currency)) {
+		return analytics.ToFloat(savings["balance"])
+	}
+
+	var total float64
+	positions, _ := savings["positions"].([]interface{})
+	for _, p := range positions {
+		pos, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if c, ok := pos["currency"].(string); ok && !strings.EqualFold(c, currency) {
+			continue
+		}
+		total += positionValue(pos)
+	}
+	return total
+}
+
+// positionValue is what a savings position is worth now: the mock's balance,
+// or the live API's currentValue (deposits plus earnings).
+func positionValue(pos map[string]interface{}) float64 {
+	for _, key := range []string{"balance", "currentValue", "amount"} {
+		if v, ok := pos[key]; ok {
+			return analytics.ToFloat(v)
+		}
+	}
+	return 0
+}
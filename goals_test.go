@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Goals still in progress are on track when the recent monthly savings
+// pace covers their deposit, earliest target first.
+func TestGoalProgressOnTrack(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	transactions := []map[string]interface{}{
+		{"type": "deposit", "amount": 400, "currency": "USD", "date": "2025-05-20T00:00:00Z"},
+		{"type": "deposit", "amount": 400, "currency": "USD", "date": "2025-04-20T00:00:00Z"},
+		{"type": "deposit", "amount": 400, "currency": "USD", "date": "2025-03-20T00:00:00Z"},
+		{"type": "withdrawal", "amount": 300, "currency": "USD", "date": "2025-05-25T00:00:00Z"},
+		{"type": "deposit", "amount": 999, "currency": "USD", "date": "2024-12-01T00:00:00Z"}, // too old
+		{"type": "send", "amount": 50, "currency": "USD", "date": "2025-05-26T00:00:00Z"},
+	}
+	pace := savingsPace(transactions, now)
+	if got := pace["USD"]; got < 295 || got > 305 {
+		t.Fatalf("pace = %.2f a month, want about 300 (900 net over 90 days)", got)
+	}
+
+	goals := []savingsGoal{
+		{ID: "g1", Name: "Trip", TargetAmount: 2200, Currency: "USD", TargetDate: "2025-12-01", Status: "active"},
+		{ID: "g2", Name: "Car", TargetAmount: 2000, Currency: "USD", TargetDate: "2026-06-01", Status: "active"},
+		{ID: "g3", Name: "Laptop", TargetAmount: 100, Currency: "USD", TargetDate: "2026-01-01", Status: "active"},
+	}
+	savings := map[string]interface{}{"balance": 1000.0, "currency": "USD"}
+	progress := goalProgress(goals, savings, pace, now)
+	want := map[string]bool{"g1": true, "g3": true, "g2": false}
+	for _, p := range progress {
+		if onTrack, ok := p["on_track"].(bool); !ok || onTrack != want[p["goal_id"].(string)] {
+			t.Errorf("%s: on_track = %v, want %v (%v)", p["goal_id"], p["on_track"], want[p["goal_id"].(string)], p)
+		}
+	}
+}
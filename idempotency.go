@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/engine"
+	"github.com/becomeliminal/nim-go-sdk/store"
+)
+
+// ============================================================================
+// IDEMPOTENCY  –  one execution per write, however often it's retried
+// ============================================================================
+// Every ExecuteWrite gets an idempotency key. A repeated key within
+// idempotencyTTL returns the original response instead of moving money
+// again. The mock executor also honors the key, so there it's passed
+// downstream in the input as "idempotency_key"; the Liminal API isn't known
+// to accept one, so live writes go out without it and are only deduplicated
+// here.
+//
+// The keys seen are kept in memory only: a restart forgets them, and a
+// write retried across a restart runs again.
+//
+// Keys come from a per-call ID carried in the context: the ID of the action
+// the user just confirmed (see confirmedCallMiddleware), or one the caller
+// sets itself, like the scheduler's run ID. Writes without one fall back to
+// the SDK's time-bucketed key. The request's RequestID is never used: the
+// engine sets it to the session ID, which is the same for every call in a
+// conversation, so two identical payments the user confirmed one after the
+// other would be taken for a retry.
+
+const (
+	idempotencyTTL     = 24 * time.Hour
+	confirmedActionTTL = time.Minute
+)
+
+// canonicalInput re-marshals a JSON object so key order and whitespace don't
+// affect keys. Any existing idempotency_key is left out.
+func canonicalInput(input json.RawMessage) (map[string]interface{}, string) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(input, &fields); err != nil || fields == nil {
+		return nil, string(input)
+	}
+	without := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		if k != "idempotency_key" {
+			without[k] = v
+		}
+	}
+	canonical, _ := json.Marshal(without)
+	return fields, string(canonical)
+}
+
+func hashKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ---------------------------------------------------------------------------
+// call IDs
+// ---------------------------------------------------------------------------
+
+type callIDKey struct{}
+
+// withCallID marks the writes made under ctx as one call, identified by id.
+func withCallID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, callIDKey{}, id)
+}
+
+// callID returns the ID set by withCallID, or "".
+func callID(ctx context.Context) string {
+	id, _ := ctx.Value(callIDKey{}).(string)
+	return id
+}
+
+// confirmedCallMiddleware gives a confirmed write the ID of the action the
+// user confirmed as its call ID.
+func confirmedCallMiddleware(actions *confirmedActions) toolMiddleware {
+	return func(tool core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			if tool.RequiresConfirmation() && callID(ctx) == "" {
+				_, canonical := canonicalInput(params.Input)
+				if actionID, ok := actions.take(params.UserID, tool.Name(), canonical); ok {
+					ctx = withCallID(ctx, actionID)
+				}
+			}
+			return next(ctx, params)
+		}
+	}
+}
+
+type replayedKey struct{}
+
+// watchReplay returns a context whose writes report, through the returned
+// flag, whether any of them was answered with an earlier response rather
+// than executed. Callers use it to count a payment only once.
+func watchReplay(ctx context.Context) (context.Context, *bool) {
+	replayed := new(bool)
+	return context.WithValue(ctx, replayedKey{}, replayed), replayed
+}
+
+// ---------------------------------------------------------------------------
+// confirmed actions
+// ---------------------------------------------------------------------------
+
+// confirmedActions remembers actions the user just confirmed, so the tool
+// call that follows can be keyed by the action ID.
+type confirmedActions struct {
+	mu      sync.Mutex
+	byInput map[string]confirmedAction
+}
+
+type confirmedAction struct {
+	id string
+	at time.Time
+}
+
+func newConfirmedActions() *confirmedActions {
+	return &confirmedActions{byInput: make(map[string]confirmedAction)}
+}
+
+func (c *confirmedActions) remember(action *core.PendingAction) {
+	_, canonical := canonicalInput(action.Input)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, a := range c.byInput {
+		if now.Sub(a.at) > confirmedActionTTL {
+			delete(c.byInput, k)
+		}
+	}
+	c.byInput[hashKey(action.UserID, action.Tool, canonical)] = confirmedAction{id: action.ID, at: now}
+}
+
+// take returns the ID of a just-confirmed action matching the call, if any.
+func (c *confirmedActions) take(userID, tool, canonical string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fp := hashKey(userID, tool, canonical)
+	a, ok := c.byInput[fp]
+	if !ok || time.Since(a.at) > confirmedActionTTL {
+		return "", false
+	}
+	delete(c.byInput, fp)
+	return a.id, true
+}
+
+// trackingConfirmations records confirmed actions on the way through.
+type trackingConfirmations struct {
+	store.Confirmations
+	actions *confirmedActions
+}
+
+func trackConfirmations(next store.Confirmations, actions *confirmedActions) store.Confirmations {
+	return &trackingConfirmations{Confirmations: next, actions: actions}
+}
+
+func (t *trackingConfirmations) Confirm(ctx context.Context, userID, actionID string) (*core.PendingAction, error) {
+	action, err := t.Confirmations.Confirm(ctx, userID, actionID)
+	if err == nil && action != nil {
+		t.actions.remember(action)
+	}
+	return action, err
+}
+
+// ---------------------------------------------------------------------------
+// executor
+// ---------------------------------------------------------------------------
+
+// idempotentExecutor wraps a core.ToolExecutor so each write runs once per
+// key. Failed calls are forgotten so they can be retried. forwardKeys
+// passes the key on as "idempotency_key", for executors that honor it.
+type idempotentExecutor struct {
+	next        core.ToolExecutor
+	forwardKeys bool
+
+	mu    sync.Mutex
+	calls map[string]*idempotentCall
+}
+
+type idempotentCall struct {
+	done chan struct{}
+	at   time.Time
+	resp *core.ExecuteResponse
+	err  error
+}
+
+var _ core.ToolExecutor = (*idempotentExecutor)(nil)
+
+func newIdempotentExecutor(next core.ToolExecutor, forwardKeys bool) *idempotentExecutor {
+	return &idempotentExecutor{
+		next:        next,
+		forwardKeys: forwardKeys,
+		calls:       make(map[string]*idempotentCall),
+	}
+}
+
+func (e *idempotentExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return e.next.Execute(ctx, req)
+}
+
+func (e *idempotentExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	fields, canonical := canonicalInput(req.Input)
+
+	var key string
+	switch existing, _ := fields["idempotency_key"].(string); {
+	case existing != "":
+		key = existing
+	case callID(ctx) != "":
+		key = hashKey("call", req.UserID, req.Tool, callID(ctx), canonical)
+	default:
+		key = engine.GenerateIdempotencyKey(req.UserID, req.Tool, json.RawMessage(canonical))
+	}
+
+	forward := *req
+	if fields != nil {
+		if e.forwardKeys {
+			fields["idempotency_key"] = key
+		} else {
+			delete(fields, "idempotency_key")
+		}
+		forward.Input, _ = json.Marshal(fields)
+	}
+	return e.once(ctx, "write:"+key, func() (*core.ExecuteResponse, error) {
+		return e.next.ExecuteWrite(ctx, &forward)
+	})
+}
+
+func (e *idempotentExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	return e.once(ctx, "confirm:"+hashKey(userID, confirmationID), func() (*core.ExecuteResponse, error) {
+		return e.next.Confirm(ctx, userID, confirmationID)
+	})
+}
+
+func (e *idempotentExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	return e.next.Cancel(ctx, userID, confirmationID)
+}
+
+// once runs fn for the first call with a key and hands every later call the
+// same outcome. Concurrent calls wait for the first one to finish.
+func (e *idempotentExecutor) once(ctx context.Context, key string, fn func() (*core.ExecuteResponse, error)) (*core.ExecuteResponse, error) {
+	now := time.Now()
+	e.mu.Lock()
+	for k, c := range e.calls {
+		if now.Sub(c.at) > idempotencyTTL {
+			delete(e.calls, k)
+		}
+	}
+	if c, ok := e.calls[key]; ok {
+		e.mu.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if c.err != nil {
+			return nil, c.err
+		}
+		slog.InfoContext(ctx, "idempotency: replaying earlier response", "key", key[:min(len(key), 20)])
+		if replayed, ok := ctx.Value(replayedKey{}).(*bool); ok {
+			*replayed = true
+		}
+		replay := *c.resp
+		return &replay, nil
+	}
+	c := &idempotentCall{done: make(chan struct{}), at: now}
+	e.calls[key] = c
+	e.mu.Unlock()
+
+	c.resp, c.err = fn()
+	if c.err == nil && c.resp == nil {
+		c.err = fmt.Errorf("executor returned no response")
+	}
+	if c.err != nil || !(c.resp.Success || c.resp.RequiresConfirmation) {
+		e.mu.Lock()
+		delete(e.calls, key)
+		e.mu.Unlock()
+	}
+	close(c.done)
+	return c.resp, c.err
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Writes are keyed by their call ID, not the request ID, which the engine
+// sets to the session ID for every call in a conversation.
+func TestIdempotencyKeysByCall(t *testing.T) {
+	captureLogs(t)
+	next := &writeRecorder{}
+	exec := newIdempotentExecutor(next, false)
+	send := func(ctx context.Context) {
+		t.Helper()
+		resp, err := exec.ExecuteWrite(ctx, &core.ExecuteRequest{
+			UserID:    "u1",
+			Tool:      "send_money",
+			Input:     json.RawMessage(`{"recipient": "@alice", "amount": "20.00"}`),
+			RequestID: "session-1",
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("send: %v %+v", err, resp)
+		}
+	}
+
+	first := withCallID(context.Background(), "action-1")
+	send(first)
+	send(withCallID(context.Background(), "action-2"))
+	if len(next.writes) != 2 {
+		t.Errorf("writes = %v, want two payments with the same session", next.writes)
+	}
+	send(first)
+	if len(next.writes) != 2 {
+		t.Errorf("writes = %v, want the retry of action-1 replayed", next.writes)
+	}
+}
+
+// The key goes downstream only to executors that honor it; the rest never
+// see one, even one the caller put in the input.
+func TestIdempotencyKeyForwarding(t *testing.T) {
+	for _, forward := range []bool{true, false} {
+		next := &writeRecorder{}
+		exec := newIdempotentExecutor(next, forward)
+		_, err := exec.ExecuteWrite(withCallID(context.Background(), "action-1"), &core.ExecuteRequest{
+			UserID: "u1",
+			Tool:   "send_money",
+			Input:  json.RawMessage(`{"recipient": "@alice", "amount": "20.00", "idempotency_key": "from-the-model"}`),
+		})
+		if err != nil || len(next.inputs) != 1 {
+			t.Fatalf("forward %v: %v, inputs %s", forward, err, next.inputs)
+		}
+		var input map[string]interface{}
+		json.Unmarshal(next.inputs[0], &input)
+		if _, ok := input["idempotency_key"]; ok != forward {
+			t.Errorf("forward %v: input %s", forward, next.inputs[0])
+		}
+	}
+}
+
+// A confirmed call is keyed by the ID of the action the user confirmed.
+func TestConfirmedCallMiddleware(t *testing.T) {
+	actions := newConfirmedActions()
+	actions.remember(&core.PendingAction{ID: "action-1", UserID: "u1", Tool: "send_money", Input: json.RawMessage(`{"amount": 20, "recipient": "@alice"}`)})
+
+	var got []string
+	tool := confirmedTool{name: "send_money"}
+	handler := confirmedCallMiddleware(actions)(tool, func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+		got = append(got, callID(ctx))
+		return &core.ToolResult{Success: true}, nil
+	})
+	for i := 0; i < 2; i++ {
+		handler(context.Background(), &core.ToolParams{UserID: "u1", Input: json.RawMessage(`{"recipient": "@alice", "amount": 20}`)})
+	}
+	if len(got) != 2 || got[0] != "action-1" || got[1] != "" {
+		t.Errorf("call IDs = %q, want the action ID once", got)
+	}
+}
+
+// confirmedTool is a write tool that only has a name.
+type confirmedTool struct {
+	core.Tool
+	name string
+}
+
+func (t confirmedTool) Name() string               { return t.name }
+func (t confirmedTool) RequiresConfirmation() bool { return true }
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/executor"
+)
+
+// ============================================================================
+// LIVE IDENTITY  –  who a Liminal JWT belongs to, checked with Liminal
+// ============================================================================
+// The server doesn't hold Liminal's signing key, so a token is verified the
+// cheap way: a get_balance call made with it. Liminal rejects a forged or
+// expired token, and a token it accepts belongs to the user named in its
+// "sub" claim, which becomes the user ID every per-user store is keyed by.
+// Verified tokens are remembered until they expire, at most
+// tokenVerifyTTL, so an active client costs one round trip, not one per
+// request.
+
+// tokenVerifyTTL bounds how long a verified token is trusted without
+// asking Liminal again.
+const tokenVerifyTTL = 5 * time.Minute
+
+type liminalVerifier struct {
+	// newExec builds an executor that calls Liminal with token.
+	newExec func(token string) core.ToolExecutor
+
+	mu       sync.Mutex
+	verified map[string]verifiedToken // by hashKey(token)
+}
+
+type verifiedToken struct {
+	userID  string
+	expires time.Time
+}
+
+func newLiminalVerifier(newExec func(token string) core.ToolExecutor) *liminalVerifier {
+	return &liminalVerifier{newExec: newExec, verified: make(map[string]verifiedToken)}
+}
+
+// liminalExecutors builds executors that call the Liminal API at baseURL
+// with one user's JWT.
+func liminalExecutors(baseURL string) func(token string) core.ToolExecutor {
+	return func(token string) core.ToolExecutor {
+		return executor.NewHTTPExecutor(executor.HTTPExecutorConfig{
+			BaseURL:  baseURL,
+			JWTToken: token,
+		})
+	}
+}
+
+// verify returns the user ID token belongs to, or an error if it isn't a
+// Liminal JWT Liminal accepts.
+func (v *liminalVerifier) verify(ctx context.Context, token string) (string, error) {
+	claims, err := jwtClaims(token)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if claims.expires.IsZero() || claims.expires.After(now.Add(tokenVerifyTTL)) {
+		claims.expires = now.Add(tokenVerifyTTL)
+	}
+	if !claims.expires.After(now) {
+		return "", errors.New("the Liminal JWT has expired")
+	}
+
+	key := hashKey(token)
+	v.mu.Lock()
+	cached, ok := v.verified[key]
+	v.mu.Unlock()
+	if ok && cached.expires.After(now) {
+		return cached.userID, nil
+	}
+
+	resp, err := v.newExec(token).Execute(ctx, &core.ExecuteRequest{
+		UserID: claims.subject,
+		Tool:   "get_balance",
+		Input:  json.RawMessage(`{}`),
+	})
+	if err != nil {
+		return "", fmt.Errorf("couldn't check the token with Liminal: %w", err)
+	}
+	if !resp.Success {
+		return "", errors.New("Liminal rejected the token")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for k, t := range v.verified {
+		if !t.expires.After(now) {
+			delete(v.verified, k)
+		}
+	}
+	v.verified[key] = verifiedToken{userID: claims.subject, expires: claims.expires}
+	return claims.subject, nil
+}
+
+type tokenClaims struct {
+	subject string
+	expires time.Time // zero if the token doesn't say
+}
+
+// jwtClaims reads the subject and expiry from a JWT's payload without
+// checking its signature; verify leaves that to Liminal.
+func jwtClaims(token string) (tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims{}, errors.New("a Liminal JWT is required as the bearer token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return tokenClaims{}, errors.New("the bearer token is not a JWT")
+	}
+	var claims struct {
+		Sub    string  `json:"sub"`
+		UserID string  `json:"user_id"`
+		Exp    float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return tokenClaims{}, errors.New("the bearer token is not a JWT")
+	}
+	c := tokenClaims{subject: claims.Sub}
+	if c.subject == "" {
+		c.subject = claims.UserID
+	}
+	if c.subject == "" {
+		return tokenClaims{}, errors.New("the Liminal JWT names no user (sub)")
+	}
+	if claims.Exp > 0 {
+		c.expires = time.Unix(int64(claims.Exp), 0)
+	}
+	return c, nil
+}
+
+// userExecutors routes each executor call to an executor holding the JWT its
+// user last connected with, so one live user's tool calls never run with
+// another's token.
+type userExecutors struct {
+	newExec func(token string) core.ToolExecutor
+
+	mu     sync.Mutex
+	byUser map[string]userExecutor
+}
+
+type userExecutor struct {
+	token string
+	exec  core.ToolExecutor
+}
+
+func newUserExecutors(newExec func(token string) core.ToolExecutor) *userExecutors {
+	return &userExecutors{newExec: newExec, byUser: make(map[string]userExecutor)}
+}
+
+// set makes token, already verified as userID's, the one userID's calls use.
+func (e *userExecutors) set(userID, token string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.byUser[userID].token != token {
+		e.byUser[userID] = userExecutor{token: token, exec: e.newExec(token)}
+	}
+}
+
+func (e *userExecutors) get(userID string) (core.ToolExecutor, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	u, ok := e.byUser[userID]
+	if !ok {
+		return nil, fmt.Errorf("no Liminal token for user %q: connect again", userID)
+	}
+	return u.exec, nil
+}
+
+func (e *userExecutors) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	exec, err := e.get(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return exec.Execute(ctx, req)
+}
+
+func (e *userExecutors) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	exec, err := e.get(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return exec.ExecuteWrite(ctx, req)
+}
+
+func (e *userExecutors) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	exec, err := e.get(userID)
+	if err != nil {
+		return nil, err
+	}
+	return exec.Confirm(ctx, userID, confirmationID)
+}
+
+func (e *userExecutors) Cancel(ctx context.Context, userID, confirmationID string) error {
+	exec, err := e.get(userID)
+	if err != nil {
+		return err
+	}
+	return exec.Cancel(ctx, userID, confirmationID)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+func TestLiminalVerifier(t *testing.T) {
+	alice := testJWT("alice")
+	liminal, _ := fakeLiminal(t, alice)
+	calls := 0
+	newExec := liminalExecutors(liminal.URL)
+	v := newLiminalVerifier(func(token string) core.ToolExecutor {
+		calls++
+		return newExec(token)
+	})
+
+	for i := 0; i < 3; i++ {
+		if userID, err := v.verify(context.Background(), alice); err != nil || userID != "alice" {
+			t.Fatalf("verify alice: %q %v", userID, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Liminal was asked %d times, want once for a remembered token", calls)
+	}
+
+	expired := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","exp":1}`)) + ".sig"
+	noSubject := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":9999999999}`)) + ".sig"
+	for name, token := range map[string]string{
+		"rejected by Liminal": testJWT("mallory"),
+		"expired":             expired,
+		"no subject":          noSubject,
+		"not a JWT":           "a.b.c",
+		"too few parts":       "abc",
+	} {
+		if userID, err := v.verify(context.Background(), token); err == nil {
+			t.Errorf("%s: verified as %q", name, userID)
+		}
+	}
+
+	claims, err := jwtClaims(testJWT("bob"))
+	if err != nil || claims.subject != "bob" || claims.expires.Before(time.Now()) {
+		t.Errorf("claims = %+v, %v", claims, err)
+	}
+}
+
+// tokenEcho is an executor that answers with the token it was built with.
+type tokenEcho struct {
+	core.ToolExecutor
+	token string
+}
+
+func (e tokenEcho) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return &core.ExecuteResponse{Success: true, Data: json.RawMessage(strconv.Quote(e.token))}, nil
+}
+
+// Each live user's calls carry their own JWT, whoever connected last.
+func TestUserExecutorsRouteByUser(t *testing.T) {
+	execs := newUserExecutors(func(token string) core.ToolExecutor { return tokenEcho{token: token} })
+	execs.set("alice", "alice-token")
+	execs.set("bob", "bob-token")
+
+	for user, want := range map[string]string{"alice": `"alice-token"`, "bob": `"bob-token"`} {
+		resp, err := execs.Execute(context.Background(), &core.ExecuteRequest{UserID: user, Tool: "get_balance"})
+		if err != nil || string(resp.Data) != want {
+			t.Errorf("%s: %v %v, want %s", user, resp, err, want)
+		}
+	}
+
+	execs.set("alice", "alice-new-token")
+	if resp, err := execs.Execute(context.Background(), &core.ExecuteRequest{UserID: "alice"}); err != nil || string(resp.Data) != `"alice-new-token"` {
+		t.Errorf("after reconnecting: %v %v", resp, err)
+	}
+	if _, err := execs.ExecuteWrite(context.Background(), &core.ExecuteRequest{UserID: "mallory", Tool: "send_money"}); err == nil {
+		t.Error("a user who never connected got an executor")
+	}
+}
+
+// Live /ws connections need a token Liminal accepts.
+func TestLiveWebSocketAuth(t *testing.T) {
+	captureLogs(t)
+	alice := testJWT("alice")
+	liminal, _ := fakeLiminal(t, alice)
+	conf := config.Default()
+	conf.AnthropicKey = "test-key"
+	conf.LiminalBaseURL = liminal.URL
+	conf.DataDir = t.TempDir()
+	a, err := newApp(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	srv := httptest.NewServer(a.Handler())
+	defer srv.Close()
+
+	for token, unauthorized := range map[string]bool{"a.b.c": true, testJWT("mallory"): true, alice: false} {
+		resp, err := http.Get(srv.URL + "/ws?token=" + token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		// A plain GET that gets past authentication fails the upgrade.
+		if (resp.StatusCode == http.StatusUnauthorized) != unauthorized {
+			t.Errorf("token %q: %d, want 401: %v", token, resp.StatusCode, unauthorized)
+		}
+	}
+}
+
+// Live imports are kept per verified user: what alice uploads shows up in
+// her analysis and not in bob's.
+func TestLiveImportsPerUser(t *testing.T) {
+	captureLogs(t)
+	alice, bob := testJWT("alice"), testJWT("bob")
+	liminal, _ := fakeLiminal(t, alice, bob)
+	conf := config.Default()
+	conf.AnthropicKey = "test-key"
+	conf.LiminalBaseURL = liminal.URL
+	conf.DataDir = t.TempDir()
+	a, err := newApp(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	srv := httptest.NewServer(a.Handler())
+	defer srv.Close()
+
+	statement := "Date,Description,Amount\n" + time.Now().AddDate(0, 0, -2).Format("2006-01-02") + ",Alice's Gym,-40.00\n"
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/imports?account=Other+Bank", strings.NewReader(statement))
+	req.Header.Set("Authorization", "Bearer "+alice)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("live POST /api/imports: %d", resp.StatusCode)
+	}
+
+	for token, want := range map[string]bool{alice: true, bob: false} {
+		status, body := apiGet(t, srv.URL+"/api/spending?days=30", token)
+		raw, _ := json.Marshal(body)
+		if status != http.StatusOK || strings.Contains(string(raw), "Other Bank") != want {
+			t.Errorf("spending: %d %s, want the import: %v", status, raw, want)
+		}
+	}
+	if accounts := a.imports.Get("alice"); len(accounts) != 1 || len(a.imports.Get("bob")) != 0 {
+		t.Errorf("imports: alice %+v, bob %+v", accounts, a.imports.Get("bob"))
+	}
+}
@@ -0,0 +1,834 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// IMPORTED ACCOUNTS  –  statements from other banks, analyzed with Liminal's
+// ============================================================================
+// Users upload CSV or OFX statements to POST /api/imports. Each transaction
+// is normalized to the get_transactions shape (positive amount, send or
+// receive, RFC3339 date) and tagged with the account it came from, then
+// stored per user. Re-uploading an overlapping statement only adds what's
+// new: IDs come from the bank's transaction ID, or from the row's contents.
+//
+// The analyzers read transactions through withImportedTransactions, which
+// adds the imported ones to every get_transactions response, tagged with
+// their account (Liminal's are tagged "liminal"). get_transactions itself,
+// budgets and the rest still see Liminal only.
+//
+// A statement's closing balance (OFX LEDGERBAL, or a CSV balance column) is
+// kept as the account's balance for get_net_worth (see networth.go).
+
+const (
+	// maxImportBytes caps one uploaded statement.
+	maxImportBytes = 5 << 20
+
+	// maxImportedTransactions is kept per account, newest first.
+	maxImportedTransactions = 5000
+
+	// liminalAccount tags Liminal's own transactions; no import can use it.
+	liminalAccount = "liminal"
+)
+
+// importedTransaction is one statement line in the get_transactions shape.
+type importedTransaction struct {
+	ID          string  `json:"id"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Type        string  `json:"type"` // send | receive
+	Status      string  `json:"status"`
+	Description string  `json:"description"`
+	Date        string  `json:"date"`
+	CreatedAt   string  `json:"created_at"`
+	Account     string  `json:"account"`
+	Source      string  `json:"source"` // always "import"
+}
+
+type importedAccount struct {
+	Name         string                `json:"name"`
+	Transactions []importedTransaction `json:"transactions"`
+	Balance      *accountBalance       `json:"balance,omitempty"`
+	LastImport   time.Time             `json:"last_import"`
+	LastFormat   string                `json:"last_format"`
+}
+
+// accountBalance is an account's closing balance on the newest statement
+// imported. Negative means the user owes it, as on a credit card.
+type accountBalance struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	AsOf     string  `json:"as_of"` // YYYY-MM-DD
+}
+
+type importStore = jsonStore[[]importedAccount]
+
+func newImportStore(dataDir string) (*importStore, error) {
+	return newJSONStore[[]importedAccount](filepath.Join(dataDir, "imports.json"))
+}
+
+// mergeImport adds txs to the user's account, creating it if needed, and
+// reports how many were new and how many it already had. balance, if the
+// statement had one, replaces the account's unless it's older.
+func mergeImport(imports *importStore, userID, account, format string, txs []importedTransaction, balance *accountBalance) (added, duplicates int, err error) {
+	err = imports.Update(userID, func(accounts *[]importedAccount) error {
+		i := slices.IndexFunc(*accounts, func(a importedAccount) bool { return strings.EqualFold(a.Name, account) })
+		if i < 0 {
+			*accounts = append(*accounts, importedAccount{Name: account})
+			i = len(*accounts) - 1
+		}
+		acct := &(*accounts)[i]
+		seen := make(map[string]bool, len(acct.Transactions))
+		for _, tx := range acct.Transactions {
+			seen[tx.ID] = true
+		}
+		added, duplicates = 0, 0
+		for _, tx := range txs {
+			if seen[tx.ID] {
+				duplicates++
+				continue
+			}
+			seen[tx.ID] = true
+			tx.Account = acct.Name
+			acct.Transactions = append(acct.Transactions, tx)
+			added++
+		}
+		// RFC3339 dates in one zone sort as strings; parse to be safe
+		// across zones.
+		sort.SliceStable(acct.Transactions, func(a, b int) bool {
+			ta, _ := time.Parse(time.RFC3339, acct.Transactions[a].Date)
+			tb, _ := time.Parse(time.RFC3339, acct.Transactions[b].Date)
+			return ta.After(tb)
+		})
+		if len(acct.Transactions) > maxImportedTransactions {
+			acct.Transactions = acct.Transactions[:maxImportedTransactions]
+		}
+		if balance != nil && (acct.Balance == nil || balance.AsOf >= acct.Balance.AsOf) {
+			acct.Balance = balance
+		}
+		acct.LastImport = time.Now()
+		acct.LastFormat = format
+		return nil
+	})
+	return added, duplicates, err
+}
+
+// ---------------------------------------------------------------------------
+// statement parsing
+// ---------------------------------------------------------------------------
+
+// statementOptions say how to read a statement's ambiguous parts.
+type statementOptions struct {
+	account  string
+	currency string // when the statement doesn't say
+	dayFirst bool   // 03/04/2025 is 3 April
+}
+
+// parseStatement normalizes a CSV or OFX statement. Lines it can't read are
+// returned as problems ("line 7: ..."); the rest are still imported.
+func parseStatement(format string, data []byte, opts statementOptions) ([]importedTransaction, []toolkit.InputProblem) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if format == "ofx" {
+		return parseOFXStatement(data, opts)
+	}
+	return parseCSVStatement(data, opts)
+}
+
+// detectStatementFormat picks ofx or csv from the file name, then the
+// content.
+func detectStatementFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ofx", ".qfx":
+		return "ofx"
+	case ".csv":
+		return "csv"
+	}
+	head := strings.ToUpper(string(data[:min(len(data), 512)]))
+	if strings.Contains(head, "OFXHEADER") || strings.Contains(head, "<OFX>") {
+		return "ofx"
+	}
+	return "csv"
+}
+
+// csvColumns are the header names banks use for each field, lowercased and
+// in order of preference.
+var csvColumns = map[string][]string{
+	"date":        {"date", "posted date", "posting date", "transaction date", "booking date", "value date"},
+	"description": {"description", "payee", "name", "merchant", "details", "narrative", "memo"},
+	"amount":      {"amount", "transaction amount"},
+	"debit":       {"debit", "debit amount", "withdrawal", "withdrawals", "money out", "paid out"},
+	"credit":      {"credit", "credit amount", "deposit", "deposits", "money in", "paid in"},
+	"direction":   {"type", "transaction type", "credit/debit", "dr/cr"},
+	"currency":    {"currency"},
+	"id":          {"id", "transaction id", "reference", "fitid"},
+	"balance":     {"balance", "running balance", "ledger balance", "available balance"},
+}
+
+// csvHeader maps each field in csvColumns to its column in the header row.
+func csvHeader(row []string) map[string]int {
+	header := make(map[string]int)
+	for i, name := range row {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := header[name]; !ok {
+			header[name] = i
+		}
+	}
+	col := make(map[string]int)
+	for field, names := range csvColumns {
+		for _, name := range names {
+			if i, ok := header[name]; ok {
+				col[field] = i
+				break
+			}
+		}
+	}
+	return col
+}
+
+// csvCell reads a field's cell from a row, or "" if the statement has no
+// such column.
+func csvCell(col map[string]int, row []string, field string) string {
+	if i, ok := col[field]; ok && i < len(row) {
+		return strings.TrimSpace(row[i])
+	}
+	return ""
+}
+
+func parseCSVStatement(data []byte, opts statementOptions) ([]importedTransaction, []toolkit.InputProblem) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, []toolkit.InputProblem{{Field: "file", Problem: "is not valid CSV: " + err.Error()}}
+	}
+	if len(records) < 2 {
+		return nil, []toolkit.InputProblem{{Field: "file", Problem: "needs a header row and at least one transaction"}}
+	}
+
+	col := csvHeader(records[0])
+	var missing []string
+	for _, field := range []string{"date", "description"} {
+		if _, ok := col[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	_, hasAmount := col["amount"]
+	_, hasDebit := col["debit"]
+	_, hasCredit := col["credit"]
+	if !hasAmount && !hasDebit && !hasCredit {
+		missing = append(missing, "amount (or debit/credit)")
+	}
+	if len(missing) > 0 {
+		return nil, []toolkit.InputProblem{{
+			Field:   "header",
+			Problem: "has no column for " + strings.Join(missing, ", "),
+			Got:     strings.Join(records[0], ","),
+		}}
+	}
+
+	cell := func(row []string, field string) string { return csvCell(col, row, field) }
+	var txs []importedTransaction
+	var problems []toolkit.InputProblem
+	seen := make(map[string]int)
+	for n, row := range records[1:] {
+		line := fmt.Sprintf("line %d", n+2)
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		date, err := parseStatementDate(cell(row, "date"), opts.dayFirst)
+		if err != nil {
+			problems = append(problems, toolkit.InputProblem{Field: line, Problem: "has a date that isn't one", Got: cell(row, "date")})
+			continue
+		}
+		var amount float64
+		switch {
+		case cell(row, "amount") != "":
+			amount, err = parseStatementAmount(cell(row, "amount"))
+			if err == nil {
+				switch strings.ToLower(cell(row, "direction")) {
+				case "debit", "dr", "d":
+					amount = -math.Abs(amount)
+				case "credit", "cr", "c":
+					amount = math.Abs(amount)
+				}
+			}
+		case cell(row, "debit") != "" || cell(row, "credit") != "":
+			amount, err = debitCredit(cell(row, "debit"), cell(row, "credit"))
+		default:
+			err = errors.New("no amount")
+		}
+		if err != nil || amount == 0 {
+			problems = append(problems, toolkit.InputProblem{Field: line, Problem: "has no amount that can be read", Got: cell(row, "amount") + cell(row, "debit") + cell(row, "credit")})
+			continue
+		}
+		currency := strings.ToUpper(cell(row, "currency"))
+		if currency == "" {
+			currency = opts.currency
+		}
+		description := cell(row, "description")
+
+		// Without a bank ID, a row is identified by what it says, counting
+		// identical rows so two same-day coffees stay two.
+		key := cell(row, "id")
+		if key == "" {
+			content := fmt.Sprintf("%s|%.2f|%s|%s", date.Format("2006-01-02"), amount, currency, description)
+			seen[content]++
+			key = fmt.Sprintf("%s|%d", content, seen[content])
+		}
+		txs = append(txs, normalizeImported(opts.account, key, date, amount, currency, description))
+	}
+	return txs, problems
+}
+
+// debitCredit reads the amount of a row with separate debit and credit
+// columns. Banks leave the unused one blank or put "0.00" in it, so
+// whichever is non-zero counts.
+func debitCredit(debit, credit string) (float64, error) {
+	var d, c float64
+	var err error
+	if debit != "" {
+		if d, err = parseStatementAmount(debit); err != nil {
+			return 0, err
+		}
+	}
+	if credit != "" {
+		if c, err = parseStatementAmount(credit); err != nil {
+			return 0, err
+		}
+	}
+	switch {
+	case d != 0 && c != 0:
+		return 0, errors.New("both a debit and a credit")
+	case d != 0:
+		return -math.Abs(d), nil
+	}
+	return math.Abs(c), nil
+}
+
+var (
+	ofxTransaction = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	ofxCurrency    = regexp.MustCompile(`(?i)<CURDEF>\s*([A-Za-z]{3})`)
+)
+
+// ofxField reads an OFX element's value, in both the SGML (unclosed) and
+// XML forms.
+func ofxField(block, name string) string {
+	re := regexp.MustCompile(`(?i)<` + name + `>([^<\r\n]*)`)
+	m := re.FindStringSubmatch(block)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">").Replace(m[1]))
+}
+
+func parseOFXStatement(data []byte, opts statementOptions) ([]importedTransaction, []toolkit.InputProblem) {
+	text := string(data)
+	currency := opts.currency
+	if m := ofxCurrency.FindStringSubmatch(text); m != nil {
+		currency = strings.ToUpper(m[1])
+	}
+	blocks := ofxTransaction.FindAllStringSubmatch(text, -1)
+	if len(blocks) == 0 {
+		return nil, []toolkit.InputProblem{{Field: "file", Problem: "has no <STMTTRN> transactions"}}
+	}
+	var txs []importedTransaction
+	var problems []toolkit.InputProblem
+	for n, m := range blocks {
+		where := fmt.Sprintf("transaction %d", n+1)
+		block := m[1]
+		posted := ofxField(block, "DTPOSTED")
+		date, err := parseOFXDate(posted)
+		if err != nil {
+			problems = append(problems, toolkit.InputProblem{Field: where, Problem: "has a DTPOSTED that isn't a date", Got: posted})
+			continue
+		}
+		amount, err := parseStatementAmount(ofxField(block, "TRNAMT"))
+		if err != nil || amount == 0 {
+			problems = append(problems, toolkit.InputProblem{Field: where, Problem: "has no TRNAMT that can be read", Got: ofxField(block, "TRNAMT")})
+			continue
+		}
+		description := ofxField(block, "NAME")
+		if description == "" {
+			description = ofxField(block, "MEMO")
+		}
+		key := ofxField(block, "FITID")
+		if key == "" {
+			key = fmt.Sprintf("%s|%.2f|%s|%d", posted, amount, description, n)
+		}
+		txs = append(txs, normalizeImported(opts.account, key, date, amount, currency, description))
+	}
+	return txs, problems
+}
+
+var ofxLedgerBalance = regexp.MustCompile(`(?is)<LEDGERBAL>(.*?)</LEDGERBAL>`)
+
+// parseStatementBalance reads the closing balance a statement states, or
+// returns nil if it states none. In a CSV that's the balance on the latest
+// dated row; rows on the same day are taken in the statement's own order,
+// which may run either way.
+func parseStatementBalance(format string, data []byte, opts statementOptions) *accountBalance {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if format == "ofx" {
+		text := string(data)
+		m := ofxLedgerBalance.FindStringSubmatch(text)
+		if m == nil {
+			return nil
+		}
+		amount, err := parseStatementAmount(ofxField(m[1], "BALAMT"))
+		if err != nil {
+			return nil
+		}
+		currency := opts.currency
+		if c := ofxCurrency.FindStringSubmatch(text); c != nil {
+			currency = strings.ToUpper(c[1])
+		}
+		asOf, err := parseOFXDate(ofxField(m[1], "DTASOF"))
+		if err != nil {
+			asOf = time.Now()
+		}
+		return &accountBalance{Amount: amount, Currency: currency, AsOf: asOf.Format("2006-01-02")}
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil || len(records) < 2 {
+		return nil
+	}
+	col := csvHeader(records[0])
+	if _, ok := col["balance"]; !ok {
+		return nil
+	}
+	type dated struct {
+		date    time.Time
+		balance accountBalance
+	}
+	var rows []dated
+	for _, row := range records[1:] {
+		date, err := parseStatementDate(csvCell(col, row, "date"), opts.dayFirst)
+		if err != nil {
+			continue
+		}
+		amount, err := parseStatementAmount(csvCell(col, row, "balance"))
+		if err != nil {
+			continue
+		}
+		currency := strings.ToUpper(csvCell(col, row, "currency"))
+		if currency == "" {
+			currency = opts.currency
+		}
+		rows = append(rows, dated{date, accountBalance{Amount: amount, Currency: currency, AsOf: date.Format("2006-01-02")}})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	newestFirst := rows[0].date.After(rows[len(rows)-1].date)
+	latest := rows[0]
+	for _, row := range rows[1:] {
+		if row.date.After(latest.date) || (row.date.Equal(latest.date) && !newestFirst) {
+			latest = row
+		}
+	}
+	return &latest.balance
+}
+
+// normalizeImported builds the get_transactions shape from a signed amount.
+func normalizeImported(account, key string, date time.Time, amount float64, currency, description string) importedTransaction {
+	txType := "receive"
+	if amount < 0 {
+		txType = "send"
+	}
+	if description == "" {
+		description = "Unknown"
+	}
+	return importedTransaction{
+		ID:          "imp_" + hashKey(strings.ToLower(account), key)[:16],
+		Amount:      analytics.RoundMoney(math.Abs(amount)),
+		Currency:    currency,
+		Type:        txType,
+		Status:      "completed",
+		Description: strings.Join(strings.Fields(description), " "),
+		Date:        date.Format(time.RFC3339),
+		CreatedAt:   date.Format(time.RFC3339),
+		Account:     account,
+		Source:      "import",
+	}
+}
+
+// parseStatementAmount reads amounts as banks write them: "$1,234.56",
+// "-12.50", "(12.50)", "12.50-", "12.50 DR".
+func parseStatementAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	upper := strings.ToUpper(s)
+	switch {
+	case strings.HasSuffix(upper, "DR"):
+		negative, s = true, s[:len(s)-2]
+	case strings.HasSuffix(upper, "CR"):
+		s = s[:len(s)-2]
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, s = true, s[1:len(s)-1]
+	}
+	if strings.HasSuffix(s, "-") {
+		negative, s = true, strings.TrimSuffix(s, "-")
+	}
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune("$€£¥, ", r) {
+			return -1
+		}
+		return r
+	}, s)
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		f = -math.Abs(f)
+	}
+	return f, nil
+}
+
+// parseStatementDate accepts the date layouts banks export. Slashed dates
+// are month first unless dayFirst is set.
+func parseStatementDate(s string, dayFirst bool) (time.Time, error) {
+	layouts := []string{time.RFC3339, "2006-01-02", "2006/01/02", "Jan 2, 2006", "2 Jan 2006", "02 Jan 2006", "02-Jan-2006"}
+	if dayFirst {
+		layouts = append(layouts, "02/01/2006", "2/1/2006", "02/01/06", "02.01.2006")
+	} else {
+		layouts = append(layouts, "01/02/2006", "1/2/2006", "01/02/06")
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// parseOFXDate reads DTPOSTED: YYYYMMDD, optionally followed by HHMMSS,
+// milliseconds and a [offset:zone] suffix, which is dropped.
+func parseOFXDate(s string) (time.Time, error) {
+	if i := strings.IndexAny(s, ".["); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) >= 14 {
+		return time.Parse("20060102150405", s[:14])
+	}
+	if len(s) >= 8 {
+		return time.Parse("20060102", s[:8])
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// ---------------------------------------------------------------------------
+// upload endpoint
+// ---------------------------------------------------------------------------
+
+// importHandler serves POST /api/imports?account=Chase+Checking. The
+// statement is the request body, or the "file" field of a multipart form.
+// Optional: format (csv | ofx, detected otherwise), currency (for CSVs
+// without a currency column, default USD) and day_first=true.
+func importHandler(imports *importStore) func(w http.ResponseWriter, r *http.Request, caller apiCaller) {
+	return func(w http.ResponseWriter, r *http.Request, caller apiCaller) {
+		query := r.URL.Query()
+		var problems []toolkit.InputProblem
+		account := strings.Join(strings.Fields(query.Get("account")), " ")
+		switch {
+		case account == "":
+			problems = append(problems, toolkit.InputProblem{Field: "account", Problem: "is required: name the account the statement is from"})
+		case len(account) > 64:
+			problems = append(problems, toolkit.InputProblem{Field: "account", Problem: "must be at most 64 characters", Got: account})
+		case strings.EqualFold(account, liminalAccount):
+			problems = append(problems, toolkit.InputProblem{Field: "account", Problem: "can't be \"liminal\", which is the Liminal wallet", Got: account})
+		}
+		format := strings.ToLower(query.Get("format"))
+		if format != "" && format != "csv" && format != "ofx" {
+			problems = append(problems, toolkit.InputProblem{Field: "format", Problem: "must be csv or ofx", Got: format})
+		}
+		currency := strings.ToUpper(strings.TrimSpace(query.Get("currency")))
+		if currency == "" {
+			currency = "USD"
+		}
+		if len(problems) > 0 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_input", "fix the parameters listed in problems", problems)
+			return
+		}
+
+		data, filename, err := readStatement(w, r)
+		if err != nil {
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeAPIError(w, status, "invalid_statement", err.Error(), nil)
+			return
+		}
+		if format == "" {
+			format = detectStatementFormat(filename, data)
+		}
+
+		opts := statementOptions{
+			account:  account,
+			currency: currency,
+			dayFirst: query.Get("day_first") == "true",
+		}
+		txs, skipped := parseStatement(format, data, opts)
+		if len(txs) == 0 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_statement", "no transactions could be read from the "+format+" statement", skipped)
+			return
+		}
+		balance := parseStatementBalance(format, data, opts)
+		added, duplicates, err := mergeImport(imports, caller.userID, account, format, txs, balance)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, "save_failed", err.Error(), nil)
+			return
+		}
+		slog.Info("statement imported", "user_id", caller.userID, "account", account, "format", format,
+			"added", added, "duplicates", duplicates, "skipped", len(skipped))
+
+		body := map[string]interface{}{
+			"account":    account,
+			"format":     format,
+			"added":      added,
+			"duplicates": duplicates,
+		}
+		if balance != nil {
+			body["balance"] = balance
+		}
+		if len(skipped) > 0 {
+			body["skipped"] = skipped
+		}
+		writeJSON(w, http.StatusOK, body)
+	}
+}
+
+// readStatement reads the upload, up to maxImportBytes.
+func readStatement(w http.ResponseWriter, r *http.Request) ([]byte, string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("the form needs the statement in a \"file\" field: %w", err)
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		return data, header.Filename, err
+	}
+	data, err := io.ReadAll(r.Body)
+	if err == nil && len(bytes.TrimSpace(data)) == 0 {
+		err = errors.New("the request body is empty; send the statement file as the body")
+	}
+	return data, "", err
+}
+
+// ---------------------------------------------------------------------------
+// executor  –  imported transactions alongside Liminal's
+// ---------------------------------------------------------------------------
+
+// importingExecutor adds the user's imported transactions to get_transactions
+// responses. Everything else passes straight through.
+type importingExecutor struct {
+	next    core.ToolExecutor
+	imports *importStore
+}
+
+var _ core.ToolExecutor = (*importingExecutor)(nil)
+
+// withImportedTransactions wraps exec so reads include imported accounts, or
+// returns exec as it is when there's no import store.
+func withImportedTransactions(exec core.ToolExecutor, imports *importStore) core.ToolExecutor {
+	if imports == nil {
+		return exec
+	}
+	return &importingExecutor{next: exec, imports: imports}
+}
+
+// Execute merges in the imported transactions since start_date, newest
+// first, up to the same limit as the Liminal request, so a large import
+// can't crowd Liminal's transactions out of an analysis.
+func (e *importingExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	resp, err := e.next.Execute(ctx, req)
+	if err != nil || resp == nil || !resp.Success || req.Tool != "get_transactions" {
+		return resp, err
+	}
+	accounts := e.imports.Get(req.UserID)
+	if len(accounts) == 0 {
+		return resp, nil
+	}
+
+	var params struct {
+		Limit     int    `json:"limit"`
+		StartDate string `json:"start_date"`
+	}
+	_ = json.Unmarshal(req.Input, &params)
+	if params.Limit <= 0 {
+		params.Limit = 20
+	}
+	var since time.Time
+	if params.StartDate != "" {
+		since, _ = time.Parse("2006-01-02", params.StartDate)
+	}
+
+	var data map[string]interface{}
+	if len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return resp, nil
+		}
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	list, _ := data["transactions"].([]interface{})
+	for _, tx := range list {
+		if m, ok := tx.(map[string]interface{}); ok && m["account"] == nil {
+			m["account"] = liminalAccount
+		}
+	}
+
+	names := []string{liminalAccount}
+	for _, acct := range accounts {
+		names = append(names, acct.Name)
+		n := 0
+		for _, tx := range acct.Transactions {
+			if n == params.Limit {
+				break
+			}
+			if t, err := time.Parse(time.RFC3339, tx.Date); err == nil && t.Before(since) {
+				break // newest first, so the rest are older still
+			}
+			list = append(list, tx)
+			n++
+		}
+	}
+	data["transactions"] = list
+	data["total"] = len(list)
+	data["accounts"] = names
+
+	merged, err := json.Marshal(data)
+	if err != nil {
+		return resp, nil
+	}
+	out := *resp
+	out.Data = merged
+	return &out, nil
+}
+
+func (e *importingExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	return e.next.ExecuteWrite(ctx, req)
+}
+
+func (e *importingExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	return e.next.Confirm(ctx, userID, confirmationID)
+}
+
+func (e *importingExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	return e.next.Cancel(ctx, userID, confirmationID)
+}
+
+// ---------------------------------------------------------------------------
+// tools
+// ---------------------------------------------------------------------------
+
+func createImportedAccountTools(imports *importStore) []core.Tool {
+	return []core.Tool{
+		createListImportedAccountsTool(imports),
+		createRemoveImportedAccountTool(imports),
+	}
+}
+
+func createListImportedAccountsTool(imports *importStore) core.Tool {
+	return tools.New("list_imported_accounts").
+		Description("List the accounts at other banks the user has imported statements from, with how many transactions each has, the dates they cover and the balance on the latest statement. analyze_spending and analyze_subscriptions include these accounts alongside Liminal, and get_net_worth includes their balances.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			accounts := imports.Get(toolParams.UserID)
+			list := make([]map[string]interface{}, 0, len(accounts))
+			for _, acct := range accounts {
+				entry := map[string]interface{}{
+					"name":         acct.Name,
+					"transactions": len(acct.Transactions),
+					"last_import":  acct.LastImport.Format(time.RFC3339),
+					"last_format":  acct.LastFormat,
+				}
+				if n := len(acct.Transactions); n > 0 {
+					entry["newest"] = acct.Transactions[0].Date[:10]
+					entry["oldest"] = acct.Transactions[n-1].Date[:10]
+				}
+				if acct.Balance != nil {
+					entry["balance"] = acct.Balance
+				}
+				list = append(list, entry)
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"accounts":   list,
+					"how_to_add": "upload a CSV or OFX statement to POST /api/imports?account=<name>",
+				},
+			}, nil
+		}).
+		Build()
+}
+
+func createRemoveImportedAccountTool(imports *importStore) core.Tool {
+	return tools.New("remove_imported_account").
+		Description("Delete an imported account and all of its imported transactions, so the analyzers stop including it. Doesn't touch the user's Liminal wallet or the other bank.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"name": toolkit.MinLength(tools.StringProperty("Name of the imported account, as list_imported_accounts shows it"), 1),
+		}, "name")).
+		Handler(toolkit.TypedHandler(func(_ context.Context, toolParams *core.ToolParams, params struct {
+			Name string `json:"name"`
+		}) (*core.ToolResult, error) {
+			var removed importedAccount
+			err := imports.Update(toolParams.UserID, func(accounts *[]importedAccount) error {
+				i := slices.IndexFunc(*accounts, func(a importedAccount) bool { return strings.EqualFold(a.Name, strings.TrimSpace(params.Name)) })
+				if i < 0 {
+					return fmt.Errorf("no imported account is called %q", params.Name)
+				}
+				removed = (*accounts)[i]
+				*accounts = append((*accounts)[:i], (*accounts)[i+1:]...)
+				return nil
+			})
+			if err != nil {
+				return toolkit.Error("failed to remove account: %v", err), nil
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"removed":              removed.Name,
+					"transactions_removed": len(removed.Transactions),
+				},
+			}, nil
+		})).
+		Build()
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSVStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		dayFirst bool
+		want     []string // "date type amount currency description"
+		problems []string // fields of the problems
+	}{
+		{
+			name: "signed amounts",
+			csv: "Posting Date,Description,Amount,Balance\n" +
+				"01/15/2025,\"NETFLIX.COM  866-579\",-15.49,1000.00\n" +
+				"01/16/2025,ACME PAYROLL,\"$2,500.00\",3484.51\n",
+			want: []string{"2025-01-15 send 15.49 USD NETFLIX.COM 866-579", "2025-01-16 receive 2500.00 USD ACME PAYROLL"},
+		},
+		{
+			name: "debit and credit columns, day first",
+			csv: "Date,Memo,Details,Paid out,Paid in,Currency\n" +
+				"03/02/2025,card 1234,Tesco Stores,12.30,,gbp\n" +
+				"04/02/2025,,Salary,,1800.00,GBP\n",
+			dayFirst: true,
+			want:     []string{"2025-02-03 send 12.30 GBP Tesco Stores", "2025-02-04 receive 1800.00 GBP Salary"},
+		},
+		{
+			name: "debit and credit columns with zeros",
+			csv: "Date,Description,Debit,Credit\n" +
+				"2025-01-02,Coffee,4.50,0.00\n" +
+				"2025-01-03,Refund,0.00,4.50\n" +
+				"2025-01-04,Nothing,0.00,0.00\n" +
+				"2025-01-05,Both,1.00,2.00\n",
+			want:     []string{"2025-01-02 send 4.50 USD Coffee", "2025-01-03 receive 4.50 USD Refund"},
+			problems: []string{"line 4", "line 5"},
+		},
+		{
+			name: "direction column and bad lines",
+			csv: "Transaction Date,Name,Amount,Type\n" +
+				"2025-01-02,Starbucks,(4.50),\n" +
+				"2025-01-03,Refund,4.50,CR\n" +
+				"2025-01-04,Gym,30.00,debit\n" +
+				"yesterday,Lunch,9.00,debit\n" +
+				"2025-01-05,Lunch,,debit\n" +
+				",,,\n",
+			want: []string{
+				"2025-01-02 send 4.50 USD Starbucks",
+				"2025-01-03 receive 4.50 USD Refund",
+				"2025-01-04 send 30.00 USD Gym",
+			},
+			problems: []string{"line 5", "line 6"},
+		},
+		{
+			name:     "no amount column",
+			csv:      "Date,Description\n2025-01-02,Coffee\n",
+			problems: []string{"header"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txs, problems := parseStatement("csv", []byte(tt.csv), statementOptions{account: "Bank", currency: "USD", dayFirst: tt.dayFirst})
+			var got []string
+			for _, tx := range txs {
+				got = append(got, fmt.Sprintf("%s %s %.2f %s %s", tx.Date[:10], tx.Type, tx.Amount, tx.Currency, tx.Description))
+				if tx.Account != "Bank" || tx.Source != "import" || tx.Status != "completed" || !strings.HasPrefix(tx.ID, "imp_") {
+					t.Errorf("transaction not tagged as imported from Bank: %+v", tx)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("transactions =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			var fields []string
+			for _, p := range problems {
+				fields = append(fields, p.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.problems, ",") {
+				t.Errorf("problems = %+v, want fields %v", problems, tt.problems)
+			}
+		})
+	}
+}
+
+func TestParseOFXStatement(t *testing.T) {
+	ofx := "OFXHEADER:100\r\nDATA:OFXSGML\r\n\r\n<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>EUR\r\n" +
+		"<BANKTRANLIST>\r\n" +
+		"<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250115120000.000[-5:EST]<TRNAMT>-9.99<FITID>A1<NAME>Spotify &amp; Co\r\n</STMTTRN>\r\n" +
+		"<STMTTRN>\r\n<TRNTYPE>CREDIT\r\n<DTPOSTED>20250116\r\n<TRNAMT>100.00\r\n<FITID>A2\r\n<MEMO>Transfer in\r\n</STMTTRN>\r\n" +
+		"<STMTTRN><DTPOSTED>soon<TRNAMT>1</STMTTRN>\r\n" +
+		"</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>\r\n"
+	if format := detectStatementFormat("", []byte(ofx)); format != "ofx" {
+		t.Fatalf("detected %s, want ofx", format)
+	}
+	txs, problems := parseStatement("ofx", []byte(ofx), statementOptions{account: "Bank", currency: "USD"})
+	if len(txs) != 2 || len(problems) != 1 || problems[0].Field != "transaction 3" {
+		t.Fatalf("txs = %+v, problems = %+v", txs, problems)
+	}
+	if tx := txs[0]; tx.Type != "send" || tx.Amount != 9.99 || tx.Currency != "EUR" || tx.Description != "Spotify & Co" || tx.Date != "2025-01-15T12:00:00Z" {
+		t.Errorf("first = %+v", tx)
+	}
+	if tx := txs[1]; tx.Type != "receive" || tx.Amount != 100 || tx.Description != "Transfer in" {
+		t.Errorf("second = %+v", tx)
+	}
+}
+
+func TestParseStatementBalance(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   string // "amount currency as_of", or "" for none
+	}{
+		{
+			name:   "csv oldest first",
+			format: "csv",
+			data:   "Date,Description,Amount,Balance\n01/15/2025,A,-15.49,1000.00\n01/16/2025,B,10,1010.00\n01/16/2025,C,5,1015.00\n",
+			want:   "1015.00 USD 2025-01-16",
+		},
+		{
+			name:   "csv newest first",
+			format: "csv",
+			data:   "Date,Description,Amount,Running Balance,Currency\n2025-01-16,C,5,\"1,015.00\",GBP\n2025-01-16,B,10,1010.00,GBP\n2025-01-15,A,-15.49,1000.00,GBP\n",
+			want:   "1015.00 GBP 2025-01-16",
+		},
+		{
+			name:   "csv without balance",
+			format: "csv",
+			data:   "Date,Description,Amount\n2025-01-15,A,-15.49\n",
+		},
+		{
+			name:   "ofx credit card",
+			format: "ofx",
+			data:   "<OFX><CREDITCARDMSGSRSV1><CCSTMTRS><CURDEF>USD<BANKTRANLIST></BANKTRANLIST><LEDGERBAL><BALAMT>-512.30<DTASOF>20250131120000</LEDGERBAL></CCSTMTRS></CREDITCARDMSGSRSV1></OFX>",
+			want:   "-512.30 USD 2025-01-31",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if b := parseStatementBalance(tt.format, []byte(tt.data), statementOptions{account: "Bank", currency: "USD"}); b != nil {
+				got = fmt.Sprintf("%.2f %s %s", b.Amount, b.Currency, b.AsOf)
+			}
+			if got != tt.want {
+				t.Errorf("balance = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Re-importing an overlapping statement adds only the new lines, and
+// identical lines on the same day are kept apart.
+func TestMergeImportDeduplicates(t *testing.T) {
+	imports, err := newImportStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := "Date,Description,Amount\n2025-01-02,Coffee,-3.00\n2025-01-02,Coffee,-3.00\n"
+	second := first + "2025-01-03,Coffee,-3.00\n"
+	for i, statement := range []string{first, second} {
+		txs, _ := parseStatement("csv", []byte(statement), statementOptions{account: "Bank", currency: "USD"})
+		added, duplicates, err := mergeImport(imports, "u1", "bank", "csv", txs, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := [][2]int{{2, 0}, {1, 2}}[i]; added != want[0] || duplicates != want[1] {
+			t.Errorf("import %d: added %d, duplicates %d, want %v", i+1, added, duplicates, want)
+		}
+	}
+	accounts := imports.Get("u1")
+	if len(accounts) != 1 || len(accounts[0].Transactions) != 3 || accounts[0].Transactions[0].Date[:10] != "2025-01-03" {
+		t.Errorf("accounts = %+v, want one account with 3 transactions, newest first", accounts)
+	}
+}
+
+// An uploaded statement shows up in the analyzers next to the mock wallet.
+func TestImportUpload(t *testing.T) {
+	srv := newAPIServer(t, testAPIToken)
+	day := func(daysAgo int) string { return time.Now().AddDate(0, 0, -daysAgo).Format("2006-01-02") }
+	statement := "Date,Description,Amount\n" +
+		day(65) + ",Gym Membership,-40.00\n" +
+		day(35) + ",Gym Membership,-40.00\n" +
+		day(5) + ",Gym Membership,-40.00\n" +
+		day(3) + ",Salary,1200.00\n"
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	part, _ := mw.CreateFormFile("file", "statement.csv")
+	part.Write([]byte(statement))
+	mw.Close()
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/imports?account=Other+Bank", &form)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+testAPIToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || body["added"] != 4.0 || body["format"] != "csv" {
+		t.Fatalf("upload: %d %v", resp.StatusCode, body)
+	}
+
+	status, body := apiGet(t, srv.URL+"/api/spending?days=30", testAPIToken)
+	byAccount, _ := body["analysis"].(map[string]interface{})["by_account"].(map[string]interface{})
+	other, _ := byAccount["Other Bank"].(map[string]interface{})
+	if status != http.StatusOK || other["spent"] != "40.00" || other["received"] != "1200.00" || byAccount["liminal"] == nil {
+		t.Errorf("spending: %d by_account = %v", status, byAccount)
+	}
+
+	status, body = apiGet(t, srv.URL+"/api/subscriptions?months=6", testAPIToken)
+	found := false
+	for _, sub := range body["subscriptions"].([]interface{}) {
+		s := sub.(map[string]interface{})
+		found = found || (s["merchant"] == "Gym Membership" && s["account"] == "Other Bank")
+	}
+	if status != http.StatusOK || !found {
+		t.Errorf("subscriptions: %d %v", status, body)
+	}
+
+	// The account name is required, and "liminal" is taken.
+	for _, query := range []string{"", "?account=Liminal"} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/imports"+query, strings.NewReader(statement))
+		req.Header.Set("Authorization", "Bearer "+testAPIToken)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("POST /api/imports%s: %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestDetectStatementFormat(t *testing.T) {
+	for name, want := range map[string]string{"a.OFX": "ofx", "a.qfx": "ofx", "a.csv": "csv", filepath.Join("x", "a.txt"): "csv"} {
+		if got := detectStatementFormat(name, []byte("Date,Amount\n")); got != want {
+			t.Errorf("%s: %s, want %s", name, got, want)
+		}
+	}
+}
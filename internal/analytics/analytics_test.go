@@ -0,0 +1,227 @@
+package analytics_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics/spending"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics/subscriptions"
+)
+
+// Golden files and fuzzing for the analyzers in the spending and
+// subscriptions packages. Each testdata/<name>.json is a transaction history plus the
+// clock and tool parameters to analyze it with. Its <name>.golden holds the
+// combined output of the spending and subscription analyzers. After an
+// intended change in output, rewrite the golden files and review the diff:
+//
+//	go test ./internal/analytics -run TestAnalyzerGolden -update
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/*.golden from the current output")
+
+// analyzerFixture mirrors the tool parameters, with the same defaults.
+type analyzerFixture struct {
+	Now          time.Time                `json:"now"`
+	Days         int                      `json:"days"`
+	Months       int                      `json:"months"`
+	MinAmount    float64                  `json:"min_amount"`
+	MaxAmount    float64                  `json:"max_amount"`
+	Currency     string                   `json:"currency"`
+	Transactions []map[string]interface{} `json:"transactions"`
+}
+
+func loadAnalyzerFixture(t *testing.T, path string) analyzerFixture {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	var fx analyzerFixture
+	if err := dec.Decode(&fx); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if fx.Days == 0 {
+		fx.Days = 30
+	}
+	if fx.Months == 0 {
+		fx.Months = 6
+	}
+	if fx.MinAmount == 0 {
+		fx.MinAmount = 1.00
+	}
+	if fx.MaxAmount == 0 {
+		fx.MaxAmount = 999.99
+	}
+	if fx.Currency == "" {
+		fx.Currency = "USD"
+	}
+	return fx
+}
+
+// runAnalyzers runs the fixture through both analyzers the way their tools do.
+func runAnalyzers(fx analyzerFixture) map[string]interface{} {
+	subs := subscriptions.Detect(fx.Transactions, fx.Now.AddDate(0, -fx.Months, 0), fx.MinAmount, fx.MaxAmount, fx.Currency)
+	return map[string]interface{}{
+		"spending":           spending.Analyze(fx.Transactions, fx.Days, fx.Currency, fx.Now),
+		"subscriptions":      subs,
+		"total_monthly_cost": subscriptions.TotalMonthlyCost(subs),
+		"warnings":           subscriptions.Warnings(subs, fx.Currency, fx.Now),
+	}
+}
+
+func TestAnalyzerGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			fx := loadAnalyzerFixture(t, path)
+			got, err := json.MarshalIndent(runAnalyzers(fx), "", "  ")
+			if err != nil {
+				t.Fatalf("output does not encode: %v", err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(path, ".json") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s (run with -update to accept):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// The output must not depend on map iteration order.
+func TestAnalyzersDeterministic(t *testing.T) {
+	fx := loadAnalyzerFixture(t, filepath.Join("testdata", "drifting_dates.json"))
+	first, _ := json.Marshal(runAnalyzers(fx))
+	for i := 0; i < 20; i++ {
+		again, _ := json.Marshal(runAnalyzers(fx))
+		if !bytes.Equal(first, again) {
+			t.Fatalf("run %d differs:\n%s\n%s", i, first, again)
+		}
+	}
+}
+
+// checkAnalyzerOutput holds for any input: the output encodes as JSON (no
+// NaN or Inf sneaks through) and every reported subscription is in range,
+// recurring and in the requested currency.
+func checkAnalyzerOutput(t *testing.T, fx analyzerFixture) {
+	t.Helper()
+	out := runAnalyzers(fx)
+	if _, err := json.Marshal(out); err != nil {
+		t.Fatalf("output does not encode: %v", err)
+	}
+	for _, sub := range out["subscriptions"].([]map[string]interface{}) {
+		amount := sub["amount"].(float64)
+		if amount < fx.MinAmount-0.005 || amount > fx.MaxAmount+0.005 {
+			t.Errorf("amount %v outside [%v, %v]", amount, fx.MinAmount, fx.MaxAmount)
+		}
+		if n := sub["occurences"].(int); n < 2 {
+			t.Errorf("subscription with %d occurrences", n)
+		}
+		if c := sub["currency"]; c != fx.Currency {
+			t.Errorf("currency %v, want %s", c, fx.Currency)
+		}
+	}
+	if len(out["warnings"].([]string)) == 0 {
+		t.Error("no warnings, not even the summary line")
+	}
+}
+
+func fuzzFixture(transactions []map[string]interface{}) analyzerFixture {
+	return analyzerFixture{
+		Now:          time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC),
+		Days:         30,
+		Months:       6,
+		MinAmount:    1,
+		MaxAmount:    999.99,
+		Currency:     "USD",
+		Transactions: transactions,
+	}
+}
+
+// FuzzAnalyzeTransactionHistory feeds arbitrary JSON transaction lists, the
+// shape analytics.FetchTransactions decodes from the executor.
+func FuzzAnalyzeTransactionHistory(f *testing.F) {
+	paths, _ := filepath.Glob(filepath.Join("testdata", "*.json"))
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		var fx struct {
+			Transactions []json.RawMessage `json:"transactions"`
+		}
+		if err := json.Unmarshal(raw, &fx); err != nil {
+			f.Fatal(err)
+		}
+		// The fuzzer stalls on seeds of several kilobytes, so each fixture
+		// contributes its first few transactions.
+		if len(fx.Transactions) > 3 {
+			fx.Transactions = fx.Transactions[:3]
+		}
+		seed, _ := json.Marshal(fx.Transactions)
+		f.Add(seed)
+	}
+	f.Add([]byte(`[{}]`))
+	f.Add([]byte(`[{"type":"send","amount":"1e308","date":"2024-03-01"},{"type":"send","amount":"1e308","date":"2024-04-01"}]`))
+	f.Add([]byte(`[{"type":"send","amount":5,"date":"0001-01-01T00:00:00Z"},{"type":"send","amount":5,"date":"9999-12-31T23:59:59Z"}]`))
+	f.Add([]byte(`[{"type":"send","amount":-5,"currency":"","description":"","date":"2024-03-01T00:00:00+14:00"}]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var transactions []map[string]interface{}
+		if err := json.Unmarshal(data, &transactions); err != nil {
+			return
+		}
+		checkAnalyzerOutput(t, fuzzFixture(transactions))
+	})
+}
+
+// FuzzTransactionFields builds transactions from arbitrary field strings,
+// including amounts that parse to NaN or Inf, which JSON input cannot carry.
+func FuzzTransactionFields(f *testing.F) {
+	f.Add("send", "9.99", "2024-02-29", "USD", "Netflix")
+	f.Add("send", "NaN", "2024-03-01T10:00:00Z", "usd", "")
+	f.Add("send", "-Inf", "2024-13-01", "EUR", "x")
+	f.Add("receive", "1e400", "", "", "@alice")
+
+	f.Fuzz(func(t *testing.T, txType, amount, date, currency, description string) {
+		parsed, _ := strconv.ParseFloat(amount, 64)
+		var transactions []map[string]interface{}
+		for i := 0; i < 3; i++ {
+			transactions = append(transactions,
+				map[string]interface{}{"type": txType, "amount": amount, "date": date, "currency": currency, "description": description},
+				map[string]interface{}{"type": txType, "amount": parsed, "created_at": date, "recipient": description},
+			)
+		}
+		fx := fuzzFixture(transactions)
+		checkAnalyzerOutput(t, fx)
+		for _, c := range []string{"EUR", strings.ToUpper(currency)} {
+			fx.Currency = c
+			checkAnalyzerOutput(t, fx)
+		}
+	})
+}
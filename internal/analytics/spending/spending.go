@@ -0,0 +1,165 @@
+// Package spending implements the analyze_spending tool: totals, daily
+// average and velocity of the user's spending over a number of days.
+package spending
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// NewTool builds the analyze_spending tool, which reads transactions through
+// exec on behalf of the calling user.
+func NewTool(exec core.ToolExecutor) core.Tool {
+	return tools.New("analyze_spending").
+		Description("Analyze the user's spending patterns over a specified time period. Returns insights about spending velocity, categories, and trends.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"days":     toolkit.Range(tools.IntegerProperty("Number of days to analyze, 1-365 (default: 30)"), 1, 365),
+			"currency": tools.StringProperty("Currency code (default: USD)"),
+		})).
+		Handler(toolkit.TypedHandler(func(ctx context.Context, toolParams *core.ToolParams, params struct {
+			Days     int    `json:"days"`
+			Currency string `json:"currency"`
+		}) (*core.ToolResult, error) {
+			if params.Days == 0 {
+				params.Days = 30
+			}
+			if params.Currency == "" {
+				params.Currency = "USD"
+			}
+			params.Currency = strings.ToUpper(params.Currency)
+			now := time.Now()
+
+			transactions, err := analytics.FetchTransactions(ctx, exec, toolParams, map[string]interface{}{
+				"limit": 100,
+			})
+			if err != nil {
+				return toolkit.Error("failed to fetch transactions: %v", err), nil
+			}
+
+			analysis := Analyze(transactions, params.Days, params.Currency, now)
+
+			result := map[string]interface{}{
+				"period_days":        params.Days,
+				"total_transactions": len(transactions),
+				"analysis":           analysis,
+				"generated_at":       now.Format(time.RFC3339),
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data:    result,
+			}, nil
+		})).
+		Build()
+}
+
+// Analyze summarises the transactions in currency from the days before now.
+// Undated transactions are counted, since the fetch already bounded them;
+// transactions in other currencies are left out rather than summed with a
+// different unit. When transactions are tagged with accounts, the totals are
+// also broken down by account.
+func Analyze(transactions []map[string]interface{}, days int, currency string, now time.Time) map[string]interface{} {
+	if days < 1 {
+		days = 1
+	}
+	cutoff := now.AddDate(0, 0, -days)
+
+	var totalSpent, totalReceived float64
+	var spendCount, receiveCount int
+	type accountTotals struct{ spent, received float64 }
+	byAccount := make(map[string]*accountTotals)
+	account := func(tx map[string]interface{}) *accountTotals {
+		name := analytics.TxAccount(tx)
+		if name == "" {
+			return &accountTotals{}
+		}
+		if byAccount[name] == nil {
+			byAccount[name] = &accountTotals{}
+		}
+		return byAccount[name]
+	}
+
+	for _, tx := range transactions {
+		if analytics.TxCurrency(tx) != currency {
+			continue
+		}
+		if t, ok := analytics.TxTime(tx); ok && t.Before(cutoff) {
+			continue
+		}
+		txType, _ := tx["type"].(string)
+		amount := analytics.TxAmount(tx)
+
+		switch txType {
+		case "send":
+			totalSpent += amount
+			spendCount++
+			account(tx).spent += amount
+		case "receive":
+			totalReceived += amount
+			receiveCount++
+			account(tx).received += amount
+		}
+	}
+
+	analysis := map[string]interface{}{
+		"currency": currency,
+	}
+	if others := analytics.OtherCurrencies(transactions, currency); len(others) > 0 {
+		analysis["other_currencies"] = others
+	}
+	if spendCount+receiveCount == 0 {
+		analysis["summary"] = "No transactions found in the specified period"
+		return analysis
+	}
+
+	avgDailySpend := totalSpent / float64(days)
+
+	analysis["total_spent"] = fmt.Sprintf("%.2f", totalSpent)
+	analysis["total_received"] = fmt.Sprintf("%.2f", totalReceived)
+	analysis["spend_count"] = spendCount
+	analysis["receive_count"] = receiveCount
+	analysis["avg_daily_spend"] = fmt.Sprintf("%.2f", avgDailySpend)
+	analysis["velocity"] = Velocity(spendCount, days)
+	if len(byAccount) > 0 {
+		accounts := make(map[string]interface{}, len(byAccount))
+		for name, t := range byAccount {
+			accounts[name] = map[string]interface{}{
+				"spent":    fmt.Sprintf("%.2f", t.spent),
+				"received": fmt.Sprintf("%.2f", t.received),
+			}
+		}
+		analysis["by_account"] = accounts
+	}
+	analysis["insights"] = []string{
+		fmt.Sprintf("You made %d spending transactions over %d days", spendCount, days),
+		fmt.Sprintf("Average daily spend: %s", analytics.FormatMoney(avgDailySpend, currency)),
+		"Consider setting up savings goals to build financial cushion",
+	}
+	return analysis
+}
+
+// Velocity rates how often the user spends: low under two transactions a
+// week, moderate under seven, high otherwise.
+func Velocity(transactionCount, days int) string {
+	if days < 1 {
+		days = 1
+	}
+	txPerWeek := float64(transactionCount) / float64(days) * 7
+
+	switch {
+	case txPerWeek < 2:
+		return "low"
+	case txPerWeek < 7:
+		return "moderate"
+	default:
+		return "high"
+	}
+}
@@ -0,0 +1,377 @@
+// Package subscriptions implements the analyze_subscriptions tool: it finds
+// payments that recur on a regular cadence and reports their cost, expected
+// next charge and overlaps.
+package subscriptions
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// NewTool builds the analyze_subscriptions tool, which reads transactions
+// through exec on behalf of the calling user.
+func NewTool(exec core.ToolExecutor) core.Tool {
+	return tools.New("analyze_subscriptions").
+		Description("Scan Transaction History to identify recurring subscriptions and recurring payments. Returns subscription patters, total month costs, and cancellation insights.").
+		Schema(toolkit.FieldOrder(tools.ObjectSchema(map[string]interface{}{
+			"timeframe_months": toolkit.Range(tools.IntegerProperty("Number of months to analyze for recurring patterns, 1-24 (default:6)"), 1, 24),
+			"min_amount":       toolkit.Minimum(tools.NumberProperty("Minimum amount to be considered as subscription (default: 1.00)"), 0),
+			"max_amount":       toolkit.Positive(tools.NumberProperty("Maximum amount to be considered as a subscription, not below min_amount (default: 999.99)")),
+			"currency":         tools.StringProperty("Currency code (default: USD)"),
+		}), "min_amount", "max_amount")).
+		Handler(toolkit.TypedHandler(func(ctx context.Context, toolParams *core.ToolParams, params struct {
+			TimeframeMonths int     `json:"timeframe_months"`
+			MinAmount       float64 `json:"min_amount"`
+			MaxAmount       float64 `json:"max_amount"`
+			Currency        string  `json:"currency"`
+		}) (*core.ToolResult, error) {
+			if params.TimeframeMonths == 0 {
+				params.TimeframeMonths = 6
+			}
+			if params.MinAmount == 0 {
+				params.MinAmount = 1.00
+			}
+			if params.MaxAmount == 0 {
+				params.MaxAmount = 999.99
+			}
+			if params.Currency == "" {
+				params.Currency = "USD"
+			}
+			params.Currency = strings.ToUpper(params.Currency)
+
+			now := time.Now()
+			cutoffDate := now.AddDate(0, -params.TimeframeMonths, 0)
+
+			transactions, err := analytics.FetchTransactions(ctx, exec, toolParams, map[string]interface{}{
+				"limit":      500,
+				"start_date": cutoffDate.Format("2006-01-02"),
+			})
+			if err != nil {
+				return toolkit.Error("failed to fetch transactions: %v", err), nil
+			}
+			subscriptions := Detect(transactions, cutoffDate, params.MinAmount, params.MaxAmount, params.Currency)
+			result := map[string]interface{}{
+				"analysis_period":            fmt.Sprintf("%d months", params.TimeframeMonths),
+				"total_transactions_scanned": len(transactions),
+				"subscriptions_found":        len(subscriptions),
+				"subscriptions":              subscriptions,
+				"currency":                   params.Currency,
+				"total_monthly_cost":         TotalMonthlyCost(subscriptions),
+				"warnings":                   Warnings(subscriptions, params.Currency, now),
+				"generated_at":               now.Format(time.RFC3339),
+			}
+			if others := analytics.OtherCurrencies(transactions, params.Currency); len(others) > 0 {
+				result["other_currencies"] = others
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data:    result,
+			}, nil
+		})).
+		Build()
+}
+
+// Detect groups outgoing payments in currency by merchant and amount and
+// reports the groups that recur on a regular cadence, sorted by merchant so
+// the output is stable. Payments tagged with different accounts are grouped
+// apart, and each subscription names its account when it has one.
+func Detect(transactions []map[string]interface{}, cutoffDate time.Time, minAmount, maxAmount float64, currency string) []map[string]interface{} {
+	subscriptions := make([]map[string]interface{}, 0)
+	type paymentKey struct {
+		account  string
+		merchant string
+		cents    int64
+	}
+	paymentGroups := make(map[paymentKey][]time.Time)
+	for _, tx := range transactions {
+		txType, _ := tx["type"].(string)
+		if txType != "send" || analytics.TxCurrency(tx) != currency {
+			continue
+		}
+		amount := analytics.TxAmount(tx)
+		if amount < minAmount || amount > maxAmount {
+			continue
+		}
+		txDate, ok := analytics.TxTime(tx)
+		if !ok || txDate.Before(cutoffDate) {
+			continue
+		}
+		key := paymentKey{account: analytics.TxAccount(tx), merchant: analytics.TxMerchant(tx), cents: int64(math.Round(amount * 100))}
+		paymentGroups[key] = append(paymentGroups[key], txDate)
+	}
+	for key, dates := range paymentGroups {
+		if len(dates) < 2 {
+			continue
+		}
+		sort.Slice(dates, func(i, j int) bool {
+			return dates[i].Before(dates[j])
+		})
+		intervals := make([]int, 0, len(dates)-1)
+		for i := 1; i < len(dates); i++ {
+			intervals = append(intervals, daysBetween(dates[i-1], dates[i]))
+		}
+		if !IsRegularPattern(intervals) {
+			continue
+		}
+		amount := float64(key.cents) / 100
+		frequency := DetectFrequency(intervals)
+		last := dates[len(dates)-1]
+		sub := map[string]interface{}{
+			"merchant":       key.merchant,
+			"amount":         amount,
+			"currency":       currency,
+			"frequency":      frequency,
+			"occurences":     len(dates),
+			"last_occurence": last.Format("2006-01-02"),
+			"estimated_next": EstimateNextPayment(last, frequency),
+			"total_paid":     analytics.RoundMoney(amount * float64(len(dates))),
+			"confidence":     Confidence(len(dates), intervals),
+		}
+		if key.account != "" {
+			sub["account"] = key.account
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		mi, _ := subscriptions[i]["merchant"].(string)
+		mj, _ := subscriptions[j]["merchant"].(string)
+		if mi != mj {
+			return mi < mj
+		}
+		if ai, aj := subscriptions[i]["amount"].(float64), subscriptions[j]["amount"].(float64); ai != aj {
+			return ai < aj
+		}
+		ai, _ := subscriptions[i]["account"].(string)
+		aj, _ := subscriptions[j]["account"].(string)
+		return ai < aj
+	})
+	return subscriptions
+}
+
+// daysBetween counts calendar days from a to b, so a payment taken later in
+// the day (or across a DST change) still reads as a whole number of days.
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	start := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	end := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// IsRegularPattern reports whether at least 70% of the intervals are within
+// 20% of their average. Same-day repeats are never a pattern.
+func IsRegularPattern(intervals []int) bool {
+	if len(intervals) == 0 {
+		return false
+	}
+	sum := 0
+	for _, interval := range intervals {
+		sum += interval
+	}
+	avg := float64(sum) / float64(len(intervals))
+	if avg < 1 {
+		return false
+	}
+	withinTolerance := 0
+	tolerance := avg * 0.2
+	for _, interval := range intervals {
+		if math.Abs(float64(interval)-avg) <= tolerance {
+			withinTolerance++
+		}
+	}
+	return float64(withinTolerance)/float64(len(intervals)) >= 0.7
+}
+
+// DetectFrequency names the cadence of the average interval, "irregular"
+// if it matches none.
+func DetectFrequency(intervals []int) string {
+	if len(intervals) == 0 {
+		return "unknown"
+	}
+	sum := 0
+	for _, interval := range intervals {
+		sum += interval
+	}
+	avgDays := float64(sum) / float64(len(intervals))
+	switch {
+	case avgDays >= 5 && avgDays < 10:
+		return "weekly"
+	case avgDays >= 10 && avgDays <= 18:
+		return "biweekly"
+	case avgDays >= 25 && avgDays <= 35:
+		return "monthly"
+	case avgDays >= 80 && avgDays <= 100:
+		return "quarterly"
+	case avgDays >= 170 && avgDays <= 190:
+		return "semi-annual"
+	case avgDays >= 350 && avgDays <= 380:
+		return "annual"
+	default:
+		return "irregular"
+	}
+}
+
+// EstimateNextPayment projects the next charge. Month-based cadences clamp
+// to the end of the month, so Jan 31 is followed by Feb 28 (or 29) and an
+// annual Feb 29 charge by Feb 28.
+func EstimateNextPayment(lastPayment time.Time, frequency string) string {
+	switch frequency {
+	case "monthly":
+		return analytics.AddMonthsClamped(lastPayment, 1).Format("2006-01-02")
+	case "quarterly":
+		return analytics.AddMonthsClamped(lastPayment, 3).Format("2006-01-02")
+	case "semi-annual":
+		return analytics.AddMonthsClamped(lastPayment, 6).Format("2006-01-02")
+	case "annual":
+		return analytics.AddMonthsClamped(lastPayment, 12).Format("2006-01-02")
+	case "biweekly":
+		return lastPayment.AddDate(0, 0, 14).Format("2006-01-02")
+	case "weekly":
+		return lastPayment.AddDate(0, 0, 7).Format("2006-01-02")
+	default:
+		return "unknown"
+	}
+}
+
+// Confidence grades a detected subscription by how regular and how
+// frequent its payments are.
+func Confidence(occurrences int, intervals []int) string {
+	switch {
+	case !IsRegularPattern(intervals):
+		return "low"
+	case occurrences >= 4:
+		return "high"
+	case occurrences >= 3:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// TotalMonthlyCost converts every subscription to a monthly amount and sums
+// them.
+func TotalMonthlyCost(subscriptions []map[string]interface{}) float64 {
+	var totalMonthly float64
+	for _, sub := range subscriptions {
+		amount := analytics.ToFloat(sub["amount"])
+		frequency, _ := sub["frequency"].(string)
+		switch frequency {
+		case "monthly":
+			totalMonthly += amount
+		case "quarterly":
+			totalMonthly += amount / 3
+		case "semi-annual":
+			totalMonthly += amount / 6
+		case "annual":
+			totalMonthly += amount / 12
+		case "biweekly":
+			totalMonthly += amount * 26 / 12
+		case "weekly":
+			totalMonthly += amount * 52 / 12
+		}
+	}
+	return analytics.RoundMoney(totalMonthly)
+}
+
+// categories flags overlapping services. A merchant can match
+// more than one category (Spotify is both streaming and music).
+var categories = []struct {
+	Name     string
+	Keywords []string
+}{
+	{"streaming", []string{"netflix", "hulu", "disney", "prime", "spotify", "hbo", "apple tv", "youtube premium"}},
+	{"music", []string{"spotify", "apple music", "youtube music", "tidal", "pandora"}},
+	{"cloud", []string{"dropbox", "google one", "icloud", "onedrive"}},
+	{"fitness", []string{"peloton", "classpass", "apple fitness", "strava"}},
+}
+
+// inactiveAfterDays is how far past its expected date a charge can be before
+// the subscription is reported as possibly inactive.
+const inactiveAfterDays = 30
+
+// Warnings lists the overall monthly cost, overlapping services, charges
+// that look inactive as of now, and a savings tip.
+func Warnings(subscriptions []map[string]interface{}, currency string, now time.Time) []string {
+	warnings := make([]string, 0)
+	if len(subscriptions) == 0 {
+		warnings = append(warnings, "No subscriptions were detected at all in your transaction history.")
+		return warnings
+	}
+	totalMonthly := TotalMonthlyCost(subscriptions)
+	warnings = append(warnings, fmt.Sprintf("You are spending approximately %s per month on subscriptions.", analytics.FormatMoney(totalMonthly, currency)))
+	for _, category := range categories {
+		var merchants []string
+		for _, sub := range subscriptions {
+			merchant, _ := sub["merchant"].(string)
+			merchantLower := strings.ToLower(merchant)
+			for _, keyword := range category.Keywords {
+				if strings.Contains(merchantLower, keyword) {
+					if !slices.Contains(merchants, merchant) {
+						merchants = append(merchants, merchant)
+					}
+					break
+				}
+			}
+		}
+		if len(merchants) > 1 {
+			warnings = append(warnings, fmt.Sprintf("You have multiple %s subscriptions (%s). Consider consolidating.", category.Name, strings.Join(merchants, ", ")))
+		}
+	}
+	// The same merchant billed to several accounts is often one service
+	// paid twice.
+	accountsByMerchant := make(map[string][]string)
+	var merchantOrder []string
+	for _, sub := range subscriptions {
+		merchant, _ := sub["merchant"].(string)
+		account, _ := sub["account"].(string)
+		if account == "" {
+			continue
+		}
+		if _, ok := accountsByMerchant[merchant]; !ok {
+			merchantOrder = append(merchantOrder, merchant)
+		}
+		if !slices.Contains(accountsByMerchant[merchant], account) {
+			accountsByMerchant[merchant] = append(accountsByMerchant[merchant], account)
+		}
+	}
+	for _, merchant := range merchantOrder {
+		if accounts := accountsByMerchant[merchant]; len(accounts) > 1 {
+			warnings = append(warnings, fmt.Sprintf("You pay '%s' from more than one account (%s). Check you're not paying for it twice.", merchant, strings.Join(accounts, ", ")))
+		}
+	}
+	for _, sub := range subscriptions {
+		lastDatestr, _ := sub["last_occurence"].(string)
+		lastDate, err := time.Parse("2006-01-02", lastDatestr)
+		if err != nil {
+			continue
+		}
+		// Judge inactivity against the subscription's own cadence, so an
+		// annual plan paid four months ago is not flagged.
+		due := lastDate.AddDate(0, 0, 90)
+		if nextStr, _ := sub["estimated_next"].(string); nextStr != "" {
+			if next, err := time.Parse("2006-01-02", nextStr); err == nil {
+				due = next.AddDate(0, 0, inactiveAfterDays)
+			}
+		}
+		if now.After(due) {
+			merchant, _ := sub["merchant"].(string)
+			warnings = append(warnings, fmt.Sprintf("Subscription to '%s' seems inactive (last paid %s). Consider cancelling if you no longer use.", merchant, lastDatestr))
+		}
+	}
+	if totalMonthly > 50 {
+		savings := analytics.RoundMoney(totalMonthly * 0.1)
+		warnings = append(warnings, fmt.Sprintf("Tip: Cancelling just 10%% of your subscriptions can possibly save you %s monthly!", analytics.FormatMoney(savings, currency)))
+	}
+	return warnings
+}
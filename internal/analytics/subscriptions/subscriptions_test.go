@@ -0,0 +1,220 @@
+package subscriptions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectFrequency(t *testing.T) {
+	tests := []struct {
+		intervals []int
+		want      string
+	}{
+		{nil, "unknown"},
+		{[]int{0, 0}, "irregular"},
+		{[]int{1, 1, 1}, "irregular"},
+		{[]int{7, 7, 7}, "weekly"},
+		{[]int{6, 8}, "weekly"},
+		{[]int{14, 14}, "biweekly"},
+		{[]int{13, 15, 14}, "biweekly"},
+		{[]int{30, 31, 30}, "monthly"},
+		{[]int{31, 29, 31}, "monthly"}, // Jan 31 → Feb 29 → Mar 31 in a leap year
+		{[]int{28, 31, 30, 31}, "monthly"},
+		{[]int{21, 21}, "irregular"},
+		{[]int{45, 45}, "irregular"},
+		{[]int{91, 92, 90}, "quarterly"},
+		{[]int{181, 184}, "semi-annual"},
+		{[]int{365}, "annual"},
+		{[]int{366}, "annual"},
+		{[]int{730}, "irregular"},
+	}
+	for _, tt := range tests {
+		if got := DetectFrequency(tt.intervals); got != tt.want {
+			t.Errorf("DetectFrequency(%v) = %q, want %q", tt.intervals, got, tt.want)
+		}
+	}
+}
+
+func TestIsRegularPattern(t *testing.T) {
+	tests := []struct {
+		intervals []int
+		want      bool
+	}{
+		{nil, false},
+		{[]int{}, false},
+		{[]int{0}, false},
+		{[]int{0, 0, 0}, false},
+		{[]int{30}, true},
+		{[]int{30, 31, 28, 31}, true},
+		{[]int{7, 7, 14, 14}, false},
+		{[]int{30, 30, 30, 30, 30, 30, 30, 90}, true}, // one missed month out of eight
+		{[]int{5, 60, 10, 40}, false},
+	}
+	for _, tt := range tests {
+		if got := IsRegularPattern(tt.intervals); got != tt.want {
+			t.Errorf("IsRegularPattern(%v) = %v, want %v", tt.intervals, got, tt.want)
+		}
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	tests := []struct {
+		occurrences int
+		intervals   []int
+		want        string
+	}{
+		{2, []int{30}, "low"},
+		{3, []int{30, 31}, "medium"},
+		{4, []int{30, 31, 30}, "high"},
+		{12, []int{30, 31, 30, 31, 30, 31, 30, 31, 30, 31, 30}, "high"},
+		{4, []int{5, 60, 10}, "low"},
+		{3, []int{0, 0}, "low"},
+	}
+	for _, tt := range tests {
+		if got := Confidence(tt.occurrences, tt.intervals); got != tt.want {
+			t.Errorf("Confidence(%d, %v) = %q, want %q", tt.occurrences, tt.intervals, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateNextPayment(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	tests := []struct {
+		last      string
+		frequency string
+		want      string
+	}{
+		{"2024-01-31", "monthly", "2024-02-29"},
+		{"2023-01-31", "monthly", "2023-02-28"},
+		{"2024-02-29", "annual", "2025-02-28"},
+		{"2024-11-30", "quarterly", "2025-02-28"},
+		{"2024-08-31", "semi-annual", "2025-02-28"},
+		{"2024-02-22", "weekly", "2024-02-29"},
+		{"2024-02-20", "biweekly", "2024-03-05"},
+		{"2024-02-20", "irregular", "unknown"},
+	}
+	for _, tt := range tests {
+		if got := EstimateNextPayment(day(tt.last), tt.frequency); got != tt.want {
+			t.Errorf("EstimateNextPayment(%s, %s) = %s, want %s", tt.last, tt.frequency, got, tt.want)
+		}
+	}
+}
+
+func TestTotalMonthlyCost(t *testing.T) {
+	sub := func(amount interface{}, frequency string) map[string]interface{} {
+		return map[string]interface{}{"amount": amount, "frequency": frequency}
+	}
+	tests := []struct {
+		name string
+		subs []map[string]interface{}
+		want float64
+	}{
+		{"none", nil, 0},
+		{"monthly", []map[string]interface{}{sub(15.49, "monthly"), sub(10.99, "monthly")}, 26.48},
+		{"annual", []map[string]interface{}{sub(120.0, "annual")}, 10},
+		{"quarterly", []map[string]interface{}{sub(60.0, "quarterly")}, 20},
+		{"semi-annual", []map[string]interface{}{sub(60.0, "semi-annual")}, 10},
+		{"weekly", []map[string]interface{}{sub(12.0, "weekly")}, 52},
+		{"biweekly", []map[string]interface{}{sub(12.0, "biweekly")}, 26},
+		{"irregular ignored", []map[string]interface{}{sub(99.0, "irregular"), sub(5.0, "monthly")}, 5},
+		{"string amount", []map[string]interface{}{sub("9.99", "monthly")}, 9.99},
+		{"malformed", []map[string]interface{}{sub(nil, "monthly"), {"frequency": 3}, {}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TotalMonthlyCost(tt.subs); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	sub := func(merchant string, amount float64, frequency, last string) map[string]interface{} {
+		return map[string]interface{}{
+			"merchant":       merchant,
+			"amount":         amount,
+			"frequency":      frequency,
+			"last_occurence": last,
+			"estimated_next": EstimateNextPayment(mustDate(last), frequency),
+		}
+	}
+	tests := []struct {
+		name     string
+		subs     []map[string]interface{}
+		currency string
+		want     []string
+	}{
+		{
+			name:     "none",
+			currency: "USD",
+			want:     []string{"No subscriptions were detected at all in your transaction history."},
+		},
+		{
+			name:     "one current",
+			subs:     []map[string]interface{}{sub("Netflix", 15.49, "monthly", "2024-03-01")},
+			currency: "USD",
+			want:     []string{"You are spending approximately $15.49 per month on subscriptions."},
+		},
+		{
+			name: "overlapping streaming and music",
+			subs: []map[string]interface{}{
+				sub("Netflix", 15.49, "monthly", "2024-03-01"),
+				sub("Spotify", 10.99, "monthly", "2024-03-03"),
+				sub("Tidal", 10.99, "monthly", "2024-03-05"),
+			},
+			currency: "USD",
+			want: []string{
+				"You are spending approximately $37.47 per month on subscriptions.",
+				"You have multiple streaming subscriptions (Netflix, Spotify). Consider consolidating.",
+				"You have multiple music subscriptions (Spotify, Tidal). Consider consolidating.",
+			},
+		},
+		{
+			name: "lapsed monthly but not a recent annual",
+			subs: []map[string]interface{}{
+				sub("Hulu", 7.99, "monthly", "2023-10-20"),
+				sub("iCloud", 120, "annual", "2023-11-01"),
+			},
+			currency: "EUR",
+			want: []string{
+				"You are spending approximately 17.99 EUR per month on subscriptions.",
+				"Subscription to 'Hulu' seems inactive (last paid 2023-10-20). Consider cancelling if you no longer use.",
+			},
+		},
+		{
+			name: "savings tip",
+			subs: []map[string]interface{}{
+				sub("Peloton", 44, "monthly", "2024-03-01"),
+				sub("Strava", 12, "monthly", "2024-03-02"),
+			},
+			currency: "USD",
+			want: []string{
+				"You are spending approximately $56.00 per month on subscriptions.",
+				"You have multiple fitness subscriptions (Peloton, Strava). Consider consolidating.",
+				"Tip: Cancelling just 10% of your subscriptions can possibly save you $5.60 monthly!",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Warnings(tt.subs, tt.currency, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustDate(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
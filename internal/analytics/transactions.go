@@ -0,0 +1,240 @@
+// Package analytics holds what the analyzers share: reading transactions
+// through a core.ToolExecutor, parsing their loosely typed fields, spending
+// categories, and money and calendar helpers. The analyzers themselves live
+// in the spending and subscriptions subpackages.
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// ExecuteRead runs a read-only Liminal tool on behalf of the calling user and
+// decodes the response payload into a generic map.
+func ExecuteRead(ctx context.Context, exec core.ToolExecutor, toolParams *core.ToolParams, tool string, input map[string]interface{}) (map[string]interface{}, error) {
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	inputJSON, _ := json.Marshal(input)
+
+	resp, err := exec.Execute(ctx, &core.ExecuteRequest{
+		UserID:    toolParams.UserID,
+		Tool:      tool,
+		Input:     inputJSON,
+		RequestID: toolParams.RequestID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", tool, err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("%s failed: %s", tool, resp.Error)
+	}
+
+	var data map[string]interface{}
+	if len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return nil, fmt.Errorf("%s returned malformed data: %w", tool, err)
+		}
+	}
+	return data, nil
+}
+
+// FetchTransactions pulls transaction history through the executor and
+// flattens it into the map shape the analyzers work on.
+func FetchTransactions(ctx context.Context, exec core.ToolExecutor, toolParams *core.ToolParams, request map[string]interface{}) ([]map[string]interface{}, error) {
+	data, err := ExecuteRead(ctx, exec, toolParams, "get_transactions", request)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []map[string]interface{}
+	if txArray, ok := data["transactions"].([]interface{}); ok {
+		for _, tx := range txArray {
+			if txMap, ok := tx.(map[string]interface{}); ok {
+				transactions = append(transactions, txMap)
+			}
+		}
+	}
+	return transactions, nil
+}
+
+// TxAmount reads a transaction amount. The mock returns numbers while the
+// live API returns decimal strings, so accept both.
+func TxAmount(tx map[string]interface{}) float64 {
+	return ToFloat(tx["amount"])
+}
+
+// ToFloat reads a loosely typed number. Anything that is not a finite
+// number reads as 0: "NaN" parses as a float but cannot be encoded back
+// into a tool result.
+func ToFloat(v interface{}) float64 {
+	var f float64
+	switch n := v.(type) {
+	case float64:
+		f = n
+	case int:
+		f = float64(n)
+	case json.Number:
+		f, _ = n.Float64()
+	case string:
+		f, _ = strconv.ParseFloat(strings.TrimSpace(n), 64)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return f
+}
+
+// TxTime returns when a transaction happened, preferring "date" over
+// "created_at".
+func TxTime(tx map[string]interface{}) (time.Time, bool) {
+	for _, key := range []string{"date", "created_at"} {
+		s, ok := tx[key].(string)
+		if !ok || s == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, true
+		}
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// TxCurrency returns a transaction's currency code, USD if it has none.
+func TxCurrency(tx map[string]interface{}) string {
+	if c, ok := tx["currency"].(string); ok && c != "" {
+		return strings.ToUpper(c)
+	}
+	return "USD"
+}
+
+// OtherCurrencies lists, sorted, the currencies other than currency that
+// appear in transactions, so a single-currency analysis can say what it left
+// out.
+func OtherCurrencies(transactions []map[string]interface{}, currency string) []string {
+	var others []string
+	for _, tx := range transactions {
+		if c := TxCurrency(tx); c != currency && !slices.Contains(others, c) {
+			others = append(others, c)
+		}
+	}
+	sort.Strings(others)
+	return others
+}
+
+// FormatMoney renders an amount for user-facing text: "$12.50" for dollars,
+// "12.50 EUR" for anything else.
+func FormatMoney(amount float64, currency string) string {
+	if currency == "USD" {
+		return fmt.Sprintf("$%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// TxCounterparty returns the other side of a transaction (a user tag) if the
+// API supplied one.
+func TxCounterparty(tx map[string]interface{}) string {
+	for _, key := range []string{"counterparty", "recipient"} {
+		if s, ok := tx[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// TxAccount returns the account a transaction came from when it has been
+// tagged with one ("liminal", or an imported account's name), or "".
+func TxAccount(tx map[string]interface{}) string {
+	s, _ := tx["account"].(string)
+	return s
+}
+
+// TxMerchant mirrors the merchant naming used by the subscription analyzer.
+func TxMerchant(tx map[string]interface{}) string {
+	if desc, ok := tx["description"].(string); ok && desc != "" {
+		return desc
+	}
+	if cp := TxCounterparty(tx); cp != "" {
+		return cp
+	}
+	return "Unknown"
+}
+
+// Categories maps transaction descriptions to coarse categories.
+// Order matters: the first matching category wins ("uber eats" is dining,
+// plain "uber" is transport).
+var Categories = []struct {
+	Name     string
+	Keywords []string
+}{
+	{"coffee", []string{"starbucks", "coffee", "cafe"}},
+	{"dining", []string{"chipotle", "doordash", "uber eats", "grubhub", "restaurant", "pizza", "mcdonald"}},
+	{"groceries", []string{"whole foods", "grocery", "trader joe", "safeway", "kroger"}},
+	{"transport", []string{"uber", "lyft", "gas station", "metro", "transit", "parking"}},
+	{"subscriptions", []string{"netflix", "spotify", "hulu", "disney", "subscription", "premium"}},
+	{"entertainment", []string{"movie", "theater", "steam", "concert", "tickets"}},
+	{"utilities", []string{"electric", "internet", "phone bill", "water", "utility"}},
+	{"shopping", []string{"amazon", "target", "nike", "walmart", "store"}},
+}
+
+// CategoryNames lists the categories in declaration order, plus
+// "other" for anything unmatched.
+func CategoryNames() []string {
+	names := make([]string, 0, len(Categories)+1)
+	for _, c := range Categories {
+		names = append(names, c.Name)
+	}
+	return append(names, "other")
+}
+
+// Categorize returns the first category whose keywords appear in
+// description, or "other".
+func Categorize(description string) string {
+	desc := strings.ToLower(description)
+	for _, c := range Categories {
+		for _, keyword := range c.Keywords {
+			if strings.Contains(desc, keyword) {
+				return c.Name
+			}
+		}
+	}
+	return "other"
+}
+
+// RoundMoney rounds to whole cents.
+func RoundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// StartOfMonth returns midnight on the first day of t's month.
+func StartOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// DaysInMonth returns the number of days in t's month.
+func DaysInMonth(t time.Time) int {
+	return StartOfMonth(t).AddDate(0, 1, -1).Day()
+}
+
+// AddMonthsClamped adds n months without overflowing into the following
+// month (Jan 31 + 1 month is Feb 28/29, not Mar 3).
+func AddMonthsClamped(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location()).AddDate(0, n, 0)
+	day := t.Day()
+	if last := DaysInMonth(first); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,497 @@
+// Package config loads the server's typed settings from defaults, a JSON
+// file, the environment and flags, and validates them.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Settings start from the defaults below. Each source then overrides the
+// one before it:
+//
+//  1. a JSON config file, from -config or CONFIG_FILE
+//  2. environment variables (a .env file is loaded first)
+//  3. command-line flags
+//
+// With fake_anthropic set, model calls go to a scripted fake API started
+// in-process (see the fake-anthropic command) instead of anthropic_base_url, and no API
+// key is needed.
+//
+// The file uses the JSON field names of Config. Unknown fields are
+// rejected so typos don't go unnoticed. The policy section sets the default
+// spending limits for users who haven't chosen their own. Fields the file
+// leaves out keep their defaults. The API key and api_token can come from the
+// file or the environment, but there are no flags for them, so they never
+// appear in a process listing.
+//
+// `-check-config` loads and validates everything, including the data files
+// and tool names, prints the redacted config and exits without serving.
+
+// Config is every server setting, with the JSON names used in the config
+// file.
+type Config struct {
+	AnthropicKey     string         `json:"anthropic_api_key"`
+	AnthropicBaseURL string         `json:"anthropic_base_url,omitempty"`
+	FakeAnthropic    string         `json:"fake_anthropic,omitempty"` // script for the in-process fake API
+	Model            string         `json:"model"`
+	MaxTokens        int64          `json:"max_tokens"`
+	Port             int            `json:"port"`
+	PublicURL        string         `json:"public_url,omitempty"`   // how users reach the server, for links
+	CORSOrigins      []string       `json:"cors_origins,omitempty"` // browser origins allowed to call /api and /exports
+	LiminalBaseURL   string         `json:"liminal_base_url"`
+	Mock             bool           `json:"mock"`
+	DryRun           bool           `json:"dry_run"`
+	ToolTimeout      Duration       `json:"tool_timeout"`
+	DataDir          string         `json:"data_dir"`
+	AuditLog         string         `json:"audit_log,omitempty"`
+	EnabledTools     []string       `json:"enabled_tools,omitempty"` // empty means all
+	APIToken         string         `json:"api_token,omitempty"`     // bearer token for /api in mock mode
+	Policy           SpendingLimits `json:"policy"`
+	Log              LogSettings    `json:"log"`
+}
+
+// LogSettings is the log section, as written; see LogSettings.Validate.
+type LogSettings struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+	Redact string `json:"redact"`
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() *Config {
+	return &Config{
+		Model:          "claude-sonnet-4-20250514",
+		MaxTokens:      4096,
+		Port:           8080,
+		LiminalBaseURL: "https://api.liminal.cash",
+		CORSOrigins:    []string{"http://localhost:5173"},
+		ToolTimeout:    Duration{30 * time.Second},
+		DataDir:        "data",
+		Policy:         DefaultSpendingLimits(),
+		Log:            LogSettings{Level: "info", Format: "json", Redact: "financial"},
+	}
+}
+
+// Duration is a time.Duration written as a string ("30s") in the file.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// SpendingLimits are the spending policy's limits, the defaults for users
+// who haven't set their own.
+type SpendingLimits struct {
+	PerTransaction           float64 `json:"per_transaction"`
+	Daily                    float64 `json:"daily"`
+	Weekly                   float64 `json:"weekly"`
+	PerRecipientDaily        float64 `json:"per_recipient_daily"`
+	EscalateAbove            float64 `json:"escalate_above"`
+	RapidRepeatCount         int     `json:"rapid_repeat_count"`
+	RapidRepeatWindowMinutes int     `json:"rapid_repeat_window_minutes"`
+	NewPayeeCoolingHours     int     `json:"new_payee_cooling_hours"`
+	NewPayeeCap              float64 `json:"new_payee_cap"`
+	DuplicateWindowMinutes   int     `json:"duplicate_window_minutes"`
+	BlockDuplicates          bool    `json:"block_duplicates"`
+}
+
+// DefaultSpendingLimits starts from the SDK's default user limits and adds
+// the checks the SDK doesn't model.
+func DefaultSpendingLimits() SpendingLimits {
+	sdk := core.DefaultUserLimits()
+	perTx, _ := strconv.ParseFloat(sdk.SingleTransferMax, 64)
+	daily, _ := strconv.ParseFloat(sdk.DailyTransferLimit, 64)
+	return SpendingLimits{
+		PerTransaction:           perTx,
+		Daily:                    daily,
+		Weekly:                   2.5 * daily,
+		PerRecipientDaily:        perTx / 2,
+		EscalateAbove:            1000,
+		RapidRepeatCount:         3,
+		RapidRepeatWindowMinutes: 10,
+		NewPayeeCoolingHours:     24,
+		NewPayeeCap:              250,
+		DuplicateWindowMinutes:   5,
+	}
+}
+
+// Validate rejects negative limits.
+func (l SpendingLimits) Validate() error {
+	if l.PerTransaction < 0 || l.Daily < 0 || l.Weekly < 0 || l.PerRecipientDaily < 0 ||
+		l.EscalateAbove < 0 || l.RapidRepeatCount < 0 || l.RapidRepeatWindowMinutes < 0 ||
+		l.NewPayeeCoolingHours < 0 || l.NewPayeeCap < 0 || l.DuplicateWindowMinutes < 0 {
+		return fmt.Errorf("limits cannot be negative")
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// sources
+// ---------------------------------------------------------------------------
+
+// configSetting is one setting that can come from the environment and,
+// when flag is set, the command line. Both go through set.
+type configSetting struct {
+	env    string
+	flag   string
+	isBool bool
+	usage  string
+	set    func(c *Config, v string) error
+}
+
+var configSettings = []configSetting{
+	{env: "ANTHROPIC_API_KEY", usage: "Anthropic API key",
+		set: func(c *Config, v string) error { c.AnthropicKey = v; return nil }},
+	{env: "API_TOKEN", usage: "bearer token for the REST API in mock mode",
+		set: func(c *Config, v string) error { c.APIToken = v; return nil }},
+	{env: "ANTHROPIC_BASE_URL", flag: "anthropic-base-url", usage: "Anthropic API base URL (default: the SDK's)",
+		set: func(c *Config, v string) error { c.AnthropicBaseURL = v; return nil }},
+	{env: "FAKE_ANTHROPIC_SCRIPT", flag: "fake-anthropic", usage: "answer model calls from this script instead of the Anthropic API",
+		set: func(c *Config, v string) error { c.FakeAnthropic = v; return nil }},
+	{env: "ANTHROPIC_MODEL", flag: "model", usage: "Claude model",
+		set: func(c *Config, v string) error { c.Model = v; return nil }},
+	{env: "ANTHROPIC_MAX_TOKENS", flag: "max-tokens", usage: "maximum tokens per response",
+		set: func(c *Config, v string) error { return parseInto(&c.MaxTokens, v) }},
+	{env: "PORT", flag: "port", usage: "HTTP port",
+		set: func(c *Config, v string) error {
+			var port int64
+			err := parseInto(&port, v)
+			c.Port = int(port)
+			return err
+		}},
+	{env: "PUBLIC_URL", flag: "public-url", usage: "URL users reach the server at, for download links (default: http://localhost:PORT)",
+		set: func(c *Config, v string) error { c.PublicURL = v; return nil }},
+	{env: "CORS_ORIGINS", flag: "cors-origins", usage: "comma-separated browser origins allowed to call the REST API, or * (default: the Vite dev server)",
+		set: func(c *Config, v string) error { c.CORSOrigins = splitList(v); return nil }},
+	{env: "LIMINAL_BASE_URL", flag: "liminal-base-url", usage: "Liminal API base URL",
+		set: func(c *Config, v string) error { c.LiminalBaseURL = v; return nil }},
+	{env: "USE_MOCK", flag: "mock", isBool: true, usage: "use the mock executor instead of the Liminal API",
+		set: func(c *Config, v string) error { return parseBool(&c.Mock, v) }},
+	{env: "DRY_RUN", flag: "dry-run", isBool: true, usage: "make every money-moving call a dry run",
+		set: func(c *Config, v string) error { return parseBool(&c.DryRun, v) }},
+	{env: "TOOL_TIMEOUT", flag: "tool-timeout", usage: "time limit per tool call, e.g. 45s (0 for none)",
+		set: func(c *Config, v string) error { return c.ToolTimeout.UnmarshalText([]byte(v)) }},
+	{env: "DATA_DIR", flag: "data-dir", usage: "directory for per-user tool state",
+		set: func(c *Config, v string) error { c.DataDir = v; return nil }},
+	{env: "AUDIT_LOG", flag: "audit-log", usage: "audit log path (default: DATA_DIR/audit.log)",
+		set: func(c *Config, v string) error { c.AuditLog = v; return nil }},
+	{env: "ENABLED_TOOLS", flag: "tools", usage: "comma-separated tools to register (default: all)",
+		set: func(c *Config, v string) error { c.EnabledTools = splitList(v); return nil }},
+	{env: "LOG_LEVEL", flag: "log-level", usage: "debug, info, warn or error",
+		set: func(c *Config, v string) error { c.Log.Level = v; return nil }},
+	{env: "LOG_FORMAT", flag: "log-format", usage: "json or text",
+		set: func(c *Config, v string) error { c.Log.Format = v; return nil }},
+	{env: "LOG_REDACT", flag: "log-redact", usage: "none, secrets, pii or financial",
+		set: func(c *Config, v string) error { c.Log.Redact = v; return nil }},
+}
+
+// Load builds the config from the defaults, the config file, the
+// environment and args, in that order. It reports whether -check-config was
+// given. The result is not validated yet.
+func Load(args []string) (*Config, bool, error) {
+	fs := flag.NewFlagSet("hackathon-starter", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "JSON config file")
+	checkOnly := fs.Bool("check-config", false, "validate the configuration, print it and exit")
+
+	// Flag values are collected first and applied after the file and the
+	// environment, whatever order they're given in.
+	type flagValue struct {
+		setting configSetting
+		value   string
+	}
+	var flagValues []flagValue
+	for _, s := range configSettings {
+		if s.flag == "" {
+			continue
+		}
+		s := s
+		collect := func(v string) error {
+			flagValues = append(flagValues, flagValue{s, v})
+			return nil
+		}
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+		if s.isBool {
+			fs.BoolFunc(s.flag, usage, collect)
+		} else {
+			fs.Func(s.flag, usage, collect)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+	if fs.NArg() > 0 {
+		return nil, false, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	cfg := Default()
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, false, err
+		}
+	}
+	for _, s := range configSettings {
+		if v := os.Getenv(s.env); v != "" {
+			if err := s.set(cfg, v); err != nil {
+				return nil, false, fmt.Errorf("invalid %s %q: %w", s.env, v, err)
+			}
+		}
+	}
+	for _, fv := range flagValues {
+		if err := fv.setting.set(cfg, fv.value); err != nil {
+			return nil, false, fmt.Errorf("invalid -%s %q: %w", fv.setting.flag, fv.value, err)
+		}
+	}
+	return cfg, *checkOnly, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	if dec.More() {
+		return fmt.Errorf("parse config file %s: unexpected data after the config object", path)
+	}
+	return nil
+}
+
+func parseInto(dst *int64, v string) error {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil {
+		return errors.New("not a whole number")
+	}
+	*dst = n
+	return nil
+}
+
+func parseBool(dst *bool, v string) error {
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		return errors.New("use true or false")
+	}
+	*dst = b
+	return nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// validation
+// ---------------------------------------------------------------------------
+
+// Validate reports every problem with the config at once.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.AnthropicKey == "" && c.FakeAnthropic == "" {
+		add("anthropic_api_key is required (set ANTHROPIC_API_KEY)")
+	}
+	if strings.TrimSpace(c.Model) == "" {
+		add("model must not be empty")
+	}
+	if c.MaxTokens < 1 {
+		add("max_tokens must be at least 1, got %d", c.MaxTokens)
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		add("port must be between 1 and 65535, got %d", c.Port)
+	}
+	if c.AnthropicBaseURL != "" {
+		if err := checkBaseURL(c.AnthropicBaseURL); err != nil {
+			add("anthropic_base_url: %v", err)
+		}
+	}
+	if c.PublicURL != "" {
+		if err := checkBaseURL(c.PublicURL); err != nil {
+			add("public_url: %v", err)
+		}
+	}
+	for _, origin := range c.CORSOrigins {
+		if err := checkOrigin(origin); err != nil {
+			add("cors_origins: %v", err)
+		}
+	}
+	if err := checkBaseURL(c.LiminalBaseURL); err != nil && !c.Mock {
+		add("liminal_base_url: %v", err)
+	}
+	if c.ToolTimeout.Duration < 0 {
+		add("tool_timeout must not be negative, got %s", c.ToolTimeout)
+	}
+	if strings.TrimSpace(c.DataDir) == "" {
+		add("data_dir must not be empty")
+	}
+	seen := make(map[string]bool)
+	for _, name := range c.EnabledTools {
+		if seen[name] {
+			add("enabled_tools lists %s twice", name)
+		}
+		seen[name] = true
+	}
+	if err := c.Policy.Validate(); err != nil {
+		add("policy: %v", err)
+	}
+	if err := c.Log.Validate(); err != nil {
+		add("log: %v", err)
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func checkBaseURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	return nil
+}
+
+// checkOrigin accepts "*" or a browser origin: scheme and host, no path.
+func checkOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	if err := checkBaseURL(origin); err != nil {
+		return err
+	}
+	if u, _ := url.Parse(origin); u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q is not an origin like http://localhost:5173", origin)
+	}
+	return nil
+}
+
+// Validate checks the log section's values. The server turns them into a
+// logger; this only rejects what it couldn't use.
+func (s LogSettings) Validate() error {
+	if s.Level != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(s.Level)); err != nil {
+			return fmt.Errorf("invalid level %q: use debug, info, warn or error", s.Level)
+		}
+	}
+	if v := strings.ToLower(s.Format); v != "" && v != "json" && v != "text" {
+		return fmt.Errorf("invalid format %q: use json or text", s.Format)
+	}
+	switch strings.ToLower(s.Redact) {
+	case "", "none", "off", "secrets", "pii", "financial", "all":
+	default:
+		return fmt.Errorf("invalid redact: unknown redaction level %q: use none, secrets, pii or financial", s.Redact)
+	}
+	return nil
+}
+
+// ToolEnabled reports whether a tool should be registered.
+func (c *Config) ToolEnabled(name string) bool {
+	if len(c.EnabledTools) == 0 {
+		return true
+	}
+	for _, n := range c.EnabledTools {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UnknownTools returns the enabled_tools entries that match no registered
+// tool.
+func (c *Config) UnknownTools(registered map[string]bool) []string {
+	var unknown []string
+	for _, n := range c.EnabledTools {
+		if !registered[n] {
+			unknown = append(unknown, n)
+		}
+	}
+	return unknown
+}
+
+// BaseURL is public_url without a trailing slash, or the local address
+// when it isn't set.
+func (c *Config) BaseURL() string {
+	if c.PublicURL != "" {
+		return strings.TrimRight(c.PublicURL, "/")
+	}
+	return fmt.Sprintf("http://localhost:%d", c.Port)
+}
+
+// AuditLogPath is audit_log, or audit.log under data_dir.
+func (c *Config) AuditLogPath() string {
+	if c.AuditLog != "" {
+		return c.AuditLog
+	}
+	return filepath.Join(c.DataDir, "audit.log")
+}
+
+// ---------------------------------------------------------------------------
+// output
+// ---------------------------------------------------------------------------
+
+// Redacted is a copy of the config with the API key and token masked.
+func (c *Config) Redacted() Config {
+	out := *c
+	out.AnthropicKey = maskSecret(c.AnthropicKey)
+	out.APIToken = maskSecret(c.APIToken)
+	return out
+}
+
+// String is the redacted config as JSON, so printing the config never shows
+// the key.
+func (c *Config) String() string {
+	raw, _ := json.Marshal(c.Redacted())
+	return string(raw)
+}
+
+// maskSecret keeps enough of a key to tell which one is configured.
+func maskSecret(s string) string {
+	switch {
+	case s == "":
+		return ""
+	case len(s) <= 12:
+		return "[REDACTED]"
+	}
+	return s[:7] + "…[REDACTED]"
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Later sources win: defaults, then the file, then the environment, then
+// flags.
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"port": 9000, "model": "from-file", "tool_timeout": "10s", "policy": {"daily": 50}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("ANTHROPIC_MODEL", "from-env")
+	t.Setenv("PORT", "9001")
+
+	c, checkOnly, err := Load([]string{"-port", "9002", "-check-config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checkOnly {
+		t.Error("-check-config was not reported")
+	}
+	if c.Port != 9002 || c.Model != "from-env" || c.ToolTimeout.Duration != 10*time.Second {
+		t.Errorf("got port %d, model %q, timeout %s", c.Port, c.Model, c.ToolTimeout)
+	}
+	if c.Policy.Daily != 50 || c.Policy.PerTransaction != DefaultSpendingLimits().PerTransaction {
+		t.Errorf("policy = %+v, want the file's daily limit over the defaults", c.Policy)
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"prot": 9000}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Load([]string{"-config", file}); err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Errorf("err = %v, want the unknown field named", err)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	c := Default()
+	c.Port = 0
+	c.Policy.Daily = -1
+	c.Log = LogSettings{Level: "loud", Format: "xml"}
+	c.CORSOrigins = []string{"http://localhost:5173/app"}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"anthropic_api_key", "port", "policy", "level", "cors_origins"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	c = Default()
+	c.AnthropicKey = "sk-ant-test"
+	if err := c.Validate(); err != nil {
+		t.Errorf("default config with a key: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package mock
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// ============================================================================
+// ACCOUNTS  –  per-user balances that the mock writes move
+// ============================================================================
+//
+// The mock keeps a small ledger per user instead of answering with fixed
+// balances: send_money, deposit_savings and withdraw_savings move money,
+// the balance reads report the result, and an overdraft fails. The three
+// writes also require confirmation, like the live tools. Earlier versions
+// answered writes immediately and kept the balances fixed.
+
+// account is one user's wallet and savings balance. Every user starts
+// from the frontend's mock balances.
+type account struct {
+	mu      sync.Mutex
+	wallet  float64
+	savings float64
+}
+
+func (m *Executor) account(userID string) *account {
+	m.accountsMu.Lock()
+	defer m.accountsMu.Unlock()
+	if m.accounts == nil {
+		m.accounts = make(map[string]*account)
+	}
+	acct, ok := m.accounts[userID]
+	if !ok {
+		acct = &account{wallet: 2847.50, savings: 15420.30}
+		m.accounts[userID] = acct
+	}
+	return acct
+}
+
+// balances returns the current wallet and savings balance.
+func (a *account) balances() (wallet, savings float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.wallet, a.savings
+}
+
+// move applies a transfer to the balances, or reports which balance is
+// too low. Amounts are rounded to cents.
+func (a *account) move(walletDelta, savingsDelta float64) (wallet, savings float64, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	wallet = math.Round((a.wallet+walletDelta)*100) / 100
+	savings = math.Round((a.savings+savingsDelta)*100) / 100
+	switch {
+	case wallet < 0:
+		return a.wallet, a.savings, fmt.Errorf("insufficient funds: wallet balance is %.2f", a.wallet)
+	case savings < 0:
+		return a.wallet, a.savings, fmt.Errorf("insufficient funds: savings balance is %.2f", a.savings)
+	}
+	a.wallet, a.savings = wallet, savings
+	return wallet, savings, nil
+}
@@ -0,0 +1,53 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// The writes need confirmation, move each user's own balances and fail
+// rather than overdraw, and a retried key returns the original response.
+func TestMoneyMovement(t *testing.T) {
+	exec := NewExecutor()
+	byName := make(map[string]core.Tool)
+	for _, tool := range Tools(exec) {
+		byName[tool.Name()] = tool
+	}
+	for _, name := range []string{"send_money", "deposit_savings", "withdraw_savings"} {
+		if !byName[name].RequiresConfirmation() {
+			t.Errorf("%s doesn't require confirmation", name)
+		}
+	}
+
+	write := func(userID, tool, input string) *core.ExecuteResponse {
+		t.Helper()
+		resp, err := exec.ExecuteWrite(context.Background(), &core.ExecuteRequest{UserID: userID, Tool: tool, Input: json.RawMessage(input)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	balances := func(userID string) string {
+		wallet, savings := exec.account(userID).balances()
+		return fmt.Sprintf("%.2f/%.2f", wallet, savings)
+	}
+
+	write("u1", "send_money", `{"recipient": "@alice", "amount": 47.5, "idempotency_key": "k1"}`)
+	write("u1", "send_money", `{"recipient": "@alice", "amount": 47.5, "idempotency_key": "k1"}`)
+	write("u1", "deposit_savings", `{"amount": "800"}`)
+	write("u1", "withdraw_savings", `{"amount": 20.3}`)
+	if got := balances("u1"); got != "2020.30/16200.00" {
+		t.Errorf("u1 balances = %s, want 2020.30/16200.00", got)
+	}
+	if got := balances("u2"); got != "2847.50/15420.30" {
+		t.Errorf("u2 balances = %s, want the starting balances", got)
+	}
+	if resp := write("u1", "send_money", `{"recipient": "@alice", "amount": 5000}`); resp.Success || !strings.Contains(resp.Error, "insufficient funds") {
+		t.Errorf("overdraft: %+v, want insufficient funds", resp)
+	}
+}
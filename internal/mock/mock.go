@@ -0,0 +1,526 @@
+// Package mock stands in for the Liminal banking API: an executor with
+// per-user balances and the nine Liminal tools built against it, returning
+// the same data as the frontend's mockBankingData.ts.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// EXECUTOR  –  satisfies core.ToolExecutor for the custom analyzer tools
+// ============================================================================
+
+// Executor answers the Liminal tools from in-memory accounts, one per user,
+// so the agent can run without Liminal credentials.
+type Executor struct {
+	// writes holds the response for each idempotency key seen, so a retried
+	// write returns the original transaction like the live API would.
+	mu     sync.Mutex
+	writes map[string]*core.ExecuteResponse
+
+	// accounts holds each user's balances, which the writes move around.
+	accountsMu sync.Mutex
+	accounts   map[string]*account
+}
+
+// NewExecutor returns an executor whose users all start from the frontend's
+// mock balances.
+func NewExecutor() *Executor {
+	return &Executor{}
+}
+
+var _ core.ToolExecutor = (*Executor)(nil)
+
+// READ EXECUTION (safe tools: get_balance, get_transactions, etc.)
+func (m *Executor) Execute(
+	_ context.Context,
+	req *core.ExecuteRequest,
+) (*core.ExecuteResponse, error) {
+	return m.run(req)
+}
+
+// WRITE EXECUTION (money-moving tools: send_money, deposit, withdraw)
+func (m *Executor) ExecuteWrite(
+	_ context.Context,
+	req *core.ExecuteRequest,
+) (*core.ExecuteResponse, error) {
+	var in struct {
+		IdempotencyKey string `json:"idempotency_key"`
+	}
+	_ = json.Unmarshal(req.Input, &in)
+	if in.IdempotencyKey == "" {
+		return m.run(req)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if resp, ok := m.writes[in.IdempotencyKey]; ok {
+		replay := *resp
+		return &replay, nil
+	}
+	resp, err := m.run(req)
+	if err == nil && resp.Success {
+		if m.writes == nil {
+			m.writes = make(map[string]*core.ExecuteResponse)
+		}
+		m.writes[in.IdempotencyKey] = resp
+	}
+	return resp, err
+}
+
+// Shared tool router
+func (m *Executor) run(
+	req *core.ExecuteRequest,
+) (*core.ExecuteResponse, error) {
+
+	var result *core.ToolResult
+	var err error
+
+	switch req.Tool {
+
+	// ---------- READ ----------
+	case "get_balance":
+		result, err = getBalance(m.account(req.UserID))
+
+	case "get_savings_balance":
+		result, err = getSavingsBalance(m.account(req.UserID))
+
+	case "get_vault_rates":
+		result, err = getVaultRates()
+
+	case "get_transactions":
+		result, err = getTransactions(req.Input)
+
+	case "get_profile":
+		result, err = getProfile()
+
+	case "search_users":
+		result, err = searchUsers()
+
+	// ---------- WRITE ----------
+	case "send_money":
+		result, err = sendMoney(m.account(req.UserID), req.Input)
+
+	case "deposit_savings":
+		result, err = depositSavings(m.account(req.UserID), req.Input)
+
+	case "withdraw_savings":
+		result, err = withdrawSavings(m.account(req.UserID), req.Input)
+
+	default:
+		result = &core.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("mock: unknown tool %q", req.Tool),
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// ToolResult.Data is already []byte from json.Marshal
+	raw, ok := result.Data.(json.RawMessage)
+	if !ok {
+		// If it's actually []byte, convert safely
+		if b, ok2 := result.Data.([]byte); ok2 {
+			raw = json.RawMessage(b)
+		} else {
+			raw = json.RawMessage(`{}`)
+		}
+	}
+
+	return &core.ExecuteResponse{
+		Success: result.Success,
+		Error:   result.Error,
+		Data:    raw,
+	}, nil
+}
+
+// CANCEL
+func (m *Executor) Cancel(
+	_ context.Context,
+	_ string,
+	_ string,
+) error {
+	return nil
+}
+
+// CONFIRM
+func (m *Executor) Confirm(
+	_ context.Context,
+	_ string,
+	_ string,
+) (*core.ExecuteResponse, error) {
+	return &core.ExecuteResponse{
+		Success: true,
+		Data:    json.RawMessage(`{"confirmed": true}`),
+	}, nil
+}
+
+// ============================================================================
+// TOOL REGISTRATION  –  the 9 Liminal tools built with tools.New()
+// ============================================================================
+
+// readHandler runs a mock read tool through the executor, which keeps
+// the balances.
+func readHandler(exec core.ToolExecutor, tool string) core.ToolHandler {
+	return func(ctx context.Context, tp *core.ToolParams) (*core.ToolResult, error) {
+		resp, err := exec.Execute(ctx, &core.ExecuteRequest{
+			UserID:    tp.UserID,
+			Tool:      tool,
+			Input:     tp.Input,
+			RequestID: tp.RequestID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &core.ToolResult{Success: resp.Success, Data: resp.Data, Error: resp.Error}, nil
+	}
+}
+
+// writeHandler runs a mock write tool through the executor, so executor
+// decorators (idempotency keys, ...) apply in mock mode like they do live.
+func writeHandler(exec core.ToolExecutor, tool string) core.ToolHandler {
+	return func(ctx context.Context, tp *core.ToolParams) (*core.ToolResult, error) {
+		resp, err := exec.ExecuteWrite(ctx, &core.ExecuteRequest{
+			UserID:    tp.UserID,
+			Tool:      tool,
+			Input:     tp.Input,
+			RequestID: tp.RequestID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &core.ToolResult{Success: resp.Success, Data: resp.Data, Error: resp.Error}, nil
+	}
+}
+
+// Tools builds the Liminal tools against exec, normally an Executor wrapped
+// in the server's executor decorators.
+func Tools(exec core.ToolExecutor) []core.Tool {
+	return []core.Tool{
+		tools.New("get_balance").
+			Description("Check the user's current wallet balance.").
+			Schema(tools.ObjectSchema(map[string]interface{}{})).
+			Handler(readHandler(exec, "get_balance")).Build(),
+
+		tools.New("get_savings_balance").
+			Description("Check the user's savings balance and APY.").
+			Schema(tools.ObjectSchema(map[string]interface{}{})).
+			Handler(readHandler(exec, "get_savings_balance")).Build(),
+
+		tools.New("get_vault_rates").
+			Description("Get current savings vault rates and APY.").
+			Schema(tools.ObjectSchema(map[string]interface{}{})).
+			Handler(func(_ context.Context, _ *core.ToolParams) (*core.ToolResult, error) {
+				return getVaultRates()
+			}).Build(),
+
+		tools.New("get_transactions").
+			Description("View the user's transaction history.").
+			Schema(tools.ObjectSchema(map[string]interface{}{
+				"limit":      toolkit.Range(tools.IntegerProperty("Max number of transactions to return (default: 20)"), 1, 500),
+				"start_date": tools.StringProperty("Filter transactions after this date (YYYY-MM-DD)"),
+			})).
+			Handler(func(_ context.Context, tp *core.ToolParams) (*core.ToolResult, error) {
+				return getTransactions(tp.Input)
+			}).Build(),
+
+		tools.New("get_profile").
+			Description("Get the user's profile information.").
+			Schema(tools.ObjectSchema(map[string]interface{}{})).
+			Handler(func(_ context.Context, _ *core.ToolParams) (*core.ToolResult, error) {
+				return getProfile()
+			}).Build(),
+
+		tools.New("search_users").
+			Description("Search for users by display tag.").
+			Schema(tools.ObjectSchema(map[string]interface{}{
+				"query": toolkit.MinLength(tools.StringProperty("The user tag or name to search for"), 1),
+			}, "query")).
+			Handler(func(_ context.Context, _ *core.ToolParams) (*core.ToolResult, error) {
+				return searchUsers()
+			}).Build(),
+
+		tools.New("send_money").
+			Description("Send money to another user. Requires confirmation.").
+			Schema(tools.ObjectSchema(map[string]interface{}{
+				"recipient": toolkit.MinLength(tools.StringProperty("Recipient user tag (e.g. @alice)"), 1),
+				"amount":    toolkit.Positive(tools.NumberProperty("Amount to send")),
+				"currency":  tools.StringProperty("Currency code (default: USD)"),
+			}, "recipient", "amount")).
+			RequiresConfirmation().
+			Handler(writeHandler(exec, "send_money")).Build(),
+
+		tools.New("deposit_savings").
+			Description("Deposit funds into savings. Requires confirmation.").
+			Schema(tools.ObjectSchema(map[string]interface{}{
+				"amount":   toolkit.Positive(tools.NumberProperty("Amount to deposit")),
+				"currency": tools.StringProperty("Currency code (default: USD)"),
+			}, "amount")).
+			RequiresConfirmation().
+			Handler(writeHandler(exec, "deposit_savings")).Build(),
+
+		tools.New("withdraw_savings").
+			Description("Withdraw funds from savings. Requires confirmation.").
+			Schema(tools.ObjectSchema(map[string]interface{}{
+				"amount":   toolkit.Positive(tools.NumberProperty("Amount to withdraw")),
+				"currency": tools.StringProperty("Currency code (default: USD)"),
+			}, "amount")).
+			RequiresConfirmation().
+			Handler(writeHandler(exec, "withdraw_savings")).Build(),
+	}
+}
+
+// ============================================================================
+// RESPONSES  –  match frontend mockBankingData.ts exactly
+// ============================================================================
+
+func getBalance(acct *account) (*core.ToolResult, error) {
+	wallet, _ := acct.balances()
+	return toToolResult(map[string]interface{}{
+		"balance":  wallet,
+		"currency": "USD",
+	})
+}
+
+func getSavingsBalance(acct *account) (*core.ToolResult, error) {
+	_, savings := acct.balances()
+	return toToolResult(map[string]interface{}{
+		"balance":  savings,
+		"currency": "USD",
+		"apy":      4.5,
+		"positions": []map[string]interface{}{
+			{"vault_id": "vault_usd_1", "balance": savings, "apy": 4.5},
+		},
+	})
+}
+
+func getVaultRates() (*core.ToolResult, error) {
+	return toToolResult(map[string]interface{}{
+		"rates": []map[string]interface{}{
+			{"vault_id": "vault_usd_1", "apy": 4.5, "currency": "USD"},
+		},
+	})
+}
+
+func getProfile() (*core.ToolResult, error) {
+	return toToolResult(map[string]interface{}{
+		"id":         "user_mock_123",
+		"email":      "demo@liminal.cash",
+		"name":       "Demo User",
+		"created_at": time.Now().AddDate(0, 0, -90).Format(time.RFC3339),
+		"verified":   true,
+	})
+}
+
+func searchUsers() (*core.ToolResult, error) {
+	return toToolResult(map[string]interface{}{
+		"users": []map[string]interface{}{
+			{"id": "user_mock_456", "name": "Alice", "tag": "@alice"},
+		},
+	})
+}
+
+// ---------------------------------------------------------------------------
+// transactions  –  same templates & variance logic as the TS mock
+// ---------------------------------------------------------------------------
+
+var txTemplates = []struct {
+	Description string
+	Amount      float64
+	Type        string // send | receive | deposit | withdrawal
+}{
+	{"Starbucks Coffee", 8.50, "send"},
+	{"Chipotle Mexican Grill", 15.75, "send"},
+	{"Whole Foods Market", 67.30, "send"},
+	{"DoorDash - Pizza Delivery", 32.50, "send"},
+	{"Local Coffee Shop", 6.25, "send"},
+	{"Uber Ride", 18.50, "send"},
+	{"Gas Station", 45.00, "send"},
+	{"Lyft Ride", 22.75, "send"},
+	{"Metro Card Reload", 30.00, "send"},
+	{"Amazon.com", 89.99, "send"},
+	{"Target Store", 54.25, "send"},
+	{"Nike Store", 125.00, "send"},
+	{"Netflix Subscription", 15.99, "send"},
+	{"Spotify Premium", 10.99, "send"},
+	{"Movie Theater", 28.50, "send"},
+	{"Steam Games", 59.99, "send"},
+	{"Electric Bill Payment", 125.50, "send"},
+	{"Internet Service", 79.99, "send"},
+	{"Phone Bill", 65.00, "send"},
+	{"Payroll Deposit", 2500.00, "receive"},
+	{"Freelance Payment", 450.00, "receive"},
+	{"Refund from Amazon", 29.99, "receive"},
+	{"Payment from @alice", 75.00, "receive"},
+	{"Savings Deposit", 200.00, "deposit"},
+	{"Savings Withdrawal", 100.00, "withdrawal"},
+}
+
+func getTransactions(input json.RawMessage) (*core.ToolResult, error) {
+	var params struct {
+		Limit     int    `json:"limit"`
+		StartDate string `json:"start_date"`
+	}
+	_ = json.Unmarshal(input, &params)
+	if params.Limit == 0 {
+		params.Limit = 20
+	}
+
+	r := rand.New(rand.NewSource(42)) // fixed seed → deterministic every time
+	now := time.Now()
+
+	var cutoff time.Time
+	if params.StartDate != "" {
+		if t, err := time.Parse("2006-01-02", params.StartDate); err == nil {
+			cutoff = t
+		}
+	}
+
+	type tx struct {
+		ID           string  `json:"id"`
+		Amount       float64 `json:"amount"`
+		Currency     string  `json:"currency"`
+		Type         string  `json:"type"`
+		Status       string  `json:"status"`
+		Description  string  `json:"description"`
+		Date         string  `json:"date"`
+		CreatedAt    string  `json:"created_at"`
+		Counterparty string  `json:"counterparty,omitempty"`
+	}
+
+	var txs []tx
+	for i := 0; i < 40 && len(txs) < params.Limit; i++ {
+		tmpl := txTemplates[r.Intn(len(txTemplates))]
+		daysAgo := r.Intn(30)
+		createdAt := now.AddDate(0, 0, -daysAgo)
+
+		if !cutoff.IsZero() && createdAt.Before(cutoff) {
+			continue
+		}
+
+		// 80–120 % variance, same as TS mock
+		variance := 0.8 + r.Float64()*0.4
+		amount := math.Round(tmpl.Amount*variance*100) / 100
+
+		cp := ""
+		if tmpl.Type == "receive" && r.Float64() > 0.5 {
+			cp = "@alice"
+		}
+
+		txs = append(txs, tx{
+			ID:           fmt.Sprintf("tx_mock_%d_%d", i, now.UnixMilli()),
+			Amount:       amount,
+			Currency:     "USD",
+			Type:         tmpl.Type,
+			Status:       "completed",
+			Description:  tmpl.Description,
+			Date:         createdAt.Format(time.RFC3339),
+			CreatedAt:    createdAt.Format(time.RFC3339),
+			Counterparty: cp,
+		})
+	}
+
+	return toToolResult(map[string]interface{}{
+		"transactions": txs,
+		"total":        len(txs),
+	})
+}
+
+// ---------------------------------------------------------------------------
+// write operations  –  move the balances and return a completed transaction
+// ---------------------------------------------------------------------------
+
+func sendMoney(acct *account, input json.RawMessage) (*core.ToolResult, error) {
+	var p struct {
+		Recipient string             `json:"recipient"`
+		Amount    toolkit.FlexAmount `json:"amount"`
+		Currency  string             `json:"currency"`
+	}
+	_ = json.Unmarshal(input, &p)
+	if p.Currency == "" {
+		p.Currency = "USD"
+	}
+	wallet, _, err := acct.move(-float64(p.Amount), 0)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+	return toToolResult(map[string]interface{}{
+		"transaction_id": toolkit.NewID("tx_mock_send"),
+		"status":         "completed",
+		"amount":         float64(p.Amount),
+		"currency":       p.Currency,
+		"recipient":      p.Recipient,
+		"new_balance":    wallet,
+		"created_at":     time.Now().Format(time.RFC3339),
+	})
+}
+
+func depositSavings(acct *account, input json.RawMessage) (*core.ToolResult, error) {
+	var p struct {
+		Amount   toolkit.FlexAmount `json:"amount"`
+		Currency string             `json:"currency"`
+	}
+	_ = json.Unmarshal(input, &p)
+	if p.Currency == "" {
+		p.Currency = "USD"
+	}
+	_, savings, err := acct.move(-float64(p.Amount), float64(p.Amount))
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+	return toToolResult(map[string]interface{}{
+		"transaction_id":      toolkit.NewID("tx_mock_dep"),
+		"status":              "completed",
+		"amount":              float64(p.Amount),
+		"currency":            p.Currency,
+		"new_savings_balance": savings,
+		"created_at":          time.Now().Format(time.RFC3339),
+	})
+}
+
+func withdrawSavings(acct *account, input json.RawMessage) (*core.ToolResult, error) {
+	var p struct {
+		Amount   toolkit.FlexAmount `json:"amount"`
+		Currency string             `json:"currency"`
+	}
+	_ = json.Unmarshal(input, &p)
+	if p.Currency == "" {
+		p.Currency = "USD"
+	}
+	_, savings, err := acct.move(float64(p.Amount), -float64(p.Amount))
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+	return toToolResult(map[string]interface{}{
+		"transaction_id":      toolkit.NewID("tx_mock_wd"),
+		"status":              "completed",
+		"amount":              float64(p.Amount),
+		"currency":            p.Currency,
+		"new_savings_balance": savings,
+		"created_at":          time.Now().Format(time.RFC3339),
+	})
+}
+
+func toToolResult(v interface{}) (*core.ToolResult, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return &core.ToolResult{Success: false, Error: err.Error()}, nil
+	}
+	// RawMessage, not []byte: the engine marshals Data again, and []byte
+	// would reach the model as base64.
+	return &core.ToolResult{Success: true, Data: json.RawMessage(data)}, nil
+}
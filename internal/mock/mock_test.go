@@ -0,0 +1,30 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+)
+
+// Tool results carry their data as JSON, so the engine's second marshal
+// passes it through instead of base64-encoding bytes for the model.
+func TestToolResultDataIsJSON(t *testing.T) {
+	for _, tool := range Tools(NewExecutor()) {
+		if tool.Name() != "get_balance" {
+			continue
+		}
+		result, err := tool.Execute(context.Background(), &core.ToolParams{UserID: "u1", Input: json.RawMessage(`{}`)})
+		if err != nil || !result.Success {
+			t.Fatalf("get_balance: %v %+v", err, result)
+		}
+		raw, _ := json.Marshal(result.Data)
+		if !strings.HasPrefix(string(raw), `{"balance":`) {
+			t.Errorf("get_balance data marshals to %s, want a JSON object", raw)
+		}
+		return
+	}
+	t.Fatal("no get_balance tool")
+}
@@ -0,0 +1,64 @@
+// Package toolkit holds the pieces every tool in the starter is built from:
+// input schema constraints and their validation, typed handlers, the error
+// result returned to the model, and small input helpers.
+package toolkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/google/uuid"
+)
+
+// Error is the failed result returned to the model.
+func Error(format string, args ...interface{}) *core.ToolResult {
+	return &core.ToolResult{
+		Success: false,
+		Error:   fmt.Sprintf(format, args...),
+	}
+}
+
+// TypedHandler decodes the tool input into P before calling fn, so handlers
+// don't each repeat the parsing and its error reply.
+func TypedHandler[P any](fn func(ctx context.Context, toolParams *core.ToolParams, params P) (*core.ToolResult, error)) core.ToolHandler {
+	return func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+		var params P
+		if len(toolParams.Input) > 0 {
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return Error("invalid input: %v", err), nil
+			}
+		}
+		return fn(ctx, toolParams, params)
+	}
+}
+
+// FlexAmount accepts amounts as JSON numbers (the mock tool schema) or as
+// decimal strings (the live Liminal schema, which scheduled transfers use).
+type FlexAmount float64
+
+func (a *FlexAmount) UnmarshalJSON(b []byte) error {
+	var f float64
+	if err := json.Unmarshal(b, &f); err == nil {
+		*a = FlexAmount(f)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", s)
+	}
+	*a = FlexAmount(f)
+	return nil
+}
+
+// NewID returns a short random ID such as "bud_3f9c0a1b2c4d".
+func NewID(prefix string) string {
+	return prefix + "_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
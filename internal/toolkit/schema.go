@@ -0,0 +1,297 @@
+package toolkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// INPUT SCHEMAS  –  constraints on tool input, checked before handlers run
+// ============================================================================
+// Tool schemas are built with tools.ObjectSchema and the property helpers;
+// the functions below add JSON Schema constraints (minimum, maximum,
+// exclusiveMinimum, minLength) to those properties. validationMiddleware
+// (in the server) checks every call against its tool's schema: required fields, types, enums
+// and bounds, plus cross-field rules such as min_amount <= max_amount.
+//
+// Failures go back to the model as a JSON error listing every problem by
+// field, so it can fix them all and call the tool again.
+
+// Minimum requires a number or integer to be at least min.
+func Minimum(prop map[string]interface{}, min float64) map[string]interface{} {
+	prop["minimum"] = min
+	return prop
+}
+
+// Range requires a number or integer to be between min and max.
+func Range(prop map[string]interface{}, min, max float64) map[string]interface{} {
+	prop["minimum"] = min
+	prop["maximum"] = max
+	return prop
+}
+
+// Positive requires a number to be greater than zero.
+func Positive(prop map[string]interface{}) map[string]interface{} {
+	prop["exclusiveMinimum"] = 0
+	return prop
+}
+
+// MinLength requires a string to have at least n characters.
+func MinLength(prop map[string]interface{}, n int) map[string]interface{} {
+	prop["minLength"] = n
+	return prop
+}
+
+// FieldOrder adds a cross-field rule to an object schema: when both are
+// given, lower must not be greater than upper. The rule is kept under
+// "x-field-order", which the registry doesn't send to the model, so say it
+// in the property descriptions too.
+func FieldOrder(schema map[string]interface{}, lower, upper string) map[string]interface{} {
+	rules, _ := schema["x-field-order"].([][2]string)
+	schema["x-field-order"] = append(rules, [2]string{lower, upper})
+	return schema
+}
+
+// APISchema returns a copy of schema with "required" as []interface{}, the
+// only form the SDK's registry passes on to the model. tools.ObjectSchema
+// builds it as []string, which the registry silently drops.
+func APISchema(schema map[string]interface{}) map[string]interface{} {
+	req, ok := schema["required"].([]string)
+	if !ok {
+		return schema
+	}
+	out := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		out[k] = v
+	}
+	list := make([]interface{}, len(req))
+	for i, r := range req {
+		list[i] = r
+	}
+	out["required"] = list
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// validation
+// ---------------------------------------------------------------------------
+
+// InputProblem is one thing wrong with a tool call's input.
+type InputProblem struct {
+	Field   string      `json:"field"`
+	Problem string      `json:"problem"`
+	Got     interface{} `json:"got,omitempty"`
+}
+
+// ValidationError is the error the model sees for invalid input.
+func ValidationError(tool string, problems []InputProblem) string {
+	raw, _ := json.Marshal(map[string]interface{}{
+		"error":    "invalid_input",
+		"tool":     tool,
+		"problems": problems,
+		"message":  fmt.Sprintf("Fix the fields listed in problems and call %s again.", tool),
+	})
+	return string(raw)
+}
+
+// ValidateInput checks raw tool input against an object schema.
+func ValidateInput(schema map[string]interface{}, raw json.RawMessage) []InputProblem {
+	var input map[string]interface{}
+	if len(raw) > 0 {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return []InputProblem{{Field: "", Problem: fmt.Sprintf("input is not valid JSON: %v", err)}}
+		}
+		obj, ok := v.(map[string]interface{})
+		if v != nil && !ok {
+			return []InputProblem{{Field: "", Problem: "input must be a JSON object", Got: v}}
+		}
+		input = obj
+	}
+	problems := validateObject("", schema, input)
+
+	rules, _ := schema["x-field-order"].([][2]string)
+	for _, r := range rules {
+		lo, loOK := input[r[0]].(float64)
+		hi, hiOK := input[r[1]].(float64)
+		if loOK && hiOK && lo > hi {
+			problems = append(problems, InputProblem{
+				Field:   r[1],
+				Problem: fmt.Sprintf("must not be less than %s (%s)", r[0], formatNumber(lo)),
+				Got:     hi,
+			})
+		}
+	}
+	return problems
+}
+
+func validateObject(path string, schema map[string]interface{}, obj map[string]interface{}) []InputProblem {
+	var problems []InputProblem
+	for _, name := range requiredProperties(schema) {
+		if v, ok := obj[name]; !ok || v == nil {
+			problems = append(problems, InputProblem{Field: joinPath(path, name), Problem: "is required"})
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, ok := props[name].(map[string]interface{})
+		// Unknown fields are let through; null means "not given".
+		if !ok || obj[name] == nil {
+			continue
+		}
+		problems = append(problems, validateValue(joinPath(path, name), prop, obj[name])...)
+	}
+	return problems
+}
+
+func validateValue(field string, prop map[string]interface{}, v interface{}) []InputProblem {
+	typ, _ := prop["type"].(string)
+	if typ != "" && !hasType(v, typ) {
+		return []InputProblem{{Field: field, Problem: "must be " + article(typ), Got: v}}
+	}
+
+	var problems []InputProblem
+	if allowed := enumValues(prop["enum"]); len(allowed) > 0 {
+		found := false
+		for _, a := range allowed {
+			if a == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, len(allowed))
+			for i, a := range allowed {
+				names[i] = fmt.Sprint(a)
+			}
+			problems = append(problems, InputProblem{Field: field, Problem: "must be one of: " + strings.Join(names, ", "), Got: v})
+		}
+	}
+
+	switch val := v.(type) {
+	case float64:
+		if min, ok := schemaNumber(prop["minimum"]); ok && val < min {
+			problems = append(problems, InputProblem{Field: field, Problem: "must be at least " + formatNumber(min), Got: val})
+		}
+		if min, ok := schemaNumber(prop["exclusiveMinimum"]); ok && val <= min {
+			problems = append(problems, InputProblem{Field: field, Problem: "must be greater than " + formatNumber(min), Got: val})
+		}
+		if max, ok := schemaNumber(prop["maximum"]); ok && val > max {
+			problems = append(problems, InputProblem{Field: field, Problem: "must be at most " + formatNumber(max), Got: val})
+		}
+	case string:
+		if n, ok := schemaNumber(prop["minLength"]); ok && float64(len([]rune(val))) < n {
+			if n == 1 {
+				problems = append(problems, InputProblem{Field: field, Problem: "must not be empty", Got: val})
+			} else {
+				problems = append(problems, InputProblem{Field: field, Problem: fmt.Sprintf("must be at least %s characters", formatNumber(n)), Got: val})
+			}
+		}
+	case []interface{}:
+		if items, ok := prop["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				problems = append(problems, validateValue(fmt.Sprintf("%s[%d]", field, i), items, item)...)
+			}
+		}
+	case map[string]interface{}:
+		problems = append(problems, validateObject(field, prop, val)...)
+	}
+	return problems
+}
+
+func hasType(v interface{}, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// enumValues reads "enum", which the SDK builds as []string.
+func enumValues(v interface{}) []interface{} {
+	switch e := v.(type) {
+	case []string:
+		out := make([]interface{}, len(e))
+		for i, s := range e {
+			out[i] = s
+		}
+		return out
+	case []interface{}:
+		return e
+	}
+	return nil
+}
+
+func schemaNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func formatNumber(n float64) string {
+	if n == math.Trunc(n) {
+		return fmt.Sprintf("%.0f", n)
+	}
+	return fmt.Sprintf("%g", n)
+}
+
+func article(typ string) string {
+	if typ == "integer" || typ == "object" || typ == "array" {
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// requiredProperties reads "required" from a schema, whichever slice type it
+// was built with.
+func requiredProperties(schema map[string]interface{}) []string {
+	switch req := schema["required"].(type) {
+	case []string:
+		return req
+	case []interface{}:
+		names := make([]string, 0, len(req))
+		for _, r := range req {
+			if s, ok := r.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
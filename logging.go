@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/becomeliminal/nim-go-sdk/store"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+// ============================================================================
+// LOGGING  –  structured, leveled logs with conversation/user/request IDs
+// ============================================================================
+// Everything logs through log/slog. setupLogging installs the default logger
+// from the log section of the config (see internal/config), usually set with:
+//
+//	LOG_LEVEL   debug | info | warn | error          (default: info)
+//	LOG_FORMAT  json | text                          (default: json)
+//	LOG_REDACT  none | secrets | pii | financial     (default: financial)
+//
+// Lines logged with a context carry conversation_id, user_id and request_id
+// when they're known. Each /ws connection gets a logScope that the
+// conversation store fills in; tool calls add their own user and request.
+//
+// Redaction applies to attribute keys at any depth, including JSON inputs
+// and results: "secrets" hides credentials, "pii" also hides contact and
+// account details, "financial" also hides amounts and recipients. Plain
+// log.Printf output (including the SDK's) goes through the same handler.
+
+type logConfig struct {
+	Level  slog.Level
+	Format string
+	Redact redactionLevel
+}
+
+// parseLogConfig reads the log section of the server config.
+func parseLogConfig(s config.LogSettings) (logConfig, error) {
+	level, format, redact := s.Level, s.Format, s.Redact
+	cfg := logConfig{Level: slog.LevelInfo, Format: "json", Redact: redactFinancial}
+
+	if level != "" {
+		if err := cfg.Level.UnmarshalText([]byte(level)); err != nil {
+			return cfg, fmt.Errorf("invalid level %q: use debug, info, warn or error", level)
+		}
+	}
+	if v := strings.ToLower(format); v != "" {
+		if v != "json" && v != "text" {
+			return cfg, fmt.Errorf("invalid format %q: use json or text", format)
+		}
+		cfg.Format = v
+	}
+	if redact != "" {
+		r, err := parseRedactionLevel(redact)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid redact: %w", err)
+		}
+		cfg.Redact = r
+	}
+	return cfg, nil
+}
+
+// setupLogging makes a logger for cfg the default.
+func setupLogging(cfg logConfig, w io.Writer) {
+	opts := &slog.HandlerOptions{
+		Level: cfg.Level,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			return redactAttr(a, cfg.Redact)
+		},
+	}
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+}
+
+// fatal logs at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// redactAttr hides the value of a sensitive attribute, and of sensitive
+// fields inside JSON values.
+func redactAttr(a slog.Attr, level redactionLevel) slog.Attr {
+	if a.Key == slog.MessageKey {
+		return slog.String(a.Key, redactMessage(a.Value.String(), level))
+	}
+	if isSensitiveKey(a.Key, level) {
+		return slog.String(a.Key, "[REDACTED]")
+	}
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	switch v := a.Value.Any().(type) {
+	case json.RawMessage:
+		return slog.Any(a.Key, json.RawMessage(redactJSONLevel(v, level)))
+	case map[string]interface{}, []interface{}:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return a
+		}
+		return slog.Any(a.Key, json.RawMessage(redactJSONLevel(raw, level)))
+	}
+	return a
+}
+
+// redactMessage hides the message text in the SDK's own log lines
+// ("[CONVERSATION id] USER: ...") at the financial level.
+func redactMessage(msg string, level redactionLevel) string {
+	if level < redactFinancial || !strings.HasPrefix(msg, "[CONVERSATION ") {
+		return msg
+	}
+	for _, role := range []string{"] USER: ", "] ASSISTANT: "} {
+		if i := strings.Index(msg, role); i >= 0 {
+			return msg[:i+len(role)] + "[REDACTED]"
+		}
+	}
+	return msg
+}
+
+// ---------------------------------------------------------------------------
+// correlation
+// ---------------------------------------------------------------------------
+
+type logScopeKey struct{}
+
+// logScope holds the IDs to stamp on log lines. A connection's scope is
+// filled in as its conversation starts; tool calls derive their own.
+type logScope struct {
+	mu             sync.Mutex
+	conversationID string
+	userID         string
+	requestID      string
+}
+
+func logScopeFrom(ctx context.Context) *logScope {
+	s, _ := ctx.Value(logScopeKey{}).(*logScope)
+	return s
+}
+
+func (s *logScope) set(conversationID, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if conversationID != "" {
+		s.conversationID = conversationID
+	}
+	if userID != "" {
+		s.userID = userID
+	}
+}
+
+func (s *logScope) attrs() []slog.Attr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var attrs []slog.Attr
+	if s.conversationID != "" {
+		attrs = append(attrs, slog.String("conversation_id", s.conversationID))
+	}
+	if s.userID != "" {
+		attrs = append(attrs, slog.String("user_id", s.userID))
+	}
+	if s.requestID != "" {
+		attrs = append(attrs, slog.String("request_id", s.requestID))
+	}
+	return attrs
+}
+
+// withLogIDs returns ctx with a scope that adds userID and requestID to the
+// IDs already in ctx.
+func withLogIDs(ctx context.Context, userID, requestID string) context.Context {
+	scope := &logScope{userID: userID, requestID: requestID}
+	if parent := logScopeFrom(ctx); parent != nil {
+		parent.mu.Lock()
+		scope.conversationID = parent.conversationID
+		if scope.userID == "" {
+			scope.userID = parent.userID
+		}
+		parent.mu.Unlock()
+	}
+	return context.WithValue(ctx, logScopeKey{}, scope)
+}
+
+// contextHandler adds the IDs from the context's logScope to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if scope := logScopeFrom(ctx); scope != nil {
+		r.AddAttrs(scope.attrs()...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// logSessions gives each /ws connection its own logScope.
+func logSessions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), logScopeKey{}, &logScope{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loggingConversations records the conversation and user on the
+// connection's logScope as conversations are started and resumed.
+type loggingConversations struct {
+	store.Conversations
+}
+
+func logConversations(next store.Conversations) store.Conversations {
+	return &loggingConversations{Conversations: next}
+}
+
+func (c *loggingConversations) Create(ctx context.Context, userID string) (*store.Conversation, error) {
+	conv, err := c.Conversations.Create(ctx, userID)
+	if scope := logScopeFrom(ctx); scope != nil && err == nil {
+		scope.set(conv.ID, userID)
+		slog.InfoContext(ctx, "conversation started")
+	}
+	return conv, err
+}
+
+func (c *loggingConversations) Get(ctx context.Context, conversationID string) (*store.ConversationWithMessages, error) {
+	conv, err := c.Conversations.Get(ctx, conversationID)
+	if scope := logScopeFrom(ctx); scope != nil && err == nil {
+		scope.set(conversationID, conv.UserID)
+	}
+	return conv, err
+}
+
+func (c *loggingConversations) Append(ctx context.Context, msg *store.AppendMessage) error {
+	if scope := logScopeFrom(ctx); scope != nil {
+		scope.set(msg.ConversationID, "")
+	}
+	err := c.Conversations.Append(ctx, msg)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store message", "role", msg.Role, "error", err)
+	} else {
+		slog.DebugContext(ctx, "message stored", "role", msg.Role, "content", msg.Content)
+	}
+	return err
+}
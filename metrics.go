@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ============================================================================
+// METRICS  –  Prometheus counters and histograms, served on /metrics
+// ============================================================================
+// Collected from:
+//   - tool calls (metricsMiddleware): count and duration by tool and outcome
+//   - executor calls (metricsExecutor): latency by tool, method and outcome
+//   - confirmations (metricsConfirmations): requested, confirmed, cancelled
+//     and expired
+//   - the /ws handler (countSessions): active WebSocket sessions
+//   - Anthropic API calls (anthropicMetrics): latency and token usage, read
+//     from the responses. These add up to the TokenUsage the server sends in
+//     "complete" messages.
+//   - the audit log (auditLog.record): entries it failed to write
+
+var (
+	toolCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "nim_tool_calls_total",
+		Help: "Tool calls by tool and outcome (ok, failed, error).",
+	}, []string{"tool", "outcome"})
+
+	toolDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "nim_tool_duration_seconds",
+		Help:    "Tool call duration, including executor calls.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"tool", "outcome"})
+
+	executorDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "nim_executor_duration_seconds",
+		Help:    "Executor call latency by tool, method (execute, execute_write, confirm, cancel) and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"tool", "method", "outcome"})
+
+	confirmationEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "nim_confirmations_total",
+		Help: "Confirmation cards by event (requested, confirmed, cancelled, expired).",
+	}, []string{"tool", "event"})
+
+	activeSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "nim_websocket_sessions_active",
+		Help: "Open WebSocket connections.",
+	})
+
+	modelCalls = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "nim_anthropic_request_duration_seconds",
+		Help:    "Anthropic API request duration (until the full response has been read) by status code.",
+		Buckets: []float64{0.25, 0.5, 1, 2, 5, 10, 20, 40, 80},
+	}, []string{"status"})
+
+	modelTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "nim_anthropic_tokens_total",
+		Help: "Anthropic tokens used by type (input, output, cache_creation_input, cache_read_input).",
+	}, []string{"type"})
+
+	auditWriteFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "nim_audit_write_failures_total",
+		Help: "Audit log entries that couldn't be written, by source (tool, executor, confirmation).",
+	}, []string{"source"})
+)
+
+// ---------------------------------------------------------------------------
+// tools
+// ---------------------------------------------------------------------------
+
+// metricsMiddleware counts tool calls and times them.
+func metricsMiddleware() toolMiddleware {
+	return func(tool core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, params)
+			outcome := "ok"
+			switch {
+			case err != nil:
+				outcome = "error"
+			case result == nil || !result.Success:
+				outcome = "failed"
+			}
+			toolCalls.WithLabelValues(tool.Name(), outcome).Inc()
+			toolDuration.WithLabelValues(tool.Name(), outcome).Observe(time.Since(start).Seconds())
+			return result, err
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// executor
+// ---------------------------------------------------------------------------
+
+// metricsExecutor times every call of the wrapped executor.
+type metricsExecutor struct {
+	next core.ToolExecutor
+}
+
+var _ core.ToolExecutor = (*metricsExecutor)(nil)
+
+func newMetricsExecutor(next core.ToolExecutor) *metricsExecutor {
+	return &metricsExecutor{next: next}
+}
+
+func observeExecutor(tool, method string, start time.Time, resp *core.ExecuteResponse, err error) {
+	outcome := "ok"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case resp != nil && resp.RequiresConfirmation:
+		outcome = "confirmation"
+	case resp == nil || !resp.Success:
+		outcome = "failed"
+	}
+	executorDuration.WithLabelValues(tool, method, outcome).Observe(time.Since(start).Seconds())
+}
+
+func (e *metricsExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	start := time.Now()
+	resp, err := e.next.Execute(ctx, req)
+	observeExecutor(req.Tool, "execute", start, resp, err)
+	return resp, err
+}
+
+func (e *metricsExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	start := time.Now()
+	resp, err := e.next.ExecuteWrite(ctx, req)
+	observeExecutor(req.Tool, "execute_write", start, resp, err)
+	return resp, err
+}
+
+func (e *metricsExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	start := time.Now()
+	resp, err := e.next.Confirm(ctx, userID, confirmationID)
+	observeExecutor("", "confirm", start, resp, err)
+	return resp, err
+}
+
+func (e *metricsExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	start := time.Now()
+	err := e.next.Cancel(ctx, userID, confirmationID)
+	observeExecutor("", "cancel", start, &core.ExecuteResponse{Success: err == nil}, err)
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// confirmations
+// ---------------------------------------------------------------------------
+
+// metricsConfirmations counts what happens to confirmation cards. A card
+// counts as expired when the user taps it too late or when Cleanup finds it
+// past its expiry, unanswered.
+type metricsConfirmations struct {
+	store.Confirmations
+
+	mu      sync.Mutex
+	pending map[string]*core.PendingAction
+}
+
+func countConfirmations(next store.Confirmations) *metricsConfirmations {
+	return &metricsConfirmations{Confirmations: next, pending: make(map[string]*core.PendingAction)}
+}
+
+func (m *metricsConfirmations) Store(ctx context.Context, action *core.PendingAction) error {
+	err := m.Confirmations.Store(ctx, action)
+	if err == nil {
+		m.mu.Lock()
+		m.pending[action.ID] = action
+		m.mu.Unlock()
+		confirmationEvents.WithLabelValues(action.Tool, "requested").Inc()
+	}
+	return err
+}
+
+// resolve forgets a card and returns its tool.
+func (m *metricsConfirmations) resolve(actionID string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tool string
+	if action, ok := m.pending[actionID]; ok {
+		tool = action.Tool
+	}
+	delete(m.pending, actionID)
+	return tool
+}
+
+func (m *metricsConfirmations) Confirm(ctx context.Context, userID, actionID string) (*core.PendingAction, error) {
+	action, err := m.Confirmations.Confirm(ctx, userID, actionID)
+	switch {
+	case err == nil:
+		m.resolve(actionID)
+		confirmationEvents.WithLabelValues(action.Tool, "confirmed").Inc()
+	case strings.Contains(err.Error(), "expired"):
+		confirmationEvents.WithLabelValues(m.resolve(actionID), "expired").Inc()
+	}
+	return action, err
+}
+
+func (m *metricsConfirmations) Cancel(ctx context.Context, userID, actionID string) error {
+	err := m.Confirmations.Cancel(ctx, userID, actionID)
+	if err == nil {
+		confirmationEvents.WithLabelValues(m.resolve(actionID), "cancelled").Inc()
+	}
+	return err
+}
+
+func (m *metricsConfirmations) Cleanup(ctx context.Context) (int, error) {
+	n, err := m.Confirmations.Cleanup(ctx)
+	now := time.Now().Unix()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, action := range m.pending {
+		if action.ExpiresAt < now {
+			confirmationEvents.WithLabelValues(action.Tool, "expired").Inc()
+			delete(m.pending, id)
+		}
+	}
+	return n, err
+}
+
+// sweepConfirmations runs Cleanup every interval until ctx is done.
+func sweepConfirmations(ctx context.Context, confirmations store.Confirmations, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := confirmations.Cleanup(ctx); err != nil {
+				slog.ErrorContext(ctx, "confirmations: cleanup failed", "error", err)
+			}
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// WebSocket sessions
+// ---------------------------------------------------------------------------
+
+// countSessions tracks open connections. The SDK's handler returns when the
+// connection closes.
+func countSessions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		activeSessions.Inc()
+		defer activeSessions.Dec()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Anthropic API
+// ---------------------------------------------------------------------------
+
+// anthropicUsage is the "usage" object of a Messages API response, or of a
+// message_start / message_delta stream event.
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+func (u anthropicUsage) record() {
+	modelTokens.WithLabelValues("input").Add(float64(u.InputTokens))
+	modelTokens.WithLabelValues("output").Add(float64(u.OutputTokens))
+	modelTokens.WithLabelValues("cache_creation_input").Add(float64(u.CacheCreationInputTokens))
+	modelTokens.WithLabelValues("cache_read_input").Add(float64(u.CacheReadInputTokens))
+}
+
+// anthropicMetrics is an Anthropic client middleware that times each API
+// request and counts the tokens it used.
+func anthropicMetrics() option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next(req)
+		if err != nil {
+			modelCalls.WithLabelValues("error").Observe(time.Since(start).Seconds())
+			return resp, err
+		}
+		resp.Body = &usageReader{
+			body:   resp.Body,
+			stream: strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"),
+			done: func(u anthropicUsage) {
+				modelCalls.WithLabelValues(strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
+				u.record()
+			},
+		}
+		return resp, nil
+	}
+}
+
+// usageReader passes a response body through and picks the token usage out
+// of it. Streams report input tokens in message_start and the running output
+// total in message_delta; plain responses have a top-level "usage".
+type usageReader struct {
+	body   io.ReadCloser
+	stream bool
+	done   func(anthropicUsage)
+
+	buf   bytes.Buffer
+	usage anthropicUsage
+	once  sync.Once
+}
+
+func (r *usageReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.buf.Write(p[:n])
+	if r.stream {
+		r.scanEvents()
+	}
+	if err != nil {
+		r.finish()
+	}
+	return n, err
+}
+
+func (r *usageReader) Close() error {
+	r.finish()
+	return r.body.Close()
+}
+
+// scanEvents consumes the complete lines in buf.
+func (r *usageReader) scanEvents() {
+	for {
+		line, err := r.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the partial line for the next read.
+			rest := append([]byte(nil), line...)
+			r.buf.Reset()
+			r.buf.Write(rest)
+			return
+		}
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		var event struct {
+			Type    string `json:"type"`
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Usage anthropicUsage `json:"usage"`
+		}
+		if json.Unmarshal(bytes.TrimSpace(data), &event) != nil {
+			continue
+		}
+		switch event.Type {
+		case "message_start":
+			r.usage = event.Message.Usage
+		case "message_delta":
+			r.usage.OutputTokens = event.Usage.OutputTokens
+		}
+	}
+}
+
+func (r *usageReader) finish() {
+	r.once.Do(func() {
+		if !r.stream {
+			var body struct {
+				Usage anthropicUsage `json:"usage"`
+			}
+			if json.Unmarshal(r.buf.Bytes(), &body) == nil {
+				r.usage = body.Usage
+			}
+		}
+		r.done(r.usage)
+	})
+}
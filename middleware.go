@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// TOOL MIDDLEWARE  –  cross-cutting behaviour composed around tool handlers
+// ============================================================================
+// A toolMiddleware wraps the handler of one tool. applyMiddleware composes a
+// chain around every tool's Execute, so the same behaviour applies to the
+// mock, live and custom tools alike. The first middleware in the list is the
+// outermost.
+
+type toolMiddleware func(tool core.Tool, next core.ToolHandler) core.ToolHandler
+
+// middlewareTool is a tool whose Execute goes through a middleware chain.
+type middlewareTool struct {
+	core.Tool
+	handler core.ToolHandler
+}
+
+// Schema is the wrapped tool's schema in the form the registry expects.
+func (t *middlewareTool) Schema() map[string]interface{} {
+	return toolkit.APISchema(t.Tool.Schema())
+}
+
+func (t *middlewareTool) Execute(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+	return t.handler(ctx, params)
+}
+
+// applyMiddleware wraps every tool in list with the chain.
+func applyMiddleware(list []core.Tool, chain ...toolMiddleware) []core.Tool {
+	wrapped := make([]core.Tool, len(list))
+	for i, t := range list {
+		handler := core.ToolHandler(t.Execute)
+		for j := len(chain) - 1; j >= 0; j-- {
+			handler = chain[j](t, handler)
+		}
+		wrapped[i] = &middlewareTool{Tool: t, handler: handler}
+	}
+	return wrapped
+}
+
+// ---------------------------------------------------------------------------
+// middleware
+// ---------------------------------------------------------------------------
+
+// loggingMiddleware logs one line per tool call with its outcome and
+// duration (and the input at debug level). The user and request IDs are
+// added to the context, so everything logged further down carries them.
+func loggingMiddleware() toolMiddleware {
+	return func(tool core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			ctx = withLogIDs(ctx, params.UserID, params.RequestID)
+			slog.DebugContext(ctx, "tool call started", "tool", tool.Name(), "input", params.Input)
+
+			start := time.Now()
+			result, err := next(ctx, params)
+			attrs := []any{"tool", tool.Name(), "duration_ms", time.Since(start).Milliseconds()}
+			switch {
+			case err != nil:
+				slog.ErrorContext(ctx, "tool call errored", append(attrs, "error", err)...)
+			case result == nil:
+				slog.ErrorContext(ctx, "tool call returned no result", attrs...)
+			case !result.Success:
+				slog.WarnContext(ctx, "tool call failed", append(attrs, "error", result.Error)...)
+			default:
+				slog.InfoContext(ctx, "tool call succeeded", attrs...)
+			}
+			return result, err
+		}
+	}
+}
+
+// timingMiddleware reports how long the tool took in the result metadata.
+func timingMiddleware() toolMiddleware {
+	return func(_ core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, params)
+			if result != nil {
+				if result.Metadata == nil {
+					result.Metadata = make(map[string]interface{})
+				}
+				result.Metadata["duration_ms"] = time.Since(start).Milliseconds()
+			}
+			return result, err
+		}
+	}
+}
+
+// recoverMiddleware turns a panicking handler into a failed result instead
+// of taking the connection down.
+func recoverMiddleware() toolMiddleware {
+	return func(tool core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (result *core.ToolResult, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.ErrorContext(ctx, "tool panicked", "tool", tool.Name(), "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+					result = toolkit.Error("internal error in %s", tool.Name())
+					err = nil
+				}
+			}()
+			return next(ctx, params)
+		}
+	}
+}
+
+// timeoutMiddleware bounds each call. perTool overrides the default by tool
+// name; a zero timeout means no limit. The handler keeps running in the
+// background after a timeout, but its context is cancelled, so executor
+// calls are abandoned.
+func timeoutMiddleware(defaultTimeout time.Duration, perTool map[string]time.Duration) toolMiddleware {
+	return func(tool core.Tool, next core.ToolHandler) core.ToolHandler {
+		timeout := defaultTimeout
+		if t, ok := perTool[tool.Name()]; ok {
+			timeout = t
+		}
+		if timeout <= 0 {
+			return next
+		}
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			type outcome struct {
+				result *core.ToolResult
+				err    error
+			}
+			done := make(chan outcome, 1)
+			go func() {
+				result, err := next(ctx, params)
+				done <- outcome{result, err}
+			}()
+
+			select {
+			case o := <-done:
+				return o.result, o.err
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					msg := fmt.Sprintf("%s timed out after %s", tool.Name(), timeout)
+					if tool.RequiresConfirmation() {
+						msg += "; it may still have gone through, so check before retrying"
+					}
+					return toolkit.Error("%s", msg), nil
+				}
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
+
+// validationMiddleware rejects input that doesn't match the tool's schema
+// (see internal/toolkit) before the handler sees it.
+func validationMiddleware() toolMiddleware {
+	return func(tool core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			if problems := toolkit.ValidateInput(tool.Schema(), params.Input); len(problems) > 0 {
+				return &core.ToolResult{
+					Success: false,
+					Error:   toolkit.ValidationError(tool.Name(), problems),
+				}, nil
+			}
+			return next(ctx, params)
+		}
+	}
+}
+
+// redactionMiddleware strips values of sensitive fields from tool results
+// before they reach the model.
+func redactionMiddleware(level redactionLevel) toolMiddleware {
+	return func(_ core.Tool, next core.ToolHandler) core.ToolHandler {
+		if level == redactNone {
+			return next
+		}
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			result, err := next(ctx, params)
+			if result == nil || result.Data == nil {
+				return result, err
+			}
+			var raw json.RawMessage
+			switch data := result.Data.(type) {
+			case json.RawMessage:
+				raw = data
+			case []byte:
+				raw = data
+			default:
+				encoded, marshalErr := json.Marshal(data)
+				if marshalErr != nil {
+					return result, err
+				}
+				raw = encoded
+			}
+			// Decoded rather than raw, so the SDK can still read the
+			// "message" it shows after a confirmation.
+			var data interface{}
+			if json.Unmarshal(redactJSONLevel(raw, level), &data) == nil {
+				result.Data = data
+			}
+			return result, err
+		}
+	}
+}
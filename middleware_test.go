@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+)
+
+// traceCalls is a middleware that records when the call enters and leaves it.
+func traceCalls(name string, trace *[]string) toolMiddleware {
+	return func(_ core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			*trace = append(*trace, name+">")
+			result, err := next(ctx, params)
+			*trace = append(*trace, "<"+name)
+			return result, err
+		}
+	}
+}
+
+// The first middleware in the chain is the outermost.
+func TestApplyMiddlewareOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		chain []string
+		want  []string
+	}{
+		{"no middleware", nil, []string{"handler"}},
+		{"one", []string{"a"}, []string{"a>", "handler", "<a"}},
+		{"three", []string{"a", "b", "c"}, []string{"a>", "b>", "c>", "handler", "<c", "<b", "<a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var trace []string
+			tool := tools.New("probe").
+				Schema(tools.ObjectSchema(map[string]interface{}{})).
+				Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					trace = append(trace, "handler")
+					return &core.ToolResult{Success: true}, nil
+				}).
+				Build()
+			var chain []toolMiddleware
+			for _, name := range tt.chain {
+				chain = append(chain, traceCalls(name, &trace))
+			}
+
+			wrapped := applyMiddleware([]core.Tool{tool}, chain...)[0]
+			if _, err := wrapped.Execute(context.Background(), &core.ToolParams{Input: json.RawMessage(`{}`)}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(trace, tt.want) {
+				t.Errorf("trace = %v, want %v", trace, tt.want)
+			}
+		})
+	}
+}
+
+// The chain main.go builds, cut down to the parts that decide the result:
+// recovery sits inside the timeout and validation runs before the handler.
+func TestMiddlewareOutcomes(t *testing.T) {
+	tests := []struct {
+		name        string
+		write       bool
+		input       string
+		timeout     time.Duration
+		handler     func() (*core.ToolResult, error)
+		wantSuccess bool
+		wantError   string
+		wantCalled  bool
+	}{
+		{
+			name:        "passes through",
+			input:       `{"amount": 5}`,
+			timeout:     time.Second,
+			handler:     func() (*core.ToolResult, error) { return &core.ToolResult{Success: true}, nil },
+			wantSuccess: true,
+			wantCalled:  true,
+		},
+		{
+			name:      "invalid input never reaches the handler",
+			input:     `{"amount": "lots"}`,
+			timeout:   time.Second,
+			handler:   func() (*core.ToolResult, error) { return &core.ToolResult{Success: true}, nil },
+			wantError: "probe",
+		},
+		{
+			name:       "panic becomes a failed result",
+			input:      `{"amount": 5}`,
+			timeout:    time.Second,
+			handler:    func() (*core.ToolResult, error) { panic("boom") },
+			wantError:  "internal error in probe",
+			wantCalled: true,
+		},
+		{
+			name:    "read timeout",
+			input:   `{"amount": 5}`,
+			timeout: 10 * time.Millisecond,
+			handler: func() (*core.ToolResult, error) {
+				time.Sleep(100 * time.Millisecond)
+				return &core.ToolResult{Success: true}, nil
+			},
+			wantError:  "probe timed out after 10ms",
+			wantCalled: true,
+		},
+		{
+			name:    "write timeout warns it may have gone through",
+			write:   true,
+			input:   `{"amount": 5}`,
+			timeout: 10 * time.Millisecond,
+			handler: func() (*core.ToolResult, error) {
+				time.Sleep(100 * time.Millisecond)
+				return &core.ToolResult{Success: true}, nil
+			},
+			wantError:  "may still have gone through",
+			wantCalled: true,
+		},
+		{
+			name:    "zero timeout means no limit",
+			input:   `{"amount": 5}`,
+			timeout: 0,
+			handler: func() (*core.ToolResult, error) {
+				time.Sleep(20 * time.Millisecond)
+				return &core.ToolResult{Success: true}, nil
+			},
+			wantSuccess: true,
+			wantCalled:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := make(chan struct{}, 1)
+			builder := tools.New("probe").
+				Schema(tools.ObjectSchema(map[string]interface{}{
+					"amount": tools.NumberProperty("Amount"),
+				}, "amount")).
+				Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+					called <- struct{}{}
+					return tt.handler()
+				})
+			if tt.write {
+				builder = builder.RequiresConfirmation()
+			}
+
+			wrapped := applyMiddleware([]core.Tool{builder.Build()},
+				timingMiddleware(),
+				timeoutMiddleware(tt.timeout, nil),
+				recoverMiddleware(),
+				validationMiddleware(),
+			)[0]
+			result, err := wrapped.Execute(context.Background(), &core.ToolParams{Input: json.RawMessage(tt.input)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Success != tt.wantSuccess || !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("result = %+v, want success %v and an error containing %q", result, tt.wantSuccess, tt.wantError)
+			}
+			if _, ok := result.Metadata["duration_ms"]; !ok {
+				t.Errorf("metadata = %v, want duration_ms", result.Metadata)
+			}
+			if gotCalled := len(called) > 0; gotCalled != tt.wantCalled {
+				t.Errorf("handler called = %v, want %v", gotCalled, tt.wantCalled)
+			}
+		})
+	}
+}
@@ -0,0 +1,534 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// CUSTOM TOOLS: NET WORTH
+// ============================================================================
+// get_net_worth adds up everything the agent knows the user has and owes:
+// the Liminal wallet, each savings vault position, the latest statement
+// balance of each imported account (see imports.go), and assets and
+// liabilities the user tracks by hand, like a house or a car loan.
+//
+// Every call records the day's total, and in mock mode a background job
+// records one a day for each user with history, so the tool can say how
+// net worth changed over a week, month, quarter or year. Totals are in one
+// currency (default USD); anything in another currency is listed under
+// other_currencies and left out, since nothing is converted.
+
+const (
+	// netWorthHistoryYears of daily snapshots are kept.
+	netWorthHistoryYears = 3
+
+	// netWorthHistoryPoints caps the history the tool returns; longer
+	// periods are thinned evenly.
+	netWorthHistoryPoints = 32
+)
+
+// netWorthItem is an asset or liability the user tracks by hand. Value is
+// never negative; liabilities are subtracted.
+type netWorthItem struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"` // asset | liability
+	Name      string    `json:"name"`
+	Value     float64   `json:"value"`
+	Currency  string    `json:"currency"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// netWorthSnapshot is one day's total in one currency.
+type netWorthSnapshot struct {
+	Date        string  `json:"date"` // YYYY-MM-DD
+	Currency    string  `json:"currency"`
+	Assets      float64 `json:"assets"`
+	Liabilities float64 `json:"liabilities"`
+	NetWorth    float64 `json:"net_worth"`
+}
+
+// netWorthLine is one thing counted toward net worth.
+type netWorthLine struct {
+	Kind     string  `json:"kind"`   // asset | liability
+	Source   string  `json:"source"` // wallet | savings | imported | manual
+	Name     string  `json:"name"`
+	Value    float64 `json:"value"`
+	Currency string  `json:"currency"`
+	AsOf     string  `json:"as_of,omitempty"` // imported balances
+	ID       string  `json:"id,omitempty"`    // manual items
+}
+
+type netWorthSummary struct {
+	Currency         string         `json:"currency"`
+	NetWorth         float64        `json:"net_worth"`
+	TotalAssets      float64        `json:"total_assets"`
+	TotalLiabilities float64        `json:"total_liabilities"`
+	Assets           []netWorthLine `json:"assets"`
+	Liabilities      []netWorthLine `json:"liabilities"`
+	OtherCurrencies  []netWorthLine `json:"other_currencies,omitempty"`
+	NoBalance        []string       `json:"imported_without_balance,omitempty"`
+}
+
+type netWorthTracker struct {
+	exec    core.ToolExecutor
+	imports *importStore
+	items   *jsonStore[[]netWorthItem]
+	history *jsonStore[[]netWorthSnapshot]
+}
+
+func newNetWorthTracker(dataDir string, exec core.ToolExecutor, imports *importStore) (*netWorthTracker, error) {
+	items, err := newJSONStore[[]netWorthItem](filepath.Join(dataDir, "net_worth_items.json"))
+	if err != nil {
+		return nil, err
+	}
+	history, err := newJSONStore[[]netWorthSnapshot](filepath.Join(dataDir, "net_worth_history.json"))
+	if err != nil {
+		return nil, err
+	}
+	return &netWorthTracker{exec: exec, imports: imports, items: items, history: history}, nil
+}
+
+// compute reads the live balances and adds the stored ones.
+func (t *netWorthTracker) compute(ctx context.Context, toolParams *core.ToolParams, currency string) (netWorthSummary, error) {
+	wallet, err := analytics.ExecuteRead(ctx, t.exec, toolParams, "get_balance", nil)
+	if err != nil {
+		return netWorthSummary{}, fmt.Errorf("failed to fetch wallet balance: %w", err)
+	}
+	savings, err := analytics.ExecuteRead(ctx, t.exec, toolParams, "get_savings_balance", nil)
+	if err != nil {
+		return netWorthSummary{}, fmt.Errorf("failed to fetch savings balance: %w", err)
+	}
+
+	lines := append(walletLines(wallet), savingsLines(savings)...)
+	var noBalance []string
+	for _, acct := range t.imports.Get(toolParams.UserID) {
+		if acct.Balance == nil {
+			noBalance = append(noBalance, acct.Name)
+			continue
+		}
+		kind := "asset"
+		if acct.Balance.Amount < 0 {
+			kind = "liability"
+		}
+		lines = append(lines, netWorthLine{
+			Kind:     kind,
+			Source:   "imported",
+			Name:     acct.Name,
+			Value:    math.Abs(acct.Balance.Amount),
+			Currency: acct.Balance.Currency,
+			AsOf:     acct.Balance.AsOf,
+		})
+	}
+	for _, item := range t.items.Get(toolParams.UserID) {
+		lines = append(lines, netWorthLine{Kind: item.Kind, Source: "manual", Name: item.Name, Value: item.Value, Currency: item.Currency, ID: item.ID})
+	}
+
+	summary := summarizeNetWorth(lines, currency)
+	summary.NoBalance = noBalance
+	return summary, nil
+}
+
+// summarizeNetWorth totals the lines in currency.
+func summarizeNetWorth(lines []netWorthLine, currency string) netWorthSummary {
+	s := netWorthSummary{Currency: currency, Assets: []netWorthLine{}, Liabilities: []netWorthLine{}}
+	for _, line := range lines {
+		line.Value = analytics.RoundMoney(line.Value)
+		switch {
+		case !strings.EqualFold(line.Currency, currency):
+			s.OtherCurrencies = append(s.OtherCurrencies, line)
+		case line.Kind == "liability":
+			s.Liabilities = append(s.Liabilities, line)
+			s.TotalLiabilities += line.Value
+		default:
+			s.Assets = append(s.Assets, line)
+			s.TotalAssets += line.Value
+		}
+	}
+	s.TotalAssets = analytics.RoundMoney(s.TotalAssets)
+	s.TotalLiabilities = analytics.RoundMoney(s.TotalLiabilities)
+	s.NetWorth = analytics.RoundMoney(s.TotalAssets - s.TotalLiabilities)
+	return s
+}
+
+// walletLines lists the wallet's balance in each currency get_balance
+// reports.
+func walletLines(balance map[string]interface{}) []netWorthLine {
+	var currencies []string
+	if _, ok := balance["balance"]; ok {
+		c, _ := balance["currency"].(string)
+		if c == "" {
+			c = "USD"
+		}
+		currencies = append(currencies, c)
+	}
+	list, _ := balance["balances"].([]interface{})
+	for _, b := range list {
+		entry, _ := b.(map[string]interface{})
+		if c, _ := entry["currency"].(string); c != "" && !slices.ContainsFunc(currencies, func(s string) bool { return strings.EqualFold(s, c) }) {
+			currencies = append(currencies, c)
+		}
+	}
+
+	var lines []netWorthLine
+	for _, c := range currencies {
+		lines = append(lines, netWorthLine{Kind: "asset", Source: "wallet", Name: "Liminal wallet", Value: walletBalance(balance, c), Currency: strings.ToUpper(c)})
+	}
+	return lines
+}
+
+// savingsLines lists each savings position, or the total when there are no
+// positions.
+func savingsLines(savings map[string]interface{}) []netWorthLine {
+	topCurrency, _ := savings["currency"].(string)
+	if topCurrency == "" {
+		topCurrency = "USD"
+	}
+	positions, _ := savings["positions"].([]interface{})
+	if len(positions) == 0 {
+		if _, ok := savings["balance"]; !ok {
+			return nil
+		}
+		return []netWorthLine{{Kind: "asset", Source: "savings", Name: "Liminal savings", Value: analytics.ToFloat(savings["balance"]), Currency: strings.ToUpper(topCurrency)}}
+	}
+
+	var lines []netWorthLine
+	for _, p := range positions {
+		pos, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		currency, _ := pos["currency"].(string)
+		if currency == "" {
+			currency = topCurrency
+		}
+		name, _ := pos["vault_id"].(string)
+		if name == "" {
+			name = strings.ToUpper(currency) + " savings vault"
+		}
+		lines = append(lines, netWorthLine{Kind: "asset", Source: "savings", Name: name, Value: positionValue(pos), Currency: strings.ToUpper(currency)})
+	}
+	return lines
+}
+
+// record stores summary as the day's snapshot, replacing any earlier one
+// from the same day, and drops snapshots older than netWorthHistoryYears.
+func (t *netWorthTracker) record(userID string, summary netWorthSummary, now time.Time) error {
+	snapshot := netWorthSnapshot{
+		Date:        now.Format("2006-01-02"),
+		Currency:    summary.Currency,
+		Assets:      summary.TotalAssets,
+		Liabilities: summary.TotalLiabilities,
+		NetWorth:    summary.NetWorth,
+	}
+	cutoff := now.AddDate(-netWorthHistoryYears, 0, 0).Format("2006-01-02")
+	return t.history.Update(userID, func(history *[]netWorthSnapshot) error {
+		kept := (*history)[:0]
+		for _, s := range *history {
+			if s.Date >= cutoff && (s.Date != snapshot.Date || s.Currency != snapshot.Currency) {
+				kept = append(kept, s)
+			}
+		}
+		kept = append(kept, snapshot)
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].Date < kept[j].Date })
+		*history = kept
+		return nil
+	})
+}
+
+// Run records a snapshot for every user with history, in each currency
+// they have history in, once a day, until ctx is done.
+func (t *netWorthTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		t.recordDue(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *netWorthTracker) recordDue(ctx context.Context, now time.Time) {
+	today := now.Format("2006-01-02")
+	for _, userID := range t.history.Users() {
+		recorded := make(map[string]bool)
+		for _, s := range t.history.Get(userID) {
+			recorded[s.Currency] = recorded[s.Currency] || s.Date == today
+		}
+		for currency, done := range recorded {
+			if done {
+				continue
+			}
+			summary, err := t.compute(ctx, &core.ToolParams{UserID: userID}, currency)
+			if err == nil {
+				err = t.record(userID, summary, now)
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "net worth: failed to record snapshot", "user_id", userID, "currency", currency, "error", err)
+			}
+		}
+	}
+}
+
+// periodStart is the first day of the calendar period containing now;
+// weeks start on Monday. "all" has no start.
+func periodStart(period string, now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "quarter":
+		return time.Date(day.Year(), (day.Month()-1)/3*3+1, 1, 0, 0, 0, 0, day.Location())
+	case "year":
+		return time.Date(day.Year(), 1, 1, 0, 0, 0, 0, day.Location())
+	case "all":
+		return time.Time{}
+	default:
+		return analytics.StartOfMonth(day)
+	}
+}
+
+// netWorthChange compares today's snapshot with where the period started:
+// the last snapshot before its first day or, when history doesn't go back
+// that far, the earliest since. history is sorted by date and in one
+// currency; it ends with today's snapshot.
+func netWorthChange(history []netWorthSnapshot, period string, now time.Time) map[string]interface{} {
+	today := now.Format("2006-01-02")
+	result := map[string]interface{}{"period": period}
+	base := -1
+	if period != "all" {
+		start := periodStart(period, now).Format("2006-01-02")
+		result["period_start"] = start
+		for i, s := range history {
+			if s.Date < start {
+				base = i
+			}
+		}
+	}
+	if base < 0 && len(history) > 0 && history[0].Date != today {
+		base = 0
+		if period != "all" {
+			result["partial"] = true
+			result["note"] = "history only goes back to " + history[0].Date + ", so the change is since then"
+		}
+	}
+	if base < 0 {
+		result["note"] = "there's no history before today yet; a snapshot is recorded every day from now on"
+		return result
+	}
+
+	from, to := history[base], history[len(history)-1]
+	change := analytics.RoundMoney(to.NetWorth - from.NetWorth)
+	result["since"] = from.Date
+	result["from"] = from.NetWorth
+	result["to"] = to.NetWorth
+	result["change"] = change
+	if from.NetWorth != 0 {
+		result["change_pct"] = math.Round(change/math.Abs(from.NetWorth)*1000) / 10
+	}
+	result["history"] = thinHistory(history[base:], netWorthHistoryPoints)
+	return result
+}
+
+// thinHistory keeps at most max snapshots, evenly spaced, always keeping the
+// first and the last.
+func thinHistory(history []netWorthSnapshot, max int) []map[string]interface{} {
+	var points []map[string]interface{}
+	step := 1.0
+	if len(history) > max {
+		step = float64(len(history)-1) / float64(max-1)
+	}
+	last := -1
+	for f := 0.0; ; f += step {
+		i := int(math.Round(f))
+		if i >= len(history) {
+			i = len(history) - 1
+		}
+		if i != last {
+			points = append(points, map[string]interface{}{"date": history[i].Date, "net_worth": history[i].NetWorth})
+			last = i
+		}
+		if i == len(history)-1 {
+			return points
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// tools
+// ---------------------------------------------------------------------------
+
+func createNetWorthTools(tracker *netWorthTracker) []core.Tool {
+	return []core.Tool{
+		createGetNetWorthTool(tracker),
+		createSetNetWorthItemTool(tracker),
+		createRemoveNetWorthItemTool(tracker),
+	}
+}
+
+func createGetNetWorthTool(tracker *netWorthTracker) core.Tool {
+	return tools.New("get_net_worth").
+		Description("Get the user's net worth: the Liminal wallet, every savings vault position, the latest balance of each imported account, and assets and liabilities they track by hand, itemized. Also returns how it changed this week, month, quarter or year (from daily snapshots) for questions like 'how has my net worth changed this quarter?'.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"period":   tools.StringEnumProperty("Calendar period to report the change over (default: month)", "week", "month", "quarter", "year", "all"),
+			"currency": tools.StringProperty("Currency code (default: USD)"),
+		})).
+		Handler(toolkit.TypedHandler(func(ctx context.Context, toolParams *core.ToolParams, params struct {
+			Period   string `json:"period"`
+			Currency string `json:"currency"`
+		}) (*core.ToolResult, error) {
+			if params.Period == "" {
+				params.Period = "month"
+			}
+			params.Currency = strings.ToUpper(strings.TrimSpace(params.Currency))
+			if params.Currency == "" {
+				params.Currency = "USD"
+			}
+
+			summary, err := tracker.compute(ctx, toolParams, params.Currency)
+			if err != nil {
+				return toolkit.Error("%v", err), nil
+			}
+			now := time.Now()
+			if err := tracker.record(toolParams.UserID, summary, now); err != nil {
+				return toolkit.Error("failed to record net worth: %v", err), nil
+			}
+			var history []netWorthSnapshot
+			for _, s := range tracker.history.Get(toolParams.UserID) {
+				if s.Currency == params.Currency {
+					history = append(history, s)
+				}
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"net_worth": summary,
+					"change":    netWorthChange(history, params.Period, now),
+					"as_of":     now.Format(time.RFC3339),
+				},
+			}, nil
+		})).
+		Build()
+}
+
+func createSetNetWorthItemTool(tracker *netWorthTracker) core.Tool {
+	return tools.New("set_net_worth_item").
+		Description("Add or update an asset or liability the user tracks by hand for get_net_worth, e.g. 'my house is worth $350k' or 'I owe $12,000 on my car loan'. An item with the same kind and name is updated rather than duplicated.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"kind":     tools.StringEnumProperty("asset (something owned) or liability (something owed)", "asset", "liability"),
+			"name":     toolkit.MinLength(tools.StringProperty("Short name (e.g. 'House', 'Car loan')"), 1),
+			"value":    toolkit.Minimum(tools.NumberProperty("Current value, or amount owed for a liability"), 0),
+			"currency": tools.StringProperty("Currency code (default: USD)"),
+		}, "kind", "name", "value")).
+		Handler(toolkit.TypedHandler(func(_ context.Context, toolParams *core.ToolParams, params struct {
+			Kind     string  `json:"kind"`
+			Name     string  `json:"name"`
+			Value    float64 `json:"value"`
+			Currency string  `json:"currency"`
+		}) (*core.ToolResult, error) {
+			name := strings.Join(strings.Fields(params.Name), " ")
+			currency := strings.ToUpper(strings.TrimSpace(params.Currency))
+			if currency == "" {
+				currency = "USD"
+			}
+			if params.Kind != "asset" && params.Kind != "liability" {
+				return toolkit.Error("kind must be asset or liability"), nil
+			}
+			if name == "" || params.Value < 0 {
+				return toolkit.Error("name is required and value cannot be negative"), nil
+			}
+
+			var saved netWorthItem
+			updated := false
+			err := tracker.items.Update(toolParams.UserID, func(items *[]netWorthItem) error {
+				i := slices.IndexFunc(*items, func(it netWorthItem) bool {
+					return it.Kind == params.Kind && strings.EqualFold(it.Name, name)
+				})
+				if i < 0 {
+					*items = append(*items, netWorthItem{ID: toolkit.NewID("nw"), Kind: params.Kind})
+					i = len(*items) - 1
+				} else {
+					updated = true
+				}
+				item := &(*items)[i]
+				item.Name = name
+				item.Value = analytics.RoundMoney(params.Value)
+				item.Currency = currency
+				item.UpdatedAt = time.Now()
+				saved = *item
+				return nil
+			})
+			if err != nil {
+				return toolkit.Error("failed to save item: %v", err), nil
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"item":    saved,
+					"updated": updated,
+				},
+			}, nil
+		})).
+		Build()
+}
+
+func createRemoveNetWorthItemTool(tracker *netWorthTracker) core.Tool {
+	return tools.New("remove_net_worth_item").
+		Description("Stop tracking an asset or liability the user added with set_net_worth_item, e.g. after selling the car or paying off a loan.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"name": toolkit.MinLength(tools.StringProperty("Name or ID of the item, as get_net_worth shows it"), 1),
+			"kind": tools.StringEnumProperty("asset or liability, when both have the same name", "asset", "liability"),
+		}, "name")).
+		Handler(toolkit.TypedHandler(func(_ context.Context, toolParams *core.ToolParams, params struct {
+			Name string `json:"name"`
+			Kind string `json:"kind"`
+		}) (*core.ToolResult, error) {
+			name := strings.Join(strings.Fields(params.Name), " ")
+			var removed netWorthItem
+			err := tracker.items.Update(toolParams.UserID, func(items *[]netWorthItem) error {
+				var matches []int
+				for i, it := range *items {
+					if (it.ID == name || strings.EqualFold(it.Name, name)) && (params.Kind == "" || it.Kind == params.Kind) {
+						matches = append(matches, i)
+					}
+				}
+				switch len(matches) {
+				case 0:
+					return fmt.Errorf("no tracked item is called %q", params.Name)
+				case 1:
+				default:
+					return fmt.Errorf("both an asset and a liability are called %q; say which kind", params.Name)
+				}
+				removed = (*items)[matches[0]]
+				*items = slices.Delete(*items, matches[0], matches[0]+1)
+				return nil
+			})
+			if err != nil {
+				return toolkit.Error("failed to remove item: %v", err), nil
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"removed": removed,
+				},
+			}, nil
+		})).
+		Build()
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/mock"
+)
+
+// Both the mock's balance shapes and the live API's are read.
+func TestNetWorthLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance string
+		savings string
+		want    []string // "source name value currency"
+	}{
+		{
+			name:    "mock",
+			balance: `{"balance": 5000, "currency": "USD"}`,
+			savings: `{"balance": 1200.5, "currency": "USD", "apy": 4.5, "positions": [{"vault_id": "vault_usd_1", "balance": 1200.5, "apy": 4.5}]}`,
+			want:    []string{"wallet Liminal wallet 5000.00 USD", "savings vault_usd_1 1200.50 USD"},
+		},
+		{
+			name:    "live",
+			balance: `{"balances": [{"currency": "USD", "amount": "250.10", "usdValue": "250.10"}, {"currency": "EUR", "amount": "40"}], "totalUsd": "293.50"}`,
+			savings: `{"positions": [{"currency": "USD", "deposited": "1000", "currentValue": "1012.34", "apy": "4.5"}], "totalUsd": "1012.34"}`,
+			want:    []string{"wallet Liminal wallet 250.10 USD", "wallet Liminal wallet 40.00 EUR", "savings USD savings vault 1012.34 USD"},
+		},
+		{
+			name:    "no positions",
+			balance: `{}`,
+			savings: `{"balance": 80}`,
+			want:    []string{"savings Liminal savings 80.00 USD"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var balance, savings map[string]interface{}
+			json.Unmarshal([]byte(tt.balance), &balance)
+			json.Unmarshal([]byte(tt.savings), &savings)
+			var got []string
+			for _, line := range append(walletLines(balance), savingsLines(savings)...) {
+				got = append(got, fmt.Sprintf("%s %s %.2f %s", line.Source, line.Name, line.Value, line.Currency))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("lines =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSummarizeNetWorth(t *testing.T) {
+	s := summarizeNetWorth([]netWorthLine{
+		{Kind: "asset", Source: "wallet", Name: "Liminal wallet", Value: 1000.004, Currency: "USD"},
+		{Kind: "asset", Source: "manual", Name: "House", Value: 300000, Currency: "usd"},
+		{Kind: "liability", Source: "imported", Name: "Visa", Value: 512.3, Currency: "USD"},
+		{Kind: "liability", Source: "manual", Name: "Mortgage", Value: 250000, Currency: "USD"},
+		{Kind: "asset", Source: "wallet", Name: "Liminal wallet", Value: 40, Currency: "EUR"},
+	}, "USD")
+	if s.TotalAssets != 301000 || s.TotalLiabilities != 250512.3 || s.NetWorth != 50487.7 {
+		t.Errorf("totals = %v - %v = %v, want 301000 - 250512.3 = 50487.7", s.TotalAssets, s.TotalLiabilities, s.NetWorth)
+	}
+	if len(s.Assets) != 2 || len(s.Liabilities) != 2 || len(s.OtherCurrencies) != 1 || s.OtherCurrencies[0].Currency != "EUR" {
+		t.Errorf("summary = %+v, want the EUR wallet under other_currencies", s)
+	}
+}
+
+func TestNetWorthChange(t *testing.T) {
+	now := time.Date(2025, 5, 14, 15, 0, 0, 0, time.UTC) // a Wednesday
+	history := []netWorthSnapshot{
+		{Date: "2025-03-31", NetWorth: 1000},
+		{Date: "2025-04-30", NetWorth: 1100},
+		{Date: "2025-05-11", NetWorth: 1180},
+		{Date: "2025-05-13", NetWorth: 1190},
+		{Date: "2025-05-14", NetWorth: 1210},
+	}
+	tests := []struct {
+		period  string
+		history []netWorthSnapshot
+		since   string
+		change  float64
+		partial bool
+	}{
+		{period: "week", history: history, since: "2025-05-11", change: 30},
+		{period: "month", history: history, since: "2025-04-30", change: 110},
+		{period: "quarter", history: history, since: "2025-03-31", change: 210},
+		{period: "year", history: history, since: "2025-03-31", change: 210, partial: true},
+		{period: "all", history: history, since: "2025-03-31", change: 210},
+		{period: "month", history: history[len(history)-1:]},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.period, len(tt.history)), func(t *testing.T) {
+			got := netWorthChange(tt.history, tt.period, now)
+			if tt.since == "" {
+				if got["since"] != nil || got["note"] == nil {
+					t.Errorf("change = %v, want only a note", got)
+				}
+				return
+			}
+			if got["since"] != tt.since || got["change"] != tt.change || (got["partial"] == true) != tt.partial {
+				t.Errorf("change = %v, want since %s, change %v, partial %v", got, tt.since, tt.change, tt.partial)
+			}
+		})
+	}
+}
+
+func TestThinHistory(t *testing.T) {
+	var history []netWorthSnapshot
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 365; i++ {
+		history = append(history, netWorthSnapshot{Date: day.AddDate(0, 0, i).Format("2006-01-02"), NetWorth: float64(i)})
+	}
+	points := thinHistory(history, 32)
+	if len(points) != 32 || points[0]["date"] != "2025-01-01" || points[31]["date"] != "2025-12-31" {
+		t.Errorf("got %d points from %v to %v, want 32 from the first day to the last", len(points), points[0]["date"], points[len(points)-1]["date"])
+	}
+	if points := thinHistory(history[:3], 32); len(points) != 3 {
+		t.Errorf("short history thinned to %d points, want all 3", len(points))
+	}
+}
+
+// The tool adds hand-tracked items and imported balances to the mock
+// wallet and savings, and records the day's snapshot; the background job
+// records one for users who haven't had one today.
+func TestNetWorthTool(t *testing.T) {
+	captureLogs(t)
+	dir := t.TempDir()
+	exec := mock.NewExecutor()
+	imports, err := newImportStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := newNetWorthTracker(dir, exec, imports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := make(map[string]core.Tool)
+	for _, tool := range createNetWorthTools(tracker) {
+		tools[tool.Name()] = tool
+	}
+	call := func(name, input string) map[string]interface{} {
+		t.Helper()
+		result, err := tools[name].Execute(context.Background(), &core.ToolParams{UserID: "u1", Input: json.RawMessage(input)})
+		if err != nil || !result.Success {
+			t.Fatalf("%s(%s): %v %+v", name, input, err, result)
+		}
+		raw, _ := json.Marshal(result.Data)
+		var data map[string]interface{}
+		json.Unmarshal(raw, &data)
+		return data
+	}
+
+	call("set_net_worth_item", `{"kind": "asset", "name": "House", "value": 300000}`)
+	call("set_net_worth_item", `{"kind": "liability", "name": "Car loan", "value": 15000}`)
+	if data := call("set_net_worth_item", `{"kind": "liability", "name": "car  LOAN", "value": 12000}`); data["updated"] != true {
+		t.Errorf("second car loan wasn't an update: %v", data)
+	}
+	call("set_net_worth_item", `{"kind": "asset", "name": "Flat in Lisbon", "value": 200000, "currency": "eur"}`)
+	mergeImport(imports, "u1", "Visa", "ofx", nil, &accountBalance{Amount: -500, Currency: "USD", AsOf: "2025-01-31"})
+	mergeImport(imports, "u1", "Old Bank", "csv", nil, nil)
+
+	params := &core.ToolParams{UserID: "u1"}
+	wallet, _ := analytics.ExecuteRead(context.Background(), exec, params, "get_balance", nil)
+	savings, _ := analytics.ExecuteRead(context.Background(), exec, params, "get_savings_balance", nil)
+	want := analytics.RoundMoney(walletBalance(wallet, "USD") + savingsBalance(savings, "USD") + 300000 - 12000 - 500)
+
+	data := call("get_net_worth", `{"period": "quarter"}`)
+	summary := data["net_worth"].(map[string]interface{})
+	if summary["net_worth"] != want || len(summary["liabilities"].([]interface{})) != 2 {
+		t.Errorf("net worth = %v, want %v with two liabilities: %v", summary["net_worth"], want, summary)
+	}
+	if other := summary["other_currencies"].([]interface{}); len(other) != 1 {
+		t.Errorf("other_currencies = %v, want the EUR flat", other)
+	}
+	if fmt.Sprint(summary["imported_without_balance"]) != "[Old Bank]" {
+		t.Errorf("imported_without_balance = %v", summary["imported_without_balance"])
+	}
+	if change := data["change"].(map[string]interface{}); change["note"] == nil {
+		t.Errorf("first call's change = %v, want a note that there's no history yet", change)
+	}
+
+	call("remove_net_worth_item", `{"name": "car loan"}`)
+	if data := call("get_net_worth", `{}`); data["net_worth"].(map[string]interface{})["net_worth"] != analytics.RoundMoney(want+12000) {
+		t.Errorf("after removing the car loan: %v", data["net_worth"])
+	}
+	if history := tracker.history.Get("u1"); len(history) != 1 {
+		t.Errorf("history = %+v, want one snapshot for today", history)
+	}
+
+	// Yesterday's snapshot is due again today; a user without history isn't
+	// snapshotted at all.
+	tracker.history.Update("u2", func(h *[]netWorthSnapshot) error {
+		*h = []netWorthSnapshot{{Date: time.Now().AddDate(0, 0, -1).Format("2006-01-02"), Currency: "USD", NetWorth: 1}}
+		return nil
+	})
+	tracker.recordDue(context.Background(), time.Now())
+	if history := tracker.history.Get("u2"); len(history) != 2 || history[1].Date != time.Now().Format("2006-01-02") {
+		t.Errorf("u2 history = %+v, want today's snapshot added", history)
+	}
+	if users := tracker.history.Users(); len(users) != 2 {
+		t.Errorf("users with history = %v, want u1 and u2", users)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+// ============================================================================
+// TRUSTED PAYEES  –  recipient allowlist with a new-payee cooling-off period
+// ============================================================================
+// The first time a user's payees are needed, the list is seeded from the
+// counterparty (or recipient) of past "send" transactions. The mock's
+// history names none, so in mock mode every payee starts untrusted. Paying
+// anyone else escalates in the spending policy until the user trusts them
+// with add_trusted_payee (a confirmed action). Payees added that way are
+// capped for a cooling-off period (new_payee_cooling_hours / new_payee_cap
+// in the spending limits); payees seeded from history are trusted straight
+// away.
+
+type trustedPayee struct {
+	Recipient string    `json:"recipient"`
+	Note      string    `json:"note,omitempty"`
+	Source    string    `json:"source"` // history | manual
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// coolingUntil is when the payee stops being capped as a new payee.
+func (p trustedPayee) coolingUntil(limits config.SpendingLimits) time.Time {
+	if p.Source == "history" {
+		return time.Time{}
+	}
+	return p.AddedAt.Add(time.Duration(limits.NewPayeeCoolingHours) * time.Hour)
+}
+
+type payeeBook struct {
+	Seeded bool           `json:"seeded"`
+	Payees []trustedPayee `json:"payees,omitempty"`
+}
+
+func (b payeeBook) find(recipient string) (trustedPayee, bool) {
+	for _, p := range b.Payees {
+		if normalizeRecipient(p.Recipient) == normalizeRecipient(recipient) {
+			return p, true
+		}
+	}
+	return trustedPayee{}, false
+}
+
+type payeeStore = jsonStore[payeeBook]
+
+// seedPayees fills the user's trusted payees from their send history the
+// first time they're needed. On failure nothing is marked seeded, so every
+// recipient is treated as new and seeding is retried next time.
+func (p *spendingPolicy) seedPayees(ctx context.Context, toolParams *core.ToolParams) {
+	if p.payees.Get(toolParams.UserID).Seeded {
+		return
+	}
+
+	transactions, err := analytics.FetchTransactions(ctx, p.exec, toolParams, map[string]interface{}{"limit": 500})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to seed trusted payees", "user_id", toolParams.UserID, "error", err)
+		return
+	}
+
+	now := time.Now()
+	err = p.payees.Update(toolParams.UserID, func(b *payeeBook) error {
+		if b.Seeded {
+			return nil
+		}
+		for _, tx := range transactions {
+			txType, _ := tx["type"].(string)
+			recipient := analytics.TxCounterparty(tx)
+			if txType != "send" || recipient == "" {
+				continue
+			}
+			if _, ok := b.find(recipient); ok {
+				continue
+			}
+			b.Payees = append(b.Payees, trustedPayee{Recipient: recipient, Source: "history", AddedAt: now})
+		}
+		b.Seeded = true
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to save trusted payees", "user_id", toolParams.UserID, "error", err)
+	}
+}
+
+func createTrustedPayeeTools(policy *spendingPolicy) []core.Tool {
+	return []core.Tool{
+		createListTrustedPayeesTool(policy),
+		createAddTrustedPayeeTool(policy),
+		createRemoveTrustedPayeeTool(policy),
+	}
+}
+
+// sentSince totals the payments to recipient since a point in time, by
+// currency.
+func sentSince(ledger []policyEntry, recipient string, since time.Time) map[string]float64 {
+	sent := make(map[string]float64)
+	for _, e := range ledger {
+		if e.Tool == "send_money" && !e.At.Before(since) && normalizeRecipient(e.Recipient) == normalizeRecipient(recipient) {
+			sent[e.Currency] += e.Amount
+		}
+	}
+	return sent
+}
+
+func createListTrustedPayeesTool(policy *spendingPolicy) core.Tool {
+	return tools.New("list_trusted_payees").
+		Description("List the recipients the user trusts for send_money, including payees still in their new-payee cooling-off period and how much can still be sent to them. The new-payee cap applies in each currency separately; remaining_new_payee_allowance lists the currencies already used, and any other currency has the full new_payee_cap.").
+		Schema(tools.ObjectSchema(map[string]interface{}{})).
+		Handler(func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			policy.seedPayees(ctx, toolParams)
+
+			now := time.Now()
+			limits := policy.Limits(toolParams.UserID)
+			ledger := policy.state.Get(toolParams.UserID).Ledger
+			payees := slices.Clone(policy.payees.Get(toolParams.UserID).Payees)
+			sort.SliceStable(payees, func(i, j int) bool {
+				return strings.ToLower(payees[i].Recipient) < strings.ToLower(payees[j].Recipient)
+			})
+
+			list := make([]map[string]interface{}, 0, len(payees))
+			for _, p := range payees {
+				entry := map[string]interface{}{
+					"recipient": p.Recipient,
+					"source":    p.Source,
+					"added_at":  p.AddedAt.Format(time.RFC3339),
+				}
+				if p.Note != "" {
+					entry["note"] = p.Note
+				}
+				if until := p.coolingUntil(limits); limits.NewPayeeCap > 0 && now.Before(until) {
+					// The cap applies in each currency separately, as in
+					// Evaluate; currencies not listed are untouched.
+					remaining := make(map[string]float64)
+					for currency, sent := range sentSince(ledger, p.Recipient, p.AddedAt) {
+						remaining[currency] = analytics.RoundMoney(limits.NewPayeeCap - sent)
+					}
+					entry["cooling_off_until"] = until.Format(time.RFC3339)
+					entry["new_payee_cap"] = limits.NewPayeeCap
+					entry["remaining_new_payee_allowance"] = remaining
+				}
+				list = append(list, entry)
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"payees": list,
+					"count":  len(list),
+				},
+			}, nil
+		}).
+		Build()
+}
+
+func createAddTrustedPayeeTool(policy *spendingPolicy) core.Tool {
+	tool := tools.New("add_trusted_payee").
+		Description("Add a recipient to the user's trusted payees so send_money can pay them. Needed before the first payment to someone new; the new payee is capped for a cooling-off period. Requires confirmation.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"recipient": tools.StringProperty("Recipient's display tag (e.g. @alice)"),
+			"note":      tools.StringProperty("Optional note, e.g. who this is"),
+		}, "recipient")).
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				Recipient string `json:"recipient"`
+				Note      string `json:"note"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+			params.Recipient = strings.TrimSpace(params.Recipient)
+			if params.Recipient == "" {
+				return &core.ToolResult{Success: false, Error: "recipient is required"}, nil
+			}
+
+			// Seed first so a later seed can't overwrite this with the
+			// history entry (or vice versa).
+			policy.seedPayees(ctx, toolParams)
+
+			payee := trustedPayee{
+				Recipient: params.Recipient,
+				Note:      strings.TrimSpace(params.Note),
+				Source:    "manual",
+				AddedAt:   time.Now(),
+			}
+			err := policy.payees.Update(toolParams.UserID, func(b *payeeBook) error {
+				if existing, ok := b.find(payee.Recipient); ok {
+					payee = existing
+					return nil
+				}
+				b.Payees = append(b.Payees, payee)
+				return nil
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to save payee: %v", err),
+				}, nil
+			}
+
+			data := map[string]interface{}{
+				"message":   fmt.Sprintf("%s is now a trusted payee.", payee.Recipient),
+				"recipient": payee.Recipient,
+				"source":    payee.Source,
+			}
+			limits := policy.Limits(toolParams.UserID)
+			if until := payee.coolingUntil(limits); limits.NewPayeeCap > 0 && time.Now().Before(until) {
+				data["cooling_off_until"] = until.Format(time.RFC3339)
+				data["new_payee_cap"] = limits.NewPayeeCap
+			}
+			return &core.ToolResult{Success: true, Data: data}, nil
+		}).
+		Build()
+
+	return &summaryTool{Tool: tool, summary: func(input json.RawMessage) string {
+		var params struct {
+			Recipient string `json:"recipient"`
+		}
+		_ = json.Unmarshal(input, &params)
+		return fmt.Sprintf("Trust new payee %s", strings.TrimSpace(params.Recipient))
+	}}
+}
+
+func createRemoveTrustedPayeeTool(policy *spendingPolicy) core.Tool {
+	return tools.New("remove_trusted_payee").
+		Description("Remove a recipient from the user's trusted payees. Future payments to them will need to be re-approved.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"recipient": tools.StringProperty("Recipient's display tag (e.g. @alice)"),
+		}, "recipient")).
+		Handler(func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				Recipient string `json:"recipient"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			// Seed first, otherwise a payee from history would come back the
+			// next time the list is needed.
+			policy.seedPayees(ctx, toolParams)
+
+			var removed trustedPayee
+			err := policy.payees.Update(toolParams.UserID, func(b *payeeBook) error {
+				var kept []trustedPayee
+				for _, p := range b.Payees {
+					if normalizeRecipient(p.Recipient) == normalizeRecipient(params.Recipient) {
+						removed = p
+						continue
+					}
+					kept = append(kept, p)
+				}
+				if removed.Recipient == "" {
+					return fmt.Errorf("%s isn't a trusted payee", params.Recipient)
+				}
+				b.Payees = kept
+				return nil
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to remove payee: %v", err),
+				}, nil
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"message":   fmt.Sprintf("%s is no longer a trusted payee.", removed.Recipient),
+					"recipient": removed.Recipient,
+				},
+			}, nil
+		}).
+		Build()
+}
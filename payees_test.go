@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+// A new payee's remaining allowance is reported per currency, as the cap is
+// enforced.
+func TestListTrustedPayeesAllowance(t *testing.T) {
+	policy, payees := newTestPolicy(t, nil, config.DefaultSpendingLimits())
+	now := time.Now()
+	payees.Update("alice", func(b *payeeBook) error {
+		b.Seeded = true
+		b.Payees = []trustedPayee{
+			{Recipient: "@bob", Source: "manual", AddedAt: now.Add(-time.Hour)},
+			{Recipient: "@carol", Source: "history", AddedAt: now.AddDate(0, -1, 0)},
+		}
+		return nil
+	})
+	for _, m := range []moneyMovement{
+		{Tool: "send_money", Recipient: "@bob", Amount: 100, Currency: "USD"},
+		{Tool: "send_money", Recipient: "@bob", Amount: 30, Currency: "EUR"},
+		{Tool: "send_money", Recipient: "@carol", Amount: 500, Currency: "USD"},
+	} {
+		if err := policy.Record("alice", m, policyDecision{}, "", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := createListTrustedPayeesTool(policy).Execute(context.Background(), &core.ToolParams{UserID: "alice", Input: json.RawMessage(`{}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("list: %+v %v", result, err)
+	}
+	raw, _ := json.Marshal(result.Data)
+	var data struct {
+		Payees []struct {
+			Recipient string             `json:"recipient"`
+			Cap       float64            `json:"new_payee_cap"`
+			Remaining map[string]float64 `json:"remaining_new_payee_allowance"`
+		} `json:"payees"`
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Payees) != 2 {
+		t.Fatalf("payees = %s", raw)
+	}
+	bob, carol := data.Payees[0], data.Payees[1]
+	if bob.Cap != 250 || bob.Remaining["USD"] != 150 || bob.Remaining["EUR"] != 220 || len(bob.Remaining) != 2 {
+		t.Errorf("bob = %+v, want 150 USD and 220 EUR left of 250", bob)
+	}
+	if carol.Remaining != nil {
+		t.Errorf("carol = %+v, want no allowance after the cooling-off", carol)
+	}
+}
@@ -0,0 +1,738 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// SPENDING POLICY  –  limits and velocity checks around money movement
+// ============================================================================
+// The policy wraps send_money and withdraw_savings and runs before the
+// executor is called. Hard caps (per transaction, daily, weekly, per
+// recipient) reject outright. Softer signals (large amounts, rapid repeat
+// payments to the same recipient, the same payment twice in a few minutes)
+// escalate: the model has to get the user to
+// approve the payment through approve_flagged_payment, which goes through the
+// normal confirmation card, before retrying.
+//
+// Limits apply per tool and per currency over rolling windows, and only count
+// money moved through this agent. Payments to recipients outside the user's
+// trusted payees (see payees.go) escalate too, and newly trusted payees are
+// capped for a cooling-off period.
+
+// policedTools are the write tools the policy applies to.
+var policedTools = map[string]bool{
+	"send_money":       true,
+	"withdraw_savings": true,
+}
+
+const (
+	policyLedgerRetention = 8 * 24 * time.Hour
+	overrideTTL           = 10 * time.Minute
+)
+
+// policyState is everything the policy keeps per user.
+type policyState struct {
+	Limits    *config.SpendingLimits `json:"limits,omitempty"` // nil means defaults
+	Ledger    []policyEntry          `json:"ledger,omitempty"`
+	Overrides []policyOverride       `json:"overrides,omitempty"`
+}
+
+// policyEntry is a money movement that went through the policy.
+type policyEntry struct {
+	Tool      string    `json:"tool"`
+	Recipient string    `json:"recipient,omitempty"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	At        time.Time `json:"at"`
+
+	// RequestKey identifies the request that made the movement, so a retry
+	// of the same request is passed through to the executor (which replays
+	// it) rather than counted or flagged as a duplicate.
+	RequestKey string `json:"request_key,omitempty"`
+}
+
+// policyOverride is a one-time user approval for an escalated payment.
+type policyOverride struct {
+	ID        string    `json:"id"`
+	Tool      string    `json:"tool"`
+	Recipient string    `json:"recipient,omitempty"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (o policyOverride) covers(m moneyMovement, now time.Time) bool {
+	return o.Tool == m.Tool &&
+		normalizeRecipient(o.Recipient) == m.Recipient &&
+		o.Currency == m.Currency &&
+		analytics.RoundMoney(o.Amount) == analytics.RoundMoney(m.Amount) &&
+		now.Before(o.ExpiresAt)
+}
+
+// moneyMovement is the policy-relevant part of a write tool's input.
+// Recipient is normalized (see normalizeRecipient).
+type moneyMovement struct {
+	Tool      string
+	Recipient string
+	Amount    float64
+	Currency  string
+}
+
+// normalizeRecipient gives every spelling of a recipient one form. Display
+// tags are case-insensitive and the "@" is optional, so "@Bob", "bob" and
+// "@bob" are all "@bob".
+func normalizeRecipient(recipient string) string {
+	r := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(recipient)), "@")
+	if r == "" {
+		return ""
+	}
+	return "@" + r
+}
+
+func parseMoneyMovement(tool string, input json.RawMessage) (moneyMovement, error) {
+	var p struct {
+		Recipient string             `json:"recipient"`
+		Amount    toolkit.FlexAmount `json:"amount"`
+		Currency  string             `json:"currency"`
+	}
+	if err := json.Unmarshal(input, &p); err != nil {
+		return moneyMovement{}, err
+	}
+	m := moneyMovement{
+		Tool:      tool,
+		Recipient: normalizeRecipient(p.Recipient),
+		Amount:    float64(p.Amount),
+		Currency:  strings.ToUpper(p.Currency),
+	}
+	if m.Currency == "" {
+		m.Currency = "USD"
+	}
+	return m, nil
+}
+
+func (m moneyMovement) String() string {
+	if m.Tool == "send_money" {
+		return fmt.Sprintf("Send %.2f %s to %s", m.Amount, m.Currency, m.Recipient)
+	}
+	if m.Tool == "withdraw_savings" {
+		return fmt.Sprintf("Withdraw %.2f %s from savings", m.Amount, m.Currency)
+	}
+	if m.Tool == "deposit_savings" {
+		return fmt.Sprintf("Deposit %.2f %s into savings", m.Amount, m.Currency)
+	}
+	return fmt.Sprintf("%s %.2f %s", m.Tool, m.Amount, m.Currency)
+}
+
+type policyAction string
+
+const (
+	policyAllow    policyAction = "allow"
+	policyReject   policyAction = "reject"
+	policyEscalate policyAction = "escalate"
+)
+
+type policyDecision struct {
+	Action  policyAction `json:"action"`
+	Reasons []string     `json:"reasons,omitempty"`
+
+	// NewPayee is set when the escalation is (also) because the recipient
+	// isn't trusted yet. That's cleared by add_trusted_payee, not by a
+	// one-time override.
+	NewPayee bool `json:"new_payee,omitempty"`
+
+	// overrideID is set when an escalation was cleared by a user approval;
+	// it's consumed once the payment succeeds.
+	overrideID string
+}
+
+type spendingPolicy struct {
+	exec     core.ToolExecutor
+	state    *jsonStore[policyState]
+	payees   *payeeStore
+	defaults config.SpendingLimits // for users who haven't set their own
+
+	mu    sync.Mutex
+	users map[string]*sync.Mutex // see lock
+}
+
+func newSpendingPolicy(exec core.ToolExecutor, state *jsonStore[policyState], payees *payeeStore, defaults config.SpendingLimits) *spendingPolicy {
+	return &spendingPolicy{exec: exec, state: state, payees: payees, defaults: defaults, users: make(map[string]*sync.Mutex)}
+}
+
+// lock serialises the user's payments from Evaluate to Record, so two
+// concurrent payments can't both fit under a limit only one of them fits
+// under. It returns the unlock function.
+func (p *spendingPolicy) lock(userID string) func() {
+	p.mu.Lock()
+	l, ok := p.users[userID]
+	if !ok {
+		l = new(sync.Mutex)
+		p.users[userID] = l
+	}
+	p.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// Limits returns the user's effective limits.
+func (p *spendingPolicy) Limits(userID string) config.SpendingLimits {
+	if l := p.state.Get(userID).Limits; l != nil {
+		return *l
+	}
+	return p.defaults
+}
+
+// usage sums ledger entries for one tool and currency since a point in time,
+// optionally for a single recipient, and counts them.
+func usage(ledger []policyEntry, m moneyMovement, since time.Time, sameRecipient bool) (float64, int) {
+	var total float64
+	var count int
+	for _, e := range ledger {
+		if e.Tool != m.Tool || e.Currency != m.Currency || e.At.Before(since) {
+			continue
+		}
+		if sameRecipient && normalizeRecipient(e.Recipient) != m.Recipient {
+			continue
+		}
+		total += e.Amount
+		count++
+	}
+	return total, count
+}
+
+// Evaluate decides whether a money movement may proceed. It doesn't change
+// any state.
+func (p *spendingPolicy) Evaluate(userID string, m moneyMovement, now time.Time) policyDecision {
+	if !policedTools[m.Tool] {
+		return policyDecision{Action: policyAllow}
+	}
+	state := p.state.Get(userID)
+	limits := p.Limits(userID)
+
+	var hard, soft []string
+	var newPayee bool
+	if limits.PerTransaction > 0 && m.Amount > limits.PerTransaction {
+		hard = append(hard, fmt.Sprintf("%.2f %s is above the per-transaction limit of %.2f", m.Amount, m.Currency, limits.PerTransaction))
+	}
+	if day, _ := usage(state.Ledger, m, now.Add(-24*time.Hour), false); limits.Daily > 0 && day+m.Amount > limits.Daily {
+		hard = append(hard, fmt.Sprintf("this would bring the last 24 hours to %.2f %s, above the daily limit of %.2f", day+m.Amount, m.Currency, limits.Daily))
+	}
+	if week, _ := usage(state.Ledger, m, now.Add(-7*24*time.Hour), false); limits.Weekly > 0 && week+m.Amount > limits.Weekly {
+		hard = append(hard, fmt.Sprintf("this would bring the last 7 days to %.2f %s, above the weekly limit of %.2f", week+m.Amount, m.Currency, limits.Weekly))
+	}
+	if m.Tool == "send_money" {
+		if toRecipient, _ := usage(state.Ledger, m, now.Add(-24*time.Hour), true); limits.PerRecipientDaily > 0 && toRecipient+m.Amount > limits.PerRecipientDaily {
+			hard = append(hard, fmt.Sprintf("this would bring payments to %s in the last 24 hours to %.2f %s, above the per-recipient limit of %.2f", m.Recipient, toRecipient+m.Amount, m.Currency, limits.PerRecipientDaily))
+		}
+		window := time.Duration(limits.RapidRepeatWindowMinutes) * time.Minute
+		if _, recent := usage(state.Ledger, m, now.Add(-window), true); limits.RapidRepeatCount > 0 && recent >= limits.RapidRepeatCount {
+			soft = append(soft, fmt.Sprintf("%d payments to %s in the last %d minutes", recent, m.Recipient, limits.RapidRepeatWindowMinutes))
+		}
+
+		payee, trusted := p.payees.Get(userID).find(m.Recipient)
+		switch {
+		case !trusted:
+			newPayee = true
+			soft = append(soft, fmt.Sprintf("%s isn't a trusted payee yet", m.Recipient))
+		case limits.NewPayeeCap > 0 && now.Before(payee.coolingUntil(limits)):
+			sent, _ := usage(state.Ledger, m, payee.AddedAt, true)
+			if sent+m.Amount > limits.NewPayeeCap {
+				hard = append(hard, fmt.Sprintf("%s is a new payee: payments are capped at %.2f %s in total until %s (%.2f already sent)",
+					m.Recipient, limits.NewPayeeCap, m.Currency, payee.coolingUntil(limits).Format("Jan 2 15:04"), sent))
+			}
+		}
+	}
+	if dup, ok := findDuplicate(state.Ledger, m, now, limits.DuplicateWindowMinutes); ok {
+		reason := fmt.Sprintf("an identical payment (%s) was made %s ago", m, now.Sub(dup.At).Round(time.Second))
+		if limits.BlockDuplicates {
+			hard = append(hard, reason)
+		} else {
+			soft = append(soft, reason)
+		}
+	}
+	if limits.EscalateAbove > 0 && m.Amount > limits.EscalateAbove {
+		soft = append(soft, fmt.Sprintf("%.2f %s is above the %.2f approval threshold", m.Amount, m.Currency, limits.EscalateAbove))
+	}
+
+	if len(hard) > 0 {
+		return policyDecision{Action: policyReject, Reasons: hard}
+	}
+	if newPayee {
+		return policyDecision{Action: policyEscalate, Reasons: soft, NewPayee: true}
+	}
+	if len(soft) > 0 {
+		for _, o := range state.Overrides {
+			if o.covers(m, now) {
+				return policyDecision{Action: policyAllow, Reasons: soft, overrideID: o.ID}
+			}
+		}
+		return policyDecision{Action: policyEscalate, Reasons: soft}
+	}
+	return policyDecision{Action: policyAllow}
+}
+
+// findDuplicate returns the most recent ledger entry with the same tool,
+// recipient, amount and currency within the window. Retries of the same
+// request never get this far (see idempotency.go); this catches the same
+// payment being asked for twice.
+func findDuplicate(ledger []policyEntry, m moneyMovement, now time.Time, windowMinutes int) (policyEntry, bool) {
+	if windowMinutes <= 0 {
+		return policyEntry{}, false
+	}
+	since := now.Add(-time.Duration(windowMinutes) * time.Minute)
+	for i := len(ledger) - 1; i >= 0; i-- {
+		e := ledger[i]
+		if e.At.Before(since) {
+			break
+		}
+		if e.Tool == m.Tool && normalizeRecipient(e.Recipient) == m.Recipient &&
+			e.Currency == m.Currency && analytics.RoundMoney(e.Amount) == analytics.RoundMoney(m.Amount) {
+			return e, true
+		}
+	}
+	return policyEntry{}, false
+}
+
+// policyRequestKey identifies a request for the ledger by its call ID (see
+// idempotency.go); requests without one don't get a key.
+func policyRequestKey(requestID string, input json.RawMessage) string {
+	if requestID == "" {
+		return ""
+	}
+	_, canonical := canonicalInput(input)
+	return hashKey(requestID, canonical)
+}
+
+// Recorded reports whether the request already went through the policy.
+func (p *spendingPolicy) Recorded(userID, requestKey string) bool {
+	if requestKey == "" {
+		return false
+	}
+	for _, e := range p.state.Get(userID).Ledger {
+		if e.RequestKey == requestKey {
+			return true
+		}
+	}
+	return false
+}
+
+// Record adds a completed movement to the ledger and consumes the override
+// that cleared it, if any.
+func (p *spendingPolicy) Record(userID string, m moneyMovement, d policyDecision, requestKey string, now time.Time) error {
+	return p.state.Update(userID, func(s *policyState) error {
+		kept := s.Ledger[:0]
+		for _, e := range s.Ledger {
+			if now.Sub(e.At) < policyLedgerRetention {
+				kept = append(kept, e)
+			}
+		}
+		s.Ledger = append(kept, policyEntry{
+			Tool:       m.Tool,
+			Recipient:  m.Recipient,
+			Amount:     m.Amount,
+			Currency:   m.Currency,
+			At:         now,
+			RequestKey: requestKey,
+		})
+		s.Overrides = pruneOverrides(s.Overrides, d.overrideID, now)
+		return nil
+	})
+}
+
+// Approve stores a one-time override for an escalated movement.
+func (p *spendingPolicy) Approve(userID string, m moneyMovement, now time.Time) (policyOverride, error) {
+	o := policyOverride{
+		ID:        toolkit.NewID("ovr"),
+		Tool:      m.Tool,
+		Recipient: m.Recipient,
+		Amount:    m.Amount,
+		Currency:  m.Currency,
+		ExpiresAt: now.Add(overrideTTL),
+	}
+	err := p.state.Update(userID, func(s *policyState) error {
+		s.Overrides = append(pruneOverrides(s.Overrides, "", now), o)
+		return nil
+	})
+	return o, err
+}
+
+func pruneOverrides(overrides []policyOverride, consumed string, now time.Time) []policyOverride {
+	var kept []policyOverride
+	for _, o := range overrides {
+		if o.ID != consumed && now.Before(o.ExpiresAt) {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// escalationMessage tells the model how to get a flagged payment approved.
+func escalationMessage(m moneyMovement, d policyDecision, limits config.SpendingLimits) string {
+	if d.NewPayee {
+		msg := fmt.Sprintf("needs extra approval (%s). This is the first payment to %s: check with the user that the recipient is right, and if so call add_trusted_payee for them, then call %s again.",
+			strings.Join(d.Reasons, "; "), m.Recipient, m.Tool)
+		if limits.NewPayeeCap > 0 && limits.NewPayeeCoolingHours > 0 {
+			msg += fmt.Sprintf(" Payments to a new payee are capped at %.2f in total for the first %d hours.", limits.NewPayeeCap, limits.NewPayeeCoolingHours)
+		}
+		return msg
+	}
+	return fmt.Sprintf("needs extra approval (%s). Explain this to the user; if they want to go ahead, call approve_flagged_payment with the same tool, recipient, amount and currency, then call %s again.",
+		strings.Join(d.Reasons, "; "), m.Tool)
+}
+
+// ---------------------------------------------------------------------------
+// tool wrapper
+// ---------------------------------------------------------------------------
+
+// policyTool enforces the spending policy around a write tool.
+type policyTool struct {
+	core.Tool
+	policy *spendingPolicy
+}
+
+// withSpendingPolicy wraps the policed tools in list and returns the rest
+// unchanged.
+func withSpendingPolicy(policy *spendingPolicy, list []core.Tool) []core.Tool {
+	wrapped := make([]core.Tool, len(list))
+	for i, t := range list {
+		if policedTools[t.Name()] {
+			t = &policyTool{Tool: t, policy: policy}
+		}
+		wrapped[i] = t
+	}
+	return wrapped
+}
+
+func (t *policyTool) Execute(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+	m, err := parseMoneyMovement(t.Name(), params.Input)
+	if err != nil {
+		return &core.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("invalid input: %v", err),
+		}, nil
+	}
+
+	defer t.policy.lock(params.UserID)()
+
+	// A retry of a request that already went through only needs the
+	// executor's replay.
+	requestKey := policyRequestKey(callID(ctx), params.Input)
+	if t.policy.Recorded(params.UserID, requestKey) {
+		return t.Tool.Execute(ctx, params)
+	}
+
+	t.policy.seedPayees(ctx, params)
+
+	now := time.Now()
+	d := t.policy.Evaluate(params.UserID, m, now)
+	switch d.Action {
+	case policyReject:
+		return &core.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("blocked by spending policy: %s", strings.Join(d.Reasons, "; ")),
+		}, nil
+	case policyEscalate:
+		return &core.ToolResult{
+			Success: false,
+			Error:   escalationMessage(m, d, t.policy.Limits(params.UserID)),
+		}, nil
+	}
+
+	ctx, replayed := watchReplay(ctx)
+	result, err := t.Tool.Execute(ctx, params)
+	if err == nil && result != nil && result.Success && !*replayed {
+		if recErr := t.policy.Record(params.UserID, m, d, requestKey, now); recErr != nil {
+			result.Metadata = map[string]interface{}{"policy_warning": fmt.Sprintf("payment not recorded against limits: %v", recErr)}
+		}
+	}
+	return result, err
+}
+
+// ---------------------------------------------------------------------------
+// tools
+// ---------------------------------------------------------------------------
+
+func createSpendingPolicyTools(policy *spendingPolicy) []core.Tool {
+	return []core.Tool{
+		createGetSpendingLimitsTool(policy),
+		createSetSpendingLimitsTool(policy),
+		createApproveFlaggedPaymentTool(policy),
+	}
+}
+
+func createGetSpendingLimitsTool(policy *spendingPolicy) core.Tool {
+	return tools.New("get_spending_limits").
+		Description("Show the user's spending limits for send_money and withdraw_savings and how much of each rolling daily/weekly limit has been used.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"currency": tools.StringProperty("Currency to report usage in (default: USD)"),
+		})).
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				Currency string `json:"currency"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+			if params.Currency == "" {
+				params.Currency = "USD"
+			}
+
+			now := time.Now()
+			ledger := policy.state.Get(toolParams.UserID).Ledger
+			used := make(map[string]interface{})
+			for tool := range policedTools {
+				m := moneyMovement{Tool: tool, Currency: strings.ToUpper(params.Currency)}
+				day, _ := usage(ledger, m, now.Add(-24*time.Hour), false)
+				week, _ := usage(ledger, m, now.Add(-7*24*time.Hour), false)
+				used[tool] = map[string]interface{}{
+					"last_24_hours": analytics.RoundMoney(day),
+					"last_7_days":   analytics.RoundMoney(week),
+				}
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"limits":   policy.Limits(toolParams.UserID),
+					"usage":    used,
+					"currency": strings.ToUpper(params.Currency),
+				},
+			}, nil
+		}).
+		Build()
+}
+
+type limitsUpdate struct {
+	PerTransaction           *float64 `json:"per_transaction"`
+	Daily                    *float64 `json:"daily"`
+	Weekly                   *float64 `json:"weekly"`
+	PerRecipientDaily        *float64 `json:"per_recipient_daily"`
+	EscalateAbove            *float64 `json:"escalate_above"`
+	RapidRepeatCount         *int     `json:"rapid_repeat_count"`
+	RapidRepeatWindowMinutes *int     `json:"rapid_repeat_window_minutes"`
+	NewPayeeCoolingHours     *int     `json:"new_payee_cooling_hours"`
+	NewPayeeCap              *float64 `json:"new_payee_cap"`
+	DuplicateWindowMinutes   *int     `json:"duplicate_window_minutes"`
+	BlockDuplicates          *bool    `json:"block_duplicates"`
+}
+
+// apply returns the limits with the update applied and a description of
+// each change.
+// limitChange is one limit changed by an update, with its old and new
+// values formatted for display.
+type limitChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+func (c limitChange) String() string {
+	return fmt.Sprintf("%s %s → %s", c.Field, c.Old, c.New)
+}
+
+func (u limitsUpdate) apply(l config.SpendingLimits) (config.SpendingLimits, []limitChange) {
+	var changes []limitChange
+	setF := func(name string, dst *float64, v *float64) {
+		if v != nil {
+			changes = append(changes, limitChange{Field: name, Old: fmt.Sprintf("%.2f", *dst), New: fmt.Sprintf("%.2f", *v)})
+			*dst = *v
+		}
+	}
+	setI := func(name string, dst *int, v *int) {
+		if v != nil {
+			changes = append(changes, limitChange{Field: name, Old: strconv.Itoa(*dst), New: strconv.Itoa(*v)})
+			*dst = *v
+		}
+	}
+	setF("per transaction", &l.PerTransaction, u.PerTransaction)
+	setF("daily", &l.Daily, u.Daily)
+	setF("weekly", &l.Weekly, u.Weekly)
+	setF("per recipient daily", &l.PerRecipientDaily, u.PerRecipientDaily)
+	setF("approval threshold", &l.EscalateAbove, u.EscalateAbove)
+	setI("rapid repeat count", &l.RapidRepeatCount, u.RapidRepeatCount)
+	setI("rapid repeat window (min)", &l.RapidRepeatWindowMinutes, u.RapidRepeatWindowMinutes)
+	setI("new payee cooling-off (hours)", &l.NewPayeeCoolingHours, u.NewPayeeCoolingHours)
+	setF("new payee cap", &l.NewPayeeCap, u.NewPayeeCap)
+	setI("duplicate window (min)", &l.DuplicateWindowMinutes, u.DuplicateWindowMinutes)
+	if u.BlockDuplicates != nil {
+		changes = append(changes, limitChange{Field: "block duplicates", Old: strconv.FormatBool(l.BlockDuplicates), New: strconv.FormatBool(*u.BlockDuplicates)})
+		l.BlockDuplicates = *u.BlockDuplicates
+	}
+	return l, changes
+}
+
+func createSetSpendingLimitsTool(policy *spendingPolicy) core.Tool {
+	tool := tools.New("set_spending_limits").
+		Description("Change the user's spending limits. Only the fields provided are changed; 0 disables a limit. Requires confirmation.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"per_transaction":             toolkit.Minimum(tools.NumberProperty("Maximum amount for a single send_money or withdraw_savings"), 0),
+			"daily":                       toolkit.Minimum(tools.NumberProperty("Maximum total per tool over any 24 hours"), 0),
+			"weekly":                      toolkit.Minimum(tools.NumberProperty("Maximum total per tool over any 7 days"), 0),
+			"per_recipient_daily":         toolkit.Minimum(tools.NumberProperty("Maximum sent to one recipient over any 24 hours"), 0),
+			"escalate_above":              toolkit.Minimum(tools.NumberProperty("Single amounts above this need extra approval"), 0),
+			"rapid_repeat_count":          toolkit.Minimum(tools.IntegerProperty("Payments to the same recipient within the window before extra approval is needed"), 0),
+			"rapid_repeat_window_minutes": toolkit.Minimum(tools.IntegerProperty("Window for rapid repeat detection, in minutes"), 0),
+			"new_payee_cooling_hours":     toolkit.Minimum(tools.IntegerProperty("How long a newly trusted payee stays capped, in hours"), 0),
+			"new_payee_cap":               toolkit.Minimum(tools.NumberProperty("Total that can be sent to a new payee during the cooling-off period"), 0),
+			"duplicate_window_minutes":    toolkit.Minimum(tools.IntegerProperty("Identical payments (same recipient, amount and currency) within this many minutes are flagged"), 0),
+			"block_duplicates":            tools.BooleanProperty("Block duplicate payments outright instead of asking for approval"),
+		})).
+		RequiresConfirmation().
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var update limitsUpdate
+			if err := json.Unmarshal(toolParams.Input, &update); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			var limits config.SpendingLimits
+			var changes []limitChange
+			err := policy.state.Update(toolParams.UserID, func(s *policyState) error {
+				current := policy.defaults
+				if s.Limits != nil {
+					current = *s.Limits
+				}
+				limits, changes = update.apply(current)
+				if err := limits.Validate(); err != nil {
+					return err
+				}
+				s.Limits = &limits
+				return nil
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to update limits: %v", err),
+				}, nil
+			}
+
+			described := make([]string, len(changes))
+			for i, c := range changes {
+				described[i] = c.String()
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"message": "Spending limits updated.",
+					"changes": described,
+					"limits":  limits,
+				},
+			}, nil
+		}).
+		Build()
+
+	return &summaryTool{Tool: tool, summary: func(input json.RawMessage) string {
+		var update limitsUpdate
+		_ = json.Unmarshal(input, &update)
+		_, changes := update.apply(policy.defaults)
+		if len(changes) == 0 {
+			return "Change spending limits"
+		}
+		var fields []string
+		for _, c := range changes {
+			// Only show the new value; the current one depends on the user.
+			fields = append(fields, c.Field+" → "+c.New)
+		}
+		return "Change spending limits: " + strings.Join(fields, ", ")
+	}}
+}
+
+func createApproveFlaggedPaymentTool(policy *spendingPolicy) core.Tool {
+	tool := tools.New("approve_flagged_payment").
+		Description("Record the user's explicit approval for a send_money or withdraw_savings call that the spending policy flagged for extra approval. The approval is valid once, for 10 minutes, for exactly this tool, recipient, amount and currency. Requires confirmation.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"tool":      tools.StringEnumProperty("The flagged tool", "send_money", "withdraw_savings"),
+			"recipient": tools.StringProperty("Recipient tag (send_money only)"),
+			"amount":    toolkit.Positive(tools.NumberProperty("Exact amount of the flagged payment")),
+			"currency":  tools.StringProperty("Currency code (default: USD)"),
+		}, "tool", "amount")).
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				Tool string `json:"tool"`
+			}
+			_ = json.Unmarshal(toolParams.Input, &params)
+			if !policedTools[params.Tool] {
+				return &core.ToolResult{Success: false, Error: "tool must be send_money or withdraw_savings"}, nil
+			}
+			m, err := parseMoneyMovement(params.Tool, toolParams.Input)
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			policy.seedPayees(ctx, toolParams)
+
+			now := time.Now()
+			d := policy.Evaluate(toolParams.UserID, m, now)
+			if d.NewPayee {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("%s isn't a trusted payee; use add_trusted_payee instead", m.Recipient),
+				}, nil
+			}
+			switch d.Action {
+			case policyReject:
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("this payment exceeds a hard spending limit and can't be approved: %s", strings.Join(d.Reasons, "; ")),
+				}, nil
+			case policyAllow:
+				return &core.ToolResult{
+					Success: true,
+					Data: map[string]interface{}{
+						"message": fmt.Sprintf("No approval needed — go ahead with %s.", m.Tool),
+					},
+				}, nil
+			}
+
+			o, err := policy.Approve(toolParams.UserID, m, now)
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to record approval: %v", err),
+				}, nil
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"message":    fmt.Sprintf("Approved: %s. Now call %s with the same details.", m, m.Tool),
+					"expires_at": o.ExpiresAt.Format(time.RFC3339),
+				},
+			}, nil
+		}).
+		Build()
+
+	return &summaryTool{Tool: tool, summary: func(input json.RawMessage) string {
+		var params struct {
+			Tool string `json:"tool"`
+		}
+		_ = json.Unmarshal(input, &params)
+		m, err := parseMoneyMovement(params.Tool, input)
+		if err != nil {
+			return "Approve flagged payment"
+		}
+		return "Approve flagged payment: " + m.String()
+	}}
+}
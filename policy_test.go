@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+)
+
+// newTestPolicy returns a spending policy with the given limits and fresh
+// stores.
+func newTestPolicy(t *testing.T, exec core.ToolExecutor, limits config.SpendingLimits) (*spendingPolicy, *payeeStore) {
+	t.Helper()
+	dir := t.TempDir()
+	policyStore, err := newJSONStore[policyState](filepath.Join(dir, "spending_policy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payees, err := newJSONStore[payeeBook](filepath.Join(dir, "payees.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newSpendingPolicy(exec, policyStore, payees, limits), payees
+}
+
+// trustPayees marks the user's payees as seeded and trusts recipients,
+// added long enough ago to be past the new-payee cooling-off.
+func trustPayees(payees *payeeStore, userID string, recipients ...string) {
+	payees.Update(userID, func(b *payeeBook) error {
+		b.Seeded = true
+		for _, r := range recipients {
+			b.Payees = append(b.Payees, trustedPayee{Recipient: r, Source: "manual", AddedAt: time.Now().AddDate(0, -1, 0)})
+		}
+		return nil
+	})
+}
+
+// slowSend is a send_money tool that takes a moment to succeed.
+func slowSend() core.Tool {
+	return tools.New("send_money").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"recipient": tools.StringProperty("Recipient"),
+			"amount":    tools.NumberProperty("Amount"),
+		})).
+		Handler(func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			time.Sleep(20 * time.Millisecond)
+			return &core.ToolResult{Success: true}, nil
+		}).
+		Build()
+}
+
+// Two payments at once can't both fit under a limit only one fits under.
+func TestPolicyConcurrentPayments(t *testing.T) {
+	limits := config.DefaultSpendingLimits()
+	limits.Daily = 100
+	policy, payees := newTestPolicy(t, nil, limits)
+	trustPayees(payees, "alice", "@bob", "@carol")
+	send := withSpendingPolicy(policy, []core.Tool{slowSend()})[0]
+
+	var wg sync.WaitGroup
+	results := make([]*core.ToolResult, 2)
+	for i, recipient := range []string{"@bob", "@carol"} {
+		wg.Add(1)
+		go func(i int, recipient string) {
+			defer wg.Done()
+			input, _ := json.Marshal(map[string]interface{}{"recipient": recipient, "amount": 60})
+			result, err := send.Execute(context.Background(), &core.ToolParams{UserID: "alice", Input: input})
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = result
+		}(i, recipient)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, r := range results {
+		if r != nil && r.Success {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d payments went through, want 1: %+v %+v", succeeded, results[0], results[1])
+	}
+	if ledger := policy.state.Get("alice").Ledger; len(ledger) != 1 {
+		t.Errorf("ledger = %+v, want one payment", ledger)
+	}
+}
+
+func TestNormalizeRecipient(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"@bob", "@bob"},
+		{"bob", "@bob"},
+		{" @Bob ", "@bob"},
+		{"BOB", "@bob"},
+		{"", ""},
+		{"  ", ""},
+	} {
+		if got := normalizeRecipient(tc.in); got != tc.want {
+			t.Errorf("normalizeRecipient(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// "bob" and "@Bob" are the same payee to the ledger and the payee book.
+func TestPolicyRecipientSpellings(t *testing.T) {
+	policy, payees := newTestPolicy(t, nil, config.DefaultSpendingLimits())
+	trustPayees(payees, "alice", "Bob")
+	send := withSpendingPolicy(policy, []core.Tool{slowSend()})[0]
+
+	result, err := send.Execute(context.Background(), &core.ToolParams{UserID: "alice", Input: json.RawMessage(`{"recipient": "bob", "amount": 20}`)})
+	if err != nil || !result.Success {
+		t.Fatalf("first payment: %+v %v", result, err)
+	}
+	m, _ := parseMoneyMovement("send_money", json.RawMessage(`{"recipient": "@Bob", "amount": 20}`))
+	d := policy.Evaluate("alice", m, time.Now())
+	if d.Action != policyEscalate || d.NewPayee || len(d.Reasons) != 1 || !strings.Contains(d.Reasons[0], "identical payment") {
+		t.Errorf("decision = %+v, want only the duplicate escalation", d)
+	}
+}
+
+// The set_spending_limits card shows each new value, whatever the field.
+func TestSetSpendingLimitsSummary(t *testing.T) {
+	policy, _ := newTestPolicy(t, nil, config.DefaultSpendingLimits())
+	tool := createSetSpendingLimitsTool(policy).(interface{ GetSummary(json.RawMessage) string })
+	for input, want := range map[string]string{
+		`{}`:                                     "Change spending limits",
+		`{"daily": 300}`:                         "Change spending limits: daily → 300.00",
+		`{"block_duplicates": true}`:             "Change spending limits: block duplicates → true",
+		`{"rapid_repeat_count": 5, "weekly": 0}`: "Change spending limits: weekly → 0.00, rapid repeat count → 5",
+		`not json`:                               "Change spending limits",
+	} {
+		if got := tool.GetSummary(json.RawMessage(input)); got != want {
+			t.Errorf("summary of %s = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// Each limit on its own: what it rejects, what it escalates and what still
+// goes through.
+func TestPolicyEvaluate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	limits := config.SpendingLimits{
+		PerTransaction:           500,
+		Daily:                    1000,
+		Weekly:                   2000,
+		PerRecipientDaily:        300,
+		EscalateAbove:            400,
+		RapidRepeatCount:         3,
+		RapidRepeatWindowMinutes: 10,
+		NewPayeeCoolingHours:     24,
+		NewPayeeCap:              250,
+		DuplicateWindowMinutes:   5,
+	}
+	send := func(recipient string, amount float64, ago time.Duration) policyEntry {
+		return policyEntry{Tool: "send_money", Recipient: recipient, Amount: amount, Currency: "USD", At: now.Add(-ago)}
+	}
+
+	for _, tc := range []struct {
+		name         string
+		move         moneyMovement
+		ledger       []policyEntry
+		overrides    []policyOverride
+		blockDups    bool
+		wantAction   policyAction
+		wantNewPayee bool
+		wantReason   string
+	}{
+		{
+			name:       "within every limit",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 50, Currency: "USD"},
+			wantAction: policyAllow,
+		},
+		{
+			name:       "deposits aren't policed",
+			move:       moneyMovement{Tool: "deposit_savings", Amount: 5000, Currency: "USD"},
+			wantAction: policyAllow,
+		},
+		{
+			name:       "per-transaction limit",
+			move:       moneyMovement{Tool: "withdraw_savings", Amount: 600, Currency: "USD"},
+			wantAction: policyReject,
+			wantReason: "per-transaction limit of 500.00",
+		},
+		{
+			name:       "daily limit counts the last 24 hours",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 150, Currency: "USD"},
+			ledger:     []policyEntry{send("@carol", 300, 2*time.Hour), send("@dave", 300, 3*time.Hour), send("@erin", 300, 23*time.Hour), send("@carol", 300, 25*time.Hour)},
+			wantAction: policyReject,
+			wantReason: "last 24 hours to 1050.00 USD",
+		},
+		{
+			name:       "daily limit is per currency",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 150, Currency: "USD"},
+			ledger:     []policyEntry{{Tool: "send_money", Recipient: "@carol", Amount: 900, Currency: "EUR", At: now.Add(-time.Hour)}},
+			wantAction: policyAllow,
+		},
+		{
+			name:       "weekly limit counts the last 7 days",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 250, Currency: "USD"},
+			ledger:     []policyEntry{send("@carol", 300, 3*24*time.Hour), send("@carol", 300, 4*24*time.Hour), send("@carol", 300, 5*24*time.Hour), send("@carol", 300, 6*24*time.Hour), send("@dave", 300, 6*24*time.Hour), send("@dave", 300, 6*24*time.Hour), send("@erin", 300, 8*24*time.Hour)},
+			wantAction: policyReject,
+			wantReason: "last 7 days to 2050.00 USD",
+		},
+		{
+			name:       "per-recipient limit",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 100, Currency: "USD"},
+			ledger:     []policyEntry{send("@bob", 250, time.Hour), send("@carol", 250, time.Hour)},
+			wantAction: policyReject,
+			wantReason: "payments to @bob in the last 24 hours to 350.00",
+		},
+		{
+			name:       "rapid repeats escalate",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 13, Currency: "USD"},
+			ledger:     []policyEntry{send("@bob", 10, 8*time.Minute), send("@bob", 11, 6*time.Minute), send("@bob", 12, 4*time.Minute)},
+			wantAction: policyEscalate,
+			wantReason: "3 payments to @bob in the last 10 minutes",
+		},
+		{
+			name:       "repeats outside the window don't",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 13, Currency: "USD"},
+			ledger:     []policyEntry{send("@bob", 10, 15*time.Minute), send("@bob", 11, 12*time.Minute), send("@bob", 12, 4*time.Minute)},
+			wantAction: policyAllow,
+		},
+		{
+			name:         "untrusted recipient",
+			move:         moneyMovement{Tool: "send_money", Recipient: "@zed", Amount: 20, Currency: "USD"},
+			wantAction:   policyEscalate,
+			wantNewPayee: true,
+			wantReason:   "@zed isn't a trusted payee yet",
+		},
+		{
+			name:       "new payee cap counts what was sent since trusting",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@newbie", Amount: 60, Currency: "USD"},
+			ledger:     []policyEntry{send("@newbie", 200, 30*time.Minute)},
+			wantAction: policyReject,
+			wantReason: "capped at 250.00 USD in total until Mar 11 11:00 (200.00 already sent)",
+		},
+		{
+			name:       "new payee under the cap",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@newbie", Amount: 50, Currency: "USD"},
+			ledger:     []policyEntry{send("@newbie", 200, 30*time.Minute)},
+			wantAction: policyAllow,
+		},
+		{
+			name:       "above the approval threshold",
+			move:       moneyMovement{Tool: "withdraw_savings", Amount: 450, Currency: "USD"},
+			wantAction: policyEscalate,
+			wantReason: "above the 400.00 approval threshold",
+		},
+		{
+			name:       "approved override clears the escalation",
+			move:       moneyMovement{Tool: "withdraw_savings", Amount: 450, Currency: "USD"},
+			overrides:  []policyOverride{{ID: "ovr_1", Tool: "withdraw_savings", Amount: 450, Currency: "USD", ExpiresAt: now.Add(time.Minute)}},
+			wantAction: policyAllow,
+		},
+		{
+			name:       "expired override doesn't",
+			move:       moneyMovement{Tool: "withdraw_savings", Amount: 450, Currency: "USD"},
+			overrides:  []policyOverride{{ID: "ovr_1", Tool: "withdraw_savings", Amount: 450, Currency: "USD", ExpiresAt: now.Add(-time.Minute)}},
+			wantAction: policyEscalate,
+		},
+		{
+			name:       "override for another amount doesn't",
+			move:       moneyMovement{Tool: "withdraw_savings", Amount: 450, Currency: "USD"},
+			overrides:  []policyOverride{{ID: "ovr_1", Tool: "withdraw_savings", Amount: 440, Currency: "USD", ExpiresAt: now.Add(time.Minute)}},
+			wantAction: policyEscalate,
+		},
+		{
+			name:       "duplicate escalates",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 50, Currency: "USD"},
+			ledger:     []policyEntry{send("@bob", 50, 2*time.Minute)},
+			wantAction: policyEscalate,
+			wantReason: "an identical payment",
+		},
+		{
+			name:       "duplicate is rejected when blocking",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 50, Currency: "USD"},
+			ledger:     []policyEntry{send("@bob", 50, 2*time.Minute)},
+			blockDups:  true,
+			wantAction: policyReject,
+			wantReason: "an identical payment",
+		},
+		{
+			name:       "duplicate outside the window",
+			move:       moneyMovement{Tool: "send_money", Recipient: "@bob", Amount: 50, Currency: "USD"},
+			ledger:     []policyEntry{send("@bob", 50, 6*time.Minute)},
+			wantAction: policyAllow,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l := limits
+			l.BlockDuplicates = tc.blockDups
+			policy, payees := newTestPolicy(t, nil, l)
+			trustPayees(payees, "alice", "@bob", "@carol", "@dave", "@erin")
+			payees.Update("alice", func(b *payeeBook) error {
+				b.Payees = append(b.Payees, trustedPayee{Recipient: "@newbie", Source: "manual", AddedAt: now.Add(-time.Hour)})
+				return nil
+			})
+			policy.state.Update("alice", func(s *policyState) error {
+				s.Ledger = tc.ledger
+				s.Overrides = tc.overrides
+				return nil
+			})
+
+			d := policy.Evaluate("alice", tc.move, now)
+			if d.Action != tc.wantAction || d.NewPayee != tc.wantNewPayee {
+				t.Fatalf("decision = %+v, want %s (new payee %v)", d, tc.wantAction, tc.wantNewPayee)
+			}
+			if tc.wantReason != "" && !strings.Contains(strings.Join(d.Reasons, "; "), tc.wantReason) {
+				t.Errorf("reasons = %q, want one containing %q", d.Reasons, tc.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// CUSTOM TOOL: SAVINGS PROJECTION
+// ============================================================================
+
+func createSavingsProjectionTool(liminalExecutor core.ToolExecutor) core.Tool {
+	return tools.New("project_savings").
+		Description("Project how the user's savings will grow under the current vault rates (monthly compounding), with optional recurring monthly deposits and withdrawals. Returns a month-by-month schedule plus the interest actually earned this month and this year so far.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"months":             toolkit.Range(tools.IntegerProperty("Number of months to project (default: 12, max: 120)"), 1, 120),
+			"monthly_deposit":    toolkit.Minimum(tools.NumberProperty("Amount deposited into savings every month (default: 0)"), 0),
+			"monthly_withdrawal": toolkit.Minimum(tools.NumberProperty("Amount withdrawn from savings every month (default: 0)"), 0),
+			"currency":           tools.StringProperty("Currency code (default: USD)"),
+		})).
+		Handler(func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				Months            int     `json:"months"`
+				MonthlyDeposit    float64 `json:"monthly_deposit"`
+				MonthlyWithdrawal float64 `json:"monthly_withdrawal"`
+				Currency          string  `json:"currency"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+			if params.Months == 0 {
+				params.Months = 12
+			}
+			if params.Currency == "" {
+				params.Currency = "USD"
+			}
+			params.Currency = strings.ToUpper(params.Currency)
+			if params.Months < 0 || params.Months > 120 {
+				return &core.ToolResult{Success: false, Error: "months must be between 1 and 120"}, nil
+			}
+			if params.MonthlyDeposit < 0 || params.MonthlyWithdrawal < 0 {
+				return &core.ToolResult{Success: false, Error: "monthly_deposit and monthly_withdrawal cannot be negative"}, nil
+			}
+
+			savings, err := analytics.ExecuteRead(ctx, liminalExecutor, toolParams, "get_savings_balance", nil)
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to fetch savings balance: %v", err),
+				}, nil
+			}
+			rates, err := analytics.ExecuteRead(ctx, liminalExecutor, toolParams, "get_vault_rates", nil)
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to fetch vault rates: %v", err),
+				}, nil
+			}
+
+			now := time.Now()
+			transactions, err := analytics.FetchTransactions(ctx, liminalExecutor, toolParams, map[string]interface{}{
+				"limit":      500,
+				"start_date": time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02"),
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to fetch transactions: %v", err),
+				}, nil
+			}
+
+			balance := savingsBalance(savings, params.Currency)
+			apy := currentAPY(savings, rates, params.Currency)
+			projection := projectSavings(balance, apy, params.MonthlyDeposit, params.MonthlyWithdrawal, params.Months, now)
+			projection["currency"] = params.Currency
+			projection["interest_earned"] = interestEarned(transactions, params.Currency, now)
+			projection["generated_at"] = now.Format(time.RFC3339)
+
+			return &core.ToolResult{
+				Success: true,
+				Data:    projection,
+			}, nil
+		}).
+		Build()
+}
+
+// projectSavings compounds monthly at the rate equivalent to the given APY.
+// Interest accrues on the opening balance; deposits and withdrawals land at
+// month end, and withdrawals never take the balance below zero.
+func projectSavings(balance, apy, deposit, withdrawal float64, months int, now time.Time) map[string]interface{} {
+	monthlyRate := math.Pow(1+apy/100, 1.0/12) - 1
+
+	schedule := make([]map[string]interface{}, 0, months)
+	var totalInterest, totalDeposits, totalWithdrawals float64
+	current := balance
+	monthStart := analytics.StartOfMonth(now)
+	depleted := ""
+
+	for m := 1; m <= months; m++ {
+		opening := current
+		interest := opening * monthlyRate
+		current += interest + deposit
+
+		withdrawn := math.Min(withdrawal, current)
+		current -= withdrawn
+		if withdrawn < withdrawal && depleted == "" {
+			depleted = monthStart.AddDate(0, m, 0).Format("2006-01")
+		}
+
+		totalInterest += interest
+		totalDeposits += deposit
+		totalWithdrawals += withdrawn
+
+		schedule = append(schedule, map[string]interface{}{
+			"month":           monthStart.AddDate(0, m, 0).Format("2006-01"),
+			"opening_balance": analytics.RoundMoney(opening),
+			"interest":        analytics.RoundMoney(interest),
+			"deposit":         analytics.RoundMoney(deposit),
+			"withdrawal":      analytics.RoundMoney(withdrawn),
+			"closing_balance": analytics.RoundMoney(current),
+		})
+	}
+
+	result := map[string]interface{}{
+		"starting_balance":   analytics.RoundMoney(balance),
+		"apy":                apy,
+		"monthly_rate_pct":   math.Round(monthlyRate*100*10000) / 10000,
+		"months":             months,
+		"monthly_deposit":    deposit,
+		"monthly_withdrawal": withdrawal,
+		"ending_balance":     analytics.RoundMoney(current),
+		"total_interest":     analytics.RoundMoney(totalInterest),
+		"total_deposits":     analytics.RoundMoney(totalDeposits),
+		"total_withdrawals":  analytics.RoundMoney(totalWithdrawals),
+		"schedule":           schedule,
+	}
+	if depleted != "" {
+		result["warning"] = fmt.Sprintf("Savings run out in %s at this withdrawal rate.", depleted)
+	}
+	return result
+}
+
+// currentAPY picks the rate that applies to the user's savings: the current
+// vault rate for each position (falling back to the position's own APY),
+// weighted by the position's value (positionValue). Without positions, use
+// the top-level APY or the best available vault rate for the currency.
+func currentAPY(savings, rates map[string]interface{}, currency string) float64 {
+	vaultAPY := make(map[string]float64)
+	best := 0.0
+	rateList, _ := rates["rates"].([]interface{})
+	for _, r := range rateList {
+		rate, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if c, ok := rate["currency"].(string); ok && !strings.EqualFold(c, currency) {
+			continue
+		}
+		apy := analytics.ToFloat(rate["apy"])
+		if id, ok := rate["vault_id"].(string); ok {
+			vaultAPY[id] = apy
+		}
+		best = math.Max(best, apy)
+	}
+
+	var weighted, total float64
+	positions, _ := savings["positions"].([]interface{})
+	for _, p := range positions {
+		pos, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if c, ok := pos["currency"].(string); ok && !strings.EqualFold(c, currency) {
+			continue
+		}
+		bal := positionValue(pos)
+		apy := analytics.ToFloat(pos["apy"])
+		if id, ok := pos["vault_id"].(string); ok {
+			if rate, ok := vaultAPY[id]; ok {
+				apy = rate
+			}
+		}
+		weighted += bal * apy
+		total += bal
+	}
+	if total > 0 {
+		return math.Round(weighted/total*100) / 100
+	}
+
+	if _, ok := savings["apy"]; ok {
+		return analytics.ToFloat(savings["apy"])
+	}
+	return best
+}
+
+// interestEarned totals interest/yield credits found in the transaction
+// history for the current month and the year to date.
+func interestEarned(transactions []map[string]interface{}, currency string, now time.Time) map[string]interface{} {
+	monthStart := analytics.StartOfMonth(now)
+	yearStart := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+
+	var thisMonth, ytd float64
+	var count int
+	for _, tx := range transactions {
+		if !isInterestTransaction(tx) || analytics.TxCurrency(tx) != currency {
+			continue
+		}
+		t, ok := analytics.TxTime(tx)
+		if !ok || t.Before(yearStart) || t.After(now) {
+			continue
+		}
+		amount := analytics.TxAmount(tx)
+		ytd += amount
+		count++
+		if !t.Before(monthStart) {
+			thisMonth += amount
+		}
+	}
+
+	return map[string]interface{}{
+		"this_month":    analytics.RoundMoney(thisMonth),
+		"year_to_date":  analytics.RoundMoney(ytd),
+		"credits_found": count,
+		"source":        "transaction_history",
+	}
+}
+
+func isInterestTransaction(tx map[string]interface{}) bool {
+	txType, _ := tx["type"].(string)
+	switch strings.ToLower(txType) {
+	case "interest", "yield", "earn", "reward":
+		return true
+	}
+	desc, _ := tx["description"].(string)
+	desc = strings.ToLower(desc)
+	return strings.Contains(desc, "interest") || strings.Contains(desc, "yield")
+}
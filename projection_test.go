@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCurrentAPY(t *testing.T) {
+	rates := `{"rates": [
+		{"vault_id": "v1", "currency": "USD", "apy": 4},
+		{"vault_id": "v2", "currency": "USD", "apy": 5},
+		{"vault_id": "v3", "currency": "EUR", "apy": 9}
+	]}`
+	tests := []struct {
+		name    string
+		savings string
+		want    float64
+	}{
+		{
+			name:    "weighted by balance",
+			savings: `{"positions": [{"vault_id": "v1", "balance": 3000}, {"vault_id": "v2", "balance": 1000}]}`,
+			want:    4.25,
+		},
+		{
+			name:    "positions valued by currentValue",
+			savings: `{"positions": [{"vault_id": "v1", "currentValue": "1000"}, {"vault_id": "v2", "currentValue": "3000"}]}`,
+			want:    4.75,
+		},
+		{
+			name:    "position's own APY without a vault rate",
+			savings: `{"positions": [{"vault_id": "old", "apy": 2, "balance": 500}]}`,
+			want:    2,
+		},
+		{
+			name:    "other currencies ignored",
+			savings: `{"positions": [{"vault_id": "v3", "currency": "EUR", "balance": 9000}, {"vault_id": "v1", "balance": 100}]}`,
+			want:    4,
+		},
+		{
+			name:    "top-level APY without positions",
+			savings: `{"apy": 3.5}`,
+			want:    3.5,
+		},
+		{
+			name:    "best rate without anything else",
+			savings: `{}`,
+			want:    5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var savings, r map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.savings), &savings); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(rates), &r); err != nil {
+				t.Fatal(err)
+			}
+			if got := currentAPY(savings, r, "USD"); got != tt.want {
+				t.Errorf("currentAPY = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// REDACTION  –  keep credentials and personal data out of logs and replies
+// ============================================================================
+
+type redactionLevel int
+
+const (
+	// redactNone leaves values untouched.
+	redactNone redactionLevel = iota
+	// redactSecrets hides credentials and keys.
+	redactSecrets
+	// redactPII also hides personal and account details.
+	redactPII
+	// redactFinancial also hides amounts, balances, recipients and message
+	// text. Used for logs; the audit log stops at redactPII so it still
+	// records what was paid to whom.
+	redactFinancial
+)
+
+func parseRedactionLevel(s string) (redactionLevel, error) {
+	switch strings.ToLower(s) {
+	case "none", "off":
+		return redactNone, nil
+	case "secrets":
+		return redactSecrets, nil
+	case "pii":
+		return redactPII, nil
+	case "financial", "all":
+		return redactFinancial, nil
+	}
+	return redactNone, fmt.Errorf("unknown redaction level %q: use none, secrets, pii or financial", s)
+}
+
+// secretKeys, piiKeys and financialKeys are matched as substrings of lower-cased JSON field
+// names, at any depth.
+var (
+	secretKeys = []string{
+		"password", "secret", "token", "jwt", "authorization", "api_key", "apikey",
+		"private_key", "seed_phrase", "mnemonic",
+	}
+	piiKeys = []string{
+		"email", "phone", "ssn", "address", "account_number", "iban", "routing_number",
+		"card_number",
+	}
+	financialKeys = []string{
+		"amount", "balance", "recipient", "counterparty", "payee", "merchant", "note",
+		"description", "content", "query",
+	}
+)
+
+func isSensitiveKey(key string, level redactionLevel) bool {
+	if level == redactNone {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, s := range secretKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	if level < redactPII {
+		return false
+	}
+	for _, s := range piiKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	if level < redactFinancial {
+		return false
+	}
+	for _, s := range financialKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON redacts everything sensitive (redactPII). Input that isn't JSON
+// is returned as a JSON string so it can still be embedded.
+func redactJSON(raw json.RawMessage) json.RawMessage {
+	return redactJSONLevel(raw, redactPII)
+}
+
+// redactJSONLevel replaces the values of sensitive fields with "[REDACTED]".
+func redactJSONLevel(raw json.RawMessage, level redactionLevel) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		quoted, _ := json.Marshal(string(raw))
+		return quoted
+	}
+	out, err := json.Marshal(redactValue(v, level))
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+func redactValue(v interface{}, level redactionLevel) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range val {
+			if isSensitiveKey(k, level) {
+				val[k] = "[REDACTED]"
+			} else {
+				val[k] = redactValue(inner, level)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, inner := range val {
+			val[i] = redactValue(inner, level)
+		}
+		return val
+	}
+	return v
+}
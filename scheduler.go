@@ -0,0 +1,802 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/tools"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/analytics"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/toolkit"
+)
+
+// ============================================================================
+// SCHEDULED TRANSFERS
+// ============================================================================
+// Users can schedule recurring send_money / deposit_savings intents ("send
+// @alice $50 every Friday"). A background loop checks for due occurrences:
+//   - pre-authorized schedules run straight through ExecuteWrite, in mock
+//     mode;
+//   - everything else is parked as "awaiting confirmation" and executed via
+//     run_scheduled_transfer, which goes through the normal confirm card.
+//
+// Against Liminal, pre-authorized runs are parked too. The server only holds
+// a user's JWT from their last connection, which may well have expired by
+// the time an occurrence falls due, so it doesn't move money for them
+// unattended.
+//
+// Under DRY_RUN nothing here moves money: pre-authorized runs are parked,
+// and run_scheduled_transfer previews the occurrence and leaves it waiting.
+
+const (
+	// pendingRunTTL is how long an unconfirmed occurrence waits before it is
+	// dropped as expired.
+	pendingRunTTL = 7 * 24 * time.Hour
+
+	// scheduledRunHour is the local hour at which occurrences fall due.
+	scheduledRunHour = 9
+
+	// transferHistoryLimit caps the run history kept per schedule.
+	transferHistoryLimit = 20
+)
+
+type scheduledTransfer struct {
+	ID            string        `json:"id"`
+	Tool          string        `json:"tool"` // send_money | deposit_savings
+	Recipient     string        `json:"recipient,omitempty"`
+	Amount        float64       `json:"amount"`
+	Currency      string        `json:"currency"`
+	Note          string        `json:"note,omitempty"`
+	Frequency     string        `json:"frequency"` // once | daily | weekly | biweekly | monthly
+	StartAt       time.Time     `json:"start_at"`
+	NextRun       time.Time     `json:"next_run"`
+	RunCount      int           `json:"run_count"` // occurrences that have fallen due so far
+	PreAuthorized bool          `json:"pre_authorized"`
+	Status        string        `json:"status"` // active | paused | cancelled | completed
+	Pending       []transferRun `json:"pending,omitempty"`
+	History       []transferRun `json:"history,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// transferRun is one occurrence of a scheduled transfer.
+type transferRun struct {
+	ID             string     `json:"id"`
+	DueAt          time.Time  `json:"due_at"`
+	Status         string     `json:"status"` // awaiting_confirmation | executed | failed | expired | skipped
+	ConfirmationID string     `json:"confirmation_id,omitempty"`
+	RanAt          *time.Time `json:"ran_at,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	Result         string     `json:"result,omitempty"`
+}
+
+// input builds the tool input in the live Liminal shape (amounts as decimal
+// strings).
+func (t scheduledTransfer) input() json.RawMessage {
+	in := map[string]interface{}{
+		"amount":   fmt.Sprintf("%.2f", t.Amount),
+		"currency": t.Currency,
+	}
+	if t.Tool == "send_money" {
+		in["recipient"] = t.Recipient
+		if t.Note != "" {
+			in["note"] = t.Note
+		}
+	}
+	raw, _ := json.Marshal(in)
+	return raw
+}
+
+// movement is what the spending policy sees of an occurrence.
+func (t scheduledTransfer) movement() moneyMovement {
+	return moneyMovement{Tool: t.Tool, Recipient: normalizeRecipient(t.Recipient), Amount: t.Amount, Currency: t.Currency}
+}
+
+func (t scheduledTransfer) summary() string {
+	if t.Tool == "send_money" {
+		return fmt.Sprintf("Send %.2f %s to %s", t.Amount, t.Currency, t.Recipient)
+	}
+	return fmt.Sprintf("Deposit %.2f %s into savings", t.Amount, t.Currency)
+}
+
+// occurrence returns when the n-th occurrence (0-based) of a schedule falls
+// due. Monthly schedules keep their day of month, clamped to short months.
+func occurrence(start time.Time, frequency string, n int) time.Time {
+	switch frequency {
+	case "daily":
+		return start.AddDate(0, 0, n)
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "biweekly":
+		return start.AddDate(0, 0, 14*n)
+	case "monthly":
+		return analytics.AddMonthsClamped(start, n)
+	}
+	if n == 0 {
+		return start
+	}
+	return time.Time{}
+}
+
+type transferStore = jsonStore[[]scheduledTransfer]
+
+// liveRunNote explains why a pre-authorized occurrence waits anyway.
+const liveRunNote = "pre-authorized runs wait for confirmation against Liminal: the server holds no credential to pay unattended"
+
+// dryRunNote is liveRunNote under DRY_RUN.
+const dryRunNote = "DRY_RUN is on: scheduled transfers are only previewed, never run"
+
+// parkNote says why pre-authorized occurrences wait for confirmation, or
+// is empty if they run unattended.
+func parkNote(unattended, dryRun bool) string {
+	switch {
+	case dryRun:
+		return dryRunNote
+	case !unattended:
+		return liveRunNote
+	}
+	return ""
+}
+
+// transferScheduler runs due occurrences in the background. unattended
+// says whether exec can act for any user on its own, which only the mock
+// executor can; dryRun (DRY_RUN) parks every occurrence regardless.
+type transferScheduler struct {
+	exec       core.ToolExecutor
+	transfers  *transferStore
+	policy     *spendingPolicy
+	unattended bool
+	dryRun     bool
+}
+
+func newTransferScheduler(exec core.ToolExecutor, transfers *transferStore, policy *spendingPolicy, unattended, dryRun bool) *transferScheduler {
+	return &transferScheduler{exec: exec, transfers: transfers, policy: policy, unattended: unattended, dryRun: dryRun}
+}
+
+// Run checks for due occurrences every interval until ctx is cancelled.
+func (s *transferScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.runDue(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dueRun is an occurrence claimed for automatic execution.
+type dueRun struct {
+	userID   string
+	transfer scheduledTransfer
+	run      transferRun
+}
+
+// runDue advances every active schedule past now. Only the most recent due
+// occurrence is acted on; older ones missed while the server was down are
+// recorded as skipped rather than fired in a burst.
+func (s *transferScheduler) runDue(ctx context.Context, now time.Time) {
+	var auto []dueRun
+	note := parkNote(s.unattended, s.dryRun)
+
+	for _, userID := range s.transfers.Users() {
+		if !hasDueWork(s.transfers.Get(userID), now) {
+			continue
+		}
+		err := s.transfers.Update(userID, func(list *[]scheduledTransfer) error {
+			for i := range *list {
+				t := &(*list)[i]
+				expirePending(t, now)
+				if t.Status != "active" || t.NextRun.IsZero() || t.NextRun.After(now) {
+					continue
+				}
+
+				var due []time.Time
+				for !t.NextRun.IsZero() && !t.NextRun.After(now) {
+					due = append(due, t.NextRun)
+					t.RunCount++
+					t.NextRun = occurrence(t.StartAt, t.Frequency, t.RunCount)
+				}
+				for _, missed := range due[:len(due)-1] {
+					t.History = appendRun(t.History, transferRun{ID: toolkit.NewID("run"), DueAt: missed, Status: "skipped"})
+				}
+				if t.NextRun.IsZero() {
+					t.Status = "completed"
+				}
+				t.UpdatedAt = now
+
+				run := transferRun{ID: toolkit.NewID("run"), DueAt: due[len(due)-1]}
+				switch {
+				case t.PreAuthorized && note == "":
+					auto = append(auto, dueRun{userID: userID, transfer: *t, run: run})
+				case t.PreAuthorized:
+					run.Status = "awaiting_confirmation"
+					run.Error = note
+					t.Pending = append(t.Pending, run)
+				default:
+					run.Status = "awaiting_confirmation"
+					t.Pending = append(t.Pending, run)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "scheduler: failed to update schedules", "user_id", userID, "error", err)
+		}
+	}
+
+	for _, d := range auto {
+		run := executeScheduledRun(ctx, s.exec, s.policy, d.userID, d.transfer, d.run, false)
+		slog.InfoContext(ctx, "scheduler: ran scheduled transfer", "user_id", d.userID, "transfer_id", d.transfer.ID, "run_id", run.ID, "status", run.Status, "error", run.Error)
+		if err := recordRun(s.transfers, d.userID, d.transfer.ID, run); err != nil {
+			slog.ErrorContext(ctx, "scheduler: failed to record run", "user_id", d.userID, "run_id", run.ID, "error", err)
+		}
+	}
+}
+
+// executeScheduledRun performs one occurrence. The request ID, also its call
+// ID for idempotency, is derived from the schedule and due time so a retried
+// occurrence is recognisable downstream. If the executor asks for its own confirmation the run is parked
+// with that confirmation ID instead.
+//
+// Runs go through the spending policy like any other payment. userApproved
+// means the user just confirmed this occurrence, which clears escalations
+// other than a new payee: that still takes add_trusted_payee, as it does
+// for send_money. Unattended runs that would escalate are parked for
+// confirmation.
+func executeScheduledRun(ctx context.Context, exec core.ToolExecutor, policy *spendingPolicy, userID string, t scheduledTransfer, run transferRun, userApproved bool) transferRun {
+	now := time.Now()
+	m := t.movement()
+	var decision policyDecision
+	if policy != nil {
+		defer policy.lock(userID)()
+	}
+	if policy != nil && run.ConfirmationID == "" {
+		policy.seedPayees(ctx, &core.ToolParams{UserID: userID})
+		decision = policy.Evaluate(userID, m, now)
+		switch {
+		case decision.Action == policyReject:
+			run.RanAt = &now
+			run.Status = "failed"
+			run.Error = "blocked by spending policy: " + strings.Join(decision.Reasons, "; ")
+			return run
+		case decision.Action == policyEscalate && (!userApproved || decision.NewPayee):
+			run.Status = "awaiting_confirmation"
+			run.Error = "needs approval: " + strings.Join(decision.Reasons, "; ")
+			if decision.NewPayee {
+				run.Error += fmt.Sprintf("; once the user confirms %s is right, call add_trusted_payee for them and run it again", t.Recipient)
+			}
+			return run
+		}
+	}
+	run.Error = ""
+
+	requestID := fmt.Sprintf("sched_%s_%d", t.ID, run.DueAt.Unix())
+	input := t.input()
+
+	ctx, replayed := watchReplay(ctx)
+	var resp *core.ExecuteResponse
+	var err error
+	if run.ConfirmationID != "" {
+		resp, err = exec.Confirm(ctx, userID, run.ConfirmationID)
+	} else {
+		resp, err = exec.ExecuteWrite(withCallID(ctx, requestID), &core.ExecuteRequest{
+			UserID:    userID,
+			Tool:      t.Tool,
+			Input:     input,
+			RequestID: requestID,
+		})
+	}
+
+	ranAt := time.Now()
+	run.RanAt = &ranAt
+	switch {
+	case err != nil:
+		run.Status = "failed"
+		run.Error = err.Error()
+	case resp.RequiresConfirmation && resp.Confirmation != nil:
+		run.Status = "awaiting_confirmation"
+		run.ConfirmationID = resp.Confirmation.ID
+	case !resp.Success:
+		run.Status = "failed"
+		run.Error = resp.Error
+	default:
+		run.Status = "executed"
+		run.Result = string(resp.Data)
+		// A replayed response means the money moved, and was counted, the
+		// first time.
+		if policy != nil && !*replayed {
+			if err := policy.Record(userID, m, decision, policyRequestKey(requestID, input), now); err != nil {
+				slog.ErrorContext(ctx, "scheduler: failed to record run against spending limits", "user_id", userID, "run_id", run.ID, "error", err)
+			}
+		}
+	}
+	return run
+}
+
+// recordRun stores the outcome of a run: parked runs go (back) to pending,
+// finished ones move to history.
+func recordRun(transfers *transferStore, userID, transferID string, run transferRun) error {
+	return transfers.Update(userID, func(list *[]scheduledTransfer) error {
+		for i := range *list {
+			t := &(*list)[i]
+			if t.ID != transferID {
+				continue
+			}
+			t.Pending = removeRun(t.Pending, run.ID)
+			if run.Status == "awaiting_confirmation" {
+				t.Pending = append(t.Pending, run)
+			} else {
+				t.History = appendRun(t.History, run)
+			}
+			t.UpdatedAt = time.Now()
+			return nil
+		}
+		return fmt.Errorf("scheduled transfer %s no longer exists", transferID)
+	})
+}
+
+// hasDueWork reports whether any schedule needs advancing or has expired
+// occurrences, so idle ticks don't rewrite the store.
+func hasDueWork(list []scheduledTransfer, now time.Time) bool {
+	for _, t := range list {
+		if t.Status == "active" && !t.NextRun.IsZero() && !t.NextRun.After(now) {
+			return true
+		}
+		for _, run := range t.Pending {
+			if now.Sub(run.DueAt) > pendingRunTTL {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func expirePending(t *scheduledTransfer, now time.Time) {
+	kept := t.Pending[:0]
+	for _, run := range t.Pending {
+		if now.Sub(run.DueAt) > pendingRunTTL {
+			run.Status = "expired"
+			t.History = appendRun(t.History, run)
+			continue
+		}
+		kept = append(kept, run)
+	}
+	t.Pending = kept
+}
+
+func appendRun(history []transferRun, run transferRun) []transferRun {
+	history = append(history, run)
+	if len(history) > transferHistoryLimit {
+		history = history[len(history)-transferHistoryLimit:]
+	}
+	return history
+}
+
+func removeRun(runs []transferRun, id string) []transferRun {
+	kept := make([]transferRun, 0, len(runs))
+	for _, r := range runs {
+		if r.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// ---------------------------------------------------------------------------
+// tools
+// ---------------------------------------------------------------------------
+
+// summaryTool overrides the confirmation summary of a tool. The SDK's summary
+// templates only see the raw input, which isn't enough when the action is
+// described by stored state.
+type summaryTool struct {
+	core.Tool
+	summary func(input json.RawMessage) string
+}
+
+func (t *summaryTool) GetSummary(input json.RawMessage) string {
+	return t.summary(input)
+}
+
+// unattended and dryRun are as for transferScheduler.
+func createScheduledTransferTools(liminalExecutor core.ToolExecutor, transfers *transferStore, policy *spendingPolicy, unattended, dryRun bool) []core.Tool {
+	return []core.Tool{
+		createCreateScheduledTransferTool(transfers, parkNote(unattended, dryRun)),
+		createListScheduledTransfersTool(transfers),
+		createSetScheduledTransferStatusTool("pause_scheduled_transfer", "Pause a scheduled transfer. No occurrences fall due while it is paused.", "paused", transfers),
+		createSetScheduledTransferStatusTool("resume_scheduled_transfer", "Resume a paused scheduled transfer from its next future occurrence.", "active", transfers),
+		createSetScheduledTransferStatusTool("cancel_scheduled_transfer", "Cancel a scheduled transfer permanently, dropping any occurrences awaiting confirmation.", "cancelled", transfers),
+		createRunScheduledTransferTool(liminalExecutor, transfers, policy, dryRun),
+	}
+}
+
+type scheduleParams struct {
+	Type          string             `json:"type"`
+	Recipient     string             `json:"recipient"`
+	Amount        toolkit.FlexAmount `json:"amount"`
+	Currency      string             `json:"currency"`
+	Note          string             `json:"note"`
+	Frequency     string             `json:"frequency"`
+	StartDate     string             `json:"start_date"`
+	PreAuthorized bool               `json:"pre_authorized"`
+}
+
+func parseScheduleParams(input json.RawMessage) (scheduleParams, string) {
+	var p scheduleParams
+	if err := json.Unmarshal(input, &p); err != nil {
+		return p, fmt.Sprintf("invalid input: %v", err)
+	}
+	if p.Currency == "" {
+		p.Currency = "USD"
+	}
+	p.Currency = strings.ToUpper(p.Currency)
+	p.Recipient = strings.TrimSpace(p.Recipient)
+
+	switch {
+	case p.Type != "send_money" && p.Type != "deposit_savings":
+		return p, "type must be 'send_money' or 'deposit_savings'"
+	case p.Type == "send_money" && p.Recipient == "":
+		return p, "recipient is required for send_money"
+	case p.Amount <= 0:
+		return p, "amount must be greater than zero"
+	}
+	switch p.Frequency {
+	case "once", "daily", "weekly", "biweekly", "monthly":
+	default:
+		return p, "frequency must be one of once, daily, weekly, biweekly, monthly"
+	}
+	if _, err := time.Parse("2006-01-02", p.StartDate); err != nil {
+		return p, "start_date must be a date in YYYY-MM-DD format"
+	}
+	return p, ""
+}
+
+// note is parkNote's: empty if pre-authorized occurrences run unattended.
+func createCreateScheduledTransferTool(transfers *transferStore, note string) core.Tool {
+	tool := tools.New("create_scheduled_transfer").
+		Description("Schedule a one-off or recurring send_money or deposit_savings, e.g. 'send @alice $50 every Friday' or 'move $200 to savings on payday' (use the date of the user's usual payroll deposit). Occurrences fall due at 09:00 on the scheduled day. Unless pre_authorized is true, each occurrence waits for the user to confirm it via run_scheduled_transfer. Requires confirmation.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"type":           tools.StringEnumProperty("What to do on each occurrence", "send_money", "deposit_savings"),
+			"recipient":      tools.StringProperty("Recipient user tag for send_money (e.g. @alice)"),
+			"amount":         toolkit.Positive(tools.NumberProperty("Amount per occurrence")),
+			"currency":       tools.StringProperty("Currency code (default: USD)"),
+			"note":           tools.StringProperty("Optional payment note for send_money"),
+			"frequency":      tools.StringEnumProperty("How often to repeat", "once", "daily", "weekly", "biweekly", "monthly"),
+			"start_date":     tools.StringProperty("Date of the first occurrence (YYYY-MM-DD)"),
+			"pre_authorized": tools.BooleanProperty("If true, occurrences run automatically without asking each time (default: false). Only set when the user explicitly asks for this."),
+		}, "type", "amount", "frequency", "start_date")).
+		RequiresConfirmation().
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			p, msg := parseScheduleParams(toolParams.Input)
+			if msg != "" {
+				return &core.ToolResult{Success: false, Error: msg}, nil
+			}
+
+			day, _ := time.ParseInLocation("2006-01-02", p.StartDate, time.Local)
+			start := day.Add(scheduledRunHour * time.Hour)
+			now := time.Now()
+			if start.Before(now.Add(-24 * time.Hour)) {
+				return &core.ToolResult{Success: false, Error: "start_date cannot be in the past"}, nil
+			}
+
+			t := scheduledTransfer{
+				ID:            toolkit.NewID("sched"),
+				Tool:          p.Type,
+				Recipient:     p.Recipient,
+				Amount:        float64(p.Amount),
+				Currency:      p.Currency,
+				Note:          p.Note,
+				Frequency:     p.Frequency,
+				StartAt:       start,
+				NextRun:       start,
+				PreAuthorized: p.PreAuthorized,
+				Status:        "active",
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			err := transfers.Update(toolParams.UserID, func(list *[]scheduledTransfer) error {
+				*list = append(*list, t)
+				return nil
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to save scheduled transfer: %v", err),
+				}, nil
+			}
+
+			data := map[string]interface{}{
+				"message":            fmt.Sprintf("Scheduled: %s (%s, first on %s).", t.summary(), t.Frequency, t.StartAt.Format("Mon Jan 2")),
+				"scheduled_transfer": t,
+			}
+			if t.PreAuthorized && note != "" {
+				data["note"] = "Each occurrence will still wait for the user to confirm it: " + note + "."
+			}
+			return &core.ToolResult{
+				Success: true,
+				Data:    data,
+			}, nil
+		}).
+		Build()
+
+	return &summaryTool{Tool: tool, summary: func(input json.RawMessage) string {
+		p, msg := parseScheduleParams(input)
+		if msg != "" {
+			return "Create scheduled transfer"
+		}
+		t := scheduledTransfer{Tool: p.Type, Recipient: p.Recipient, Amount: float64(p.Amount), Currency: p.Currency}
+		s := fmt.Sprintf("Schedule: %s, %s starting %s", t.summary(), p.Frequency, p.StartDate)
+		if p.PreAuthorized && note == "" {
+			s += " (runs automatically without asking)"
+		}
+		return s
+	}}
+}
+
+func createListScheduledTransfersTool(transfers *transferStore) core.Tool {
+	return tools.New("list_scheduled_transfers").
+		Description("List the user's scheduled transfers with their next run, any occurrences awaiting confirmation, and recent run history.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"include_inactive": tools.BooleanProperty("Also list cancelled and completed schedules (default: false)"),
+		})).
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				IncludeInactive bool `json:"include_inactive"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			list := make([]map[string]interface{}, 0)
+			awaiting := 0
+			for _, t := range transfers.Get(toolParams.UserID) {
+				if !params.IncludeInactive && (t.Status == "cancelled" || t.Status == "completed") {
+					continue
+				}
+				history := t.History
+				if len(history) > 5 {
+					history = history[len(history)-5:]
+				}
+				entry := map[string]interface{}{
+					"transfer_id":    t.ID,
+					"summary":        t.summary(),
+					"type":           t.Tool,
+					"frequency":      t.Frequency,
+					"status":         t.Status,
+					"pre_authorized": t.PreAuthorized,
+					"recent_runs":    history,
+				}
+				if !t.NextRun.IsZero() && t.Status == "active" {
+					entry["next_run"] = t.NextRun.Format(time.RFC3339)
+				}
+				if len(t.Pending) > 0 {
+					entry["awaiting_confirmation"] = t.Pending
+					awaiting += len(t.Pending)
+				}
+				list = append(list, entry)
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"scheduled_transfers":   list,
+					"awaiting_confirmation": awaiting,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+func createSetScheduledTransferStatusTool(name, description, status string, transfers *transferStore) core.Tool {
+	return tools.New(name).
+		Description(description).
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"transfer_id": tools.StringProperty("ID of the scheduled transfer"),
+		}, "transfer_id")).
+		Handler(func(_ context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			var params struct {
+				TransferID string `json:"transfer_id"`
+			}
+			if err := json.Unmarshal(toolParams.Input, &params); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("invalid input: %v", err),
+				}, nil
+			}
+
+			var updated scheduledTransfer
+			err := transfers.Update(toolParams.UserID, func(list *[]scheduledTransfer) error {
+				for i := range *list {
+					t := &(*list)[i]
+					if t.ID != params.TransferID {
+						continue
+					}
+					if t.Status == "cancelled" || t.Status == "completed" {
+						return fmt.Errorf("transfer is already %s", t.Status)
+					}
+
+					now := time.Now()
+					switch status {
+					case "active":
+						// Skip occurrences that fell due while paused.
+						for !t.NextRun.IsZero() && t.NextRun.Before(now) {
+							t.RunCount++
+							t.NextRun = occurrence(t.StartAt, t.Frequency, t.RunCount)
+						}
+						if t.NextRun.IsZero() {
+							return fmt.Errorf("no future occurrences left to resume")
+						}
+					case "cancelled":
+						for _, run := range t.Pending {
+							run.Status = "expired"
+							t.History = appendRun(t.History, run)
+						}
+						t.Pending = nil
+					}
+					t.Status = status
+					t.UpdatedAt = now
+					updated = *t
+					return nil
+				}
+				return fmt.Errorf("no scheduled transfer with id %q", params.TransferID)
+			})
+			if err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("failed to update scheduled transfer: %v", err),
+				}, nil
+			}
+
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"transfer_id": updated.ID,
+					"summary":     updated.summary(),
+					"status":      updated.Status,
+				},
+			}, nil
+		}).
+		Build()
+}
+
+// Under dryRun the occurrence is previewed instead of run, and stays
+// pending.
+func createRunScheduledTransferTool(liminalExecutor core.ToolExecutor, transfers *transferStore, policy *spendingPolicy, dryRun bool) core.Tool {
+	tool := tools.New("run_scheduled_transfer").
+		Description("Execute a scheduled transfer occurrence that is awaiting confirmation (see list_scheduled_transfers). Pass the schedule's amount, currency and recipient as listed: they are shown on the confirmation card and must match the schedule. Requires confirmation.").
+		Schema(tools.ObjectSchema(map[string]interface{}{
+			"transfer_id":   tools.StringProperty("ID of the scheduled transfer"),
+			"occurrence_id": tools.StringProperty("ID of the pending occurrence (default: the oldest one)"),
+			"amount":        toolkit.Positive(tools.NumberProperty("Amount per occurrence, as listed")),
+			"currency":      tools.StringProperty("Currency code, as listed"),
+			"recipient":     tools.StringProperty("Recipient, as listed (send_money only)"),
+		}, "transfer_id", "amount", "currency")).
+		RequiresConfirmation().
+		Handler(func(ctx context.Context, toolParams *core.ToolParams) (*core.ToolResult, error) {
+			t, run, msg := findPendingRun(transfers, toolParams.UserID, toolParams.Input)
+			if msg != "" {
+				return &core.ToolResult{Success: false, Error: msg}, nil
+			}
+			if dryRun {
+				m := t.movement()
+				preview := previewMovement(ctx, liminalExecutor, policy, toolParams, m)
+				preview["occurrence_id"] = run.ID
+				preview["message"] = fmt.Sprintf("Preview only: no money was moved, and the occurrence of %s is still waiting.", t.summary())
+				return &core.ToolResult{Success: true, Data: preview}, nil
+			}
+
+			run = executeScheduledRun(ctx, liminalExecutor, policy, toolParams.UserID, t, run, true)
+			if err := recordRun(transfers, toolParams.UserID, t.ID, run); err != nil {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("transfer ran but could not be recorded: %v", err),
+				}, nil
+			}
+
+			if run.Status != "executed" {
+				return &core.ToolResult{
+					Success: false,
+					Error:   fmt.Sprintf("scheduled transfer %s %s: %s", t.ID, run.Status, run.Error),
+				}, nil
+			}
+			var result interface{}
+			_ = json.Unmarshal([]byte(run.Result), &result)
+			return &core.ToolResult{
+				Success: true,
+				Data: map[string]interface{}{
+					"message":       fmt.Sprintf("Done! %s (scheduled for %s).", t.summary(), run.DueAt.Format("Mon Jan 2")),
+					"occurrence_id": run.ID,
+					"result":        result,
+				},
+			}, nil
+		}).
+		Build()
+
+	// GetSummary doesn't know the user, so it can't look the transfer up
+	// without risking describing someone else's. The card shows what the
+	// input claims instead, and the handler refuses to run a transfer that
+	// doesn't match it.
+	return &summaryTool{Tool: tool, summary: func(input json.RawMessage) string {
+		if dryRun {
+			return "Dry run (no money moves): " + runSummary(input)
+		}
+		return runSummary(input)
+	}}
+}
+
+// runParams is the input of run_scheduled_transfer.
+type runParams struct {
+	TransferID   string             `json:"transfer_id"`
+	OccurrenceID string             `json:"occurrence_id"`
+	Amount       toolkit.FlexAmount `json:"amount"`
+	Currency     string             `json:"currency"`
+	Recipient    string             `json:"recipient"`
+}
+
+// transfer is the transfer p describes.
+func (p runParams) transfer() scheduledTransfer {
+	t := scheduledTransfer{Tool: "deposit_savings", Amount: float64(p.Amount), Currency: strings.ToUpper(p.Currency)}
+	if p.Recipient != "" {
+		t.Tool, t.Recipient = "send_money", p.Recipient
+	}
+	return t
+}
+
+// mismatch says how t differs from what p describes, or returns "".
+func (p runParams) mismatch(t scheduledTransfer) string {
+	want := p.transfer()
+	if t.Tool != want.Tool || math.Abs(t.Amount-want.Amount) >= 0.005 || t.Currency != want.Currency ||
+		normalizeRecipient(t.Recipient) != normalizeRecipient(want.Recipient) {
+		return fmt.Sprintf("scheduled transfer %s is %q, not %q: check list_scheduled_transfers", t.ID, t.summary(), want.summary())
+	}
+	return ""
+}
+
+// runSummary describes a run_scheduled_transfer call from its input alone.
+func runSummary(input json.RawMessage) string {
+	var p runParams
+	if err := json.Unmarshal(input, &p); err != nil || p.TransferID == "" {
+		return "Run scheduled transfer"
+	}
+	if p.OccurrenceID != "" {
+		return fmt.Sprintf("%s (occurrence %s of scheduled transfer %s)", p.transfer().summary(), p.OccurrenceID, p.TransferID)
+	}
+	return fmt.Sprintf("%s (scheduled transfer %s)", p.transfer().summary(), p.TransferID)
+}
+
+// findPendingRun locates the occurrence a run_scheduled_transfer call refers
+// to among the user's schedules, returning a user-facing message if there
+// isn't one or it isn't the transfer the call describes.
+func findPendingRun(transfers *transferStore, userID string, input json.RawMessage) (scheduledTransfer, transferRun, string) {
+	var p runParams
+	if err := json.Unmarshal(input, &p); err != nil {
+		return scheduledTransfer{}, transferRun{}, fmt.Sprintf("invalid input: %v", err)
+	}
+	for _, t := range transfers.Get(userID) {
+		if t.ID != p.TransferID {
+			continue
+		}
+		if msg := p.mismatch(t); msg != "" {
+			return t, transferRun{}, msg
+		}
+		for _, run := range t.Pending {
+			if p.OccurrenceID == "" || run.ID == p.OccurrenceID {
+				return t, run, ""
+			}
+		}
+		return t, transferRun{}, "this scheduled transfer has no occurrence awaiting confirmation"
+	}
+	return scheduledTransfer{}, transferRun{}, fmt.Sprintf("no scheduled transfer with id %q", p.TransferID)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/becomeliminal/nim-go-sdk/core"
+
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/config"
+	"github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter/internal/mock"
+)
+
+// writeRecorder is an executor that records the write calls it gets.
+type writeRecorder struct {
+	core.ToolExecutor
+	writes []string
+	inputs []json.RawMessage
+}
+
+func (e *writeRecorder) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	e.writes = append(e.writes, req.UserID+" "+req.Tool)
+	e.inputs = append(e.inputs, req.Input)
+	return &core.ExecuteResponse{Success: true, Data: json.RawMessage(`{}`)}, nil
+}
+
+// Against Liminal a pre-authorized occurrence is parked for confirmation,
+// not paid unattended, and the confirm card of run_scheduled_transfer
+// describes only what the caller asked for.
+func TestLiveSchedulerParksPreAuthorizedRuns(t *testing.T) {
+	captureLogs(t)
+	transfers, err := newJSONStore[[]scheduledTransfer](filepath.Join(t.TempDir(), "scheduled_transfers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	transfers.Update("alice", func(list *[]scheduledTransfer) error {
+		*list = []scheduledTransfer{{
+			ID: "sched_1", Tool: "send_money", Recipient: "@bob", Amount: 50, Currency: "USD",
+			Frequency: "weekly", StartAt: now.Add(-time.Hour), NextRun: now.Add(-time.Hour),
+			PreAuthorized: true, Status: "active",
+		}}
+		return nil
+	})
+
+	exec := &writeRecorder{}
+	newTransferScheduler(exec, transfers, nil, false, false).runDue(context.Background(), now)
+	if len(exec.writes) != 0 {
+		t.Errorf("writes = %v, want none against Liminal", exec.writes)
+	}
+	list := transfers.Get("alice")
+	if len(list) != 1 || len(list[0].Pending) != 1 || list[0].Pending[0].Status != "awaiting_confirmation" || list[0].Pending[0].Error != liveRunNote {
+		t.Fatalf("schedules = %+v, want the occurrence awaiting confirmation", list)
+	}
+
+	tools := make(map[string]core.Tool)
+	for _, tool := range createScheduledTransferTools(exec, transfers, nil, false, false) {
+		tools[tool.Name()] = tool
+	}
+	// The confirm card shows the amount, currency and recipient the call
+	// claims, and the handler won't run a transfer that differs from them.
+	summary := tools["run_scheduled_transfer"].(interface{ GetSummary(json.RawMessage) string }).
+		GetSummary(json.RawMessage(`{"transfer_id": "sched_1", "amount": 50, "currency": "usd", "recipient": "@bob"}`))
+	if summary != "Send 50.00 USD to @bob (scheduled transfer sched_1)" {
+		t.Errorf("summary = %q", summary)
+	}
+	for _, input := range []string{
+		`{"transfer_id": "sched_1", "amount": 5, "currency": "USD", "recipient": "@bob"}`,
+		`{"transfer_id": "sched_1", "amount": 50, "currency": "EUR", "recipient": "@bob"}`,
+		`{"transfer_id": "sched_1", "amount": 50, "currency": "USD", "recipient": "@mallory"}`,
+		`{"transfer_id": "sched_1", "amount": 50, "currency": "USD"}`,
+	} {
+		result, err := tools["run_scheduled_transfer"].Execute(context.Background(), &core.ToolParams{UserID: "alice", Input: json.RawMessage(input)})
+		if err != nil || result.Success || !strings.Contains(result.Error, "Send 50.00 USD to @bob") || len(exec.writes) != 0 {
+			t.Errorf("%s: %v %+v, writes %v", input, err, result, exec.writes)
+		}
+	}
+
+	// Someone else can't run alice's occurrence.
+	result, err := tools["run_scheduled_transfer"].Execute(context.Background(), &core.ToolParams{
+		UserID: "mallory",
+		Input:  json.RawMessage(`{"transfer_id": "sched_1", "amount": 50, "currency": "USD", "recipient": "@bob"}`),
+	})
+	if err != nil || result.Success || len(exec.writes) != 0 {
+		t.Errorf("mallory's run: %v %+v, writes %v", err, result, exec.writes)
+	}
+}
+
+// Confirming an occurrence approves the payment, not its payee: a schedule
+// to someone who isn't trusted yet waits for add_trusted_payee like
+// send_money does.
+func TestScheduledRunKeepsNewPayeeEscalation(t *testing.T) {
+	captureLogs(t)
+	transfers, err := newJSONStore[[]scheduledTransfer](filepath.Join(t.TempDir(), "scheduled_transfers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := &writeRecorder{ToolExecutor: mock.NewExecutor()}
+	policy, payees := newTestPolicy(t, exec, config.DefaultSpendingLimits())
+	now := time.Now()
+	transfers.Update("alice", func(list *[]scheduledTransfer) error {
+		*list = []scheduledTransfer{{
+			ID: "sched_1", Tool: "send_money", Recipient: "@bob", Amount: 50, Currency: "USD",
+			Frequency: "weekly", StartAt: now, NextRun: now.Add(7 * 24 * time.Hour), Status: "active",
+			Pending: []transferRun{{ID: "run_1", DueAt: now, Status: "awaiting_confirmation"}},
+		}}
+		return nil
+	})
+
+	var run core.Tool
+	for _, tool := range createScheduledTransferTools(exec, transfers, policy, true, false) {
+		if tool.Name() == "run_scheduled_transfer" {
+			run = tool
+		}
+	}
+	params := &core.ToolParams{UserID: "alice", Input: json.RawMessage(`{"transfer_id": "sched_1", "amount": 50, "currency": "USD", "recipient": "@bob"}`)}
+
+	result, err := run.Execute(context.Background(), params)
+	if err != nil || result.Success || !strings.Contains(result.Error, "add_trusted_payee") || len(exec.writes) != 0 {
+		t.Fatalf("untrusted payee: %v %+v, writes %v", err, result, exec.writes)
+	}
+	if pending := transfers.Get("alice")[0].Pending; len(pending) != 1 {
+		t.Fatalf("pending = %+v, want the occurrence still waiting", pending)
+	}
+
+	payees.Update("alice", func(b *payeeBook) error {
+		b.Payees = append(b.Payees, trustedPayee{Recipient: "@bob", Source: "manual", AddedAt: now})
+		return nil
+	})
+	result, err = run.Execute(context.Background(), params)
+	if err != nil || !result.Success || len(exec.writes) != 1 {
+		t.Errorf("trusted payee: %v %+v, writes %v", err, result, exec.writes)
+	}
+}
+
+// An occurrence run twice is paid once and counted against the limits once.
+func TestScheduledRunReplayIsNotCountedTwice(t *testing.T) {
+	captureLogs(t)
+	writes := &writeRecorder{ToolExecutor: mock.NewExecutor()}
+	exec := newIdempotentExecutor(writes, true)
+	policy, payees := newTestPolicy(t, exec, config.DefaultSpendingLimits())
+	trustPayees(payees, "alice", "@bob")
+	now := time.Now()
+
+	transfer := scheduledTransfer{ID: "sched_1", Tool: "send_money", Recipient: "@bob", Amount: 50, Currency: "USD"}
+	for i := 0; i < 2; i++ {
+		run := executeScheduledRun(context.Background(), exec, policy, "alice", transfer, transferRun{ID: "run_1", DueAt: now}, true)
+		if run.Status != "executed" {
+			t.Fatalf("run %d: %+v", i+1, run)
+		}
+	}
+	if len(writes.writes) != 1 {
+		t.Errorf("writes = %v, want one", writes.writes)
+	}
+	if ledger := policy.state.Get("alice").Ledger; len(ledger) != 1 {
+		t.Errorf("ledger = %+v, want the payment counted once", ledger)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ============================================================================
+// PERSISTENCE  –  small JSON-file store for per-user state
+// ============================================================================
+
+// jsonStore keeps one record per user in memory and rewrites a single JSON
+// file on every update. It's sized for the modest amount of state the custom
+// tools keep (budgets, goals, ...), not for anything high-volume.
+type jsonStore[T any] struct {
+	mu   sync.Mutex
+	path string
+	data map[string]T
+}
+
+func newJSONStore[T any](path string) (*jsonStore[T], error) {
+	s := &jsonStore[T]{
+		path: path,
+		data: make(map[string]T),
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &s.data); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Get returns the user's record, or the zero value if there is none.
+func (s *jsonStore[T]) Get(userID string) T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[userID]
+}
+
+// Users returns the IDs of every user with a stored record.
+func (s *jsonStore[T]) Users() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]string, 0, len(s.data))
+	for userID := range s.data {
+		users = append(users, userID)
+	}
+	return users
+}
+
+// Update applies fn to the user's record and persists the result. If fn
+// returns an error or the file can't be written, the record is left as it
+// was, in memory and on disk.
+func (s *jsonStore[T]) Update(userID string, fn func(*T) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Work on a deep copy so a failed update can't leave half-applied
+	// changes behind, and values handed out by Get are never mutated.
+	var rec T
+	if cur, ok := s.data[userID]; ok {
+		raw, err := json.Marshal(cur)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return err
+		}
+	}
+	if err := fn(&rec); err != nil {
+		return err
+	}
+	prev, had := s.data[userID]
+	s.data[userID] = rec
+	if err := s.flush(); err != nil {
+		if had {
+			s.data[userID] = prev
+		} else {
+			delete(s.data, userID)
+		}
+		return err
+	}
+	return nil
+}
+
+// flush writes the store atomically (temp file + rename). Callers hold mu.
+func (s *jsonStore[T]) flush() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
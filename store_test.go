@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// An update that can't be written leaves the record as it was.
+func TestJSONStoreFailedFlush(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newJSONStore[[]string](filepath.Join(dir, "data", "store.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update("alice", func(v *[]string) error { *v = []string{"one"}; return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the data directory with a file so every write fails.
+	if err := os.RemoveAll(filepath.Join(dir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []string{"alice", "bob"} {
+		if err := s.Update(user, func(v *[]string) error { *v = append(*v, "two"); return nil }); err == nil {
+			t.Fatalf("%s: update succeeded without a file to write", user)
+		}
+	}
+	if got := s.Get("alice"); len(got) != 1 || got[0] != "one" {
+		t.Errorf("alice = %v, want the record from before the failed update", got)
+	}
+	if got := s.Users(); len(got) != 1 {
+		t.Errorf("users = %v, want bob's failed first update left out", got)
+	}
+}
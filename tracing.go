@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/becomeliminal/nim-go-sdk/core"
+	"github.com/becomeliminal/nim-go-sdk/store"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ============================================================================
+// TRACING  –  OpenTelemetry spans for each turn, model call and tool call
+// ============================================================================
+// Spans:
+//   - user_message / confirm_action: one per turn of a conversation, from
+//     the user's message (or tap on confirm) until the reply is stored
+//   - anthropic.messages: each model call, as a child of the turn
+//   - tool <name>: each tool call, as a child of the turn
+//   - executor <method>: Execute / ExecuteWrite / Confirm / Cancel, as
+//     children of the tool call that made them
+//
+// The SDK runs a whole connection under the request context of /ws, so
+// traceSessions puts a turnTracker in it, the conversation store starts and
+// ends turns on it, and the other spans find their parent there. Spans carry
+// conversation.id and request.id (the SDK uses the conversation ID as the
+// request ID of a run).
+//
+// Spans are exported over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT (or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) is set, using the standard OTEL_*
+// variables for headers, service name and sampling. Otherwise tracing is off.
+
+const tracerName = "github.com/becomeliminal/nim-go-sdk/examples/hackathon-starter"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// setupTracing installs the global tracer provider. The returned function
+// flushes and stops it.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(envOr("OTEL_SERVICE_NAME", "nim-hackathon-starter")),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("building resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// ---------------------------------------------------------------------------
+// turns
+// ---------------------------------------------------------------------------
+
+type turnTrackerKey struct{}
+
+// turnTracker holds the span of the current turn on one connection.
+type turnTracker struct {
+	mu   sync.Mutex
+	conn trace.Span
+	turn trace.Span
+}
+
+// traceSessions wraps the /ws handler with a span for the connection and a
+// turnTracker for its turns.
+func traceSessions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, "websocket session", trace.WithSpanKind(trace.SpanKindServer))
+		tracker := &turnTracker{conn: span}
+		defer func() {
+			tracker.end()
+			span.End()
+		}()
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, turnTrackerKey{}, tracker)))
+	})
+}
+
+// start ends the current turn, if any, and starts a new one.
+func (t *turnTracker) start(name string, attrs ...attribute.KeyValue) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.turn != nil {
+		t.turn.End()
+	}
+	ctx := trace.ContextWithSpan(context.Background(), t.conn)
+	_, t.turn = tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func (t *turnTracker) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.turn != nil {
+		t.turn.End()
+		t.turn = nil
+	}
+}
+
+func (t *turnTracker) event(name string, attrs ...attribute.KeyValue) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.turn != nil {
+		t.turn.AddEvent(name, trace.WithAttributes(attrs...))
+	}
+}
+
+func turnTrackerFrom(ctx context.Context) *turnTracker {
+	t, _ := ctx.Value(turnTrackerKey{}).(*turnTracker)
+	return t
+}
+
+// spanParent returns the context new spans should start from: ctx itself
+// inside a tool or executor span, or ctx with the current turn's span when
+// ctx only carries the connection.
+func spanParent(ctx context.Context) context.Context {
+	t := turnTrackerFrom(ctx)
+	if t == nil {
+		return ctx
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if trace.SpanFromContext(ctx).SpanContext().SpanID() != t.conn.SpanContext().SpanID() {
+		return ctx
+	}
+	if t.turn != nil {
+		return trace.ContextWithSpan(ctx, t.turn)
+	}
+	return ctx
+}
+
+// tracingConversations starts a turn when the user's message is stored and
+// ends it when the reply is.
+type tracingConversations struct {
+	store.Conversations
+}
+
+func traceConversations(next store.Conversations) store.Conversations {
+	return &tracingConversations{Conversations: next}
+}
+
+func (c *tracingConversations) Append(ctx context.Context, msg *store.AppendMessage) error {
+	if t := turnTrackerFrom(ctx); t != nil {
+		switch msg.Role {
+		case "user":
+			t.start("user_message",
+				attribute.String("conversation.id", msg.ConversationID),
+				attribute.String("request.id", msg.ConversationID),
+				attribute.Int("message.length", len(msg.Content)),
+			)
+		case "assistant":
+			t.end()
+		}
+	}
+	return c.Conversations.Append(ctx, msg)
+}
+
+// tracingConfirmations marks confirmation cards on the turn that raised
+// them and starts a turn when one is confirmed.
+type tracingConfirmations struct {
+	store.Confirmations
+}
+
+func traceConfirmations(next store.Confirmations) store.Confirmations {
+	return &tracingConfirmations{Confirmations: next}
+}
+
+func (c *tracingConfirmations) Store(ctx context.Context, action *core.PendingAction) error {
+	if t := turnTrackerFrom(ctx); t != nil {
+		t.event("confirmation requested",
+			attribute.String("confirmation.id", action.ID),
+			attribute.String("tool", action.Tool),
+		)
+		t.end()
+	}
+	return c.Confirmations.Store(ctx, action)
+}
+
+func (c *tracingConfirmations) Confirm(ctx context.Context, userID, actionID string) (*core.PendingAction, error) {
+	action, err := c.Confirmations.Confirm(ctx, userID, actionID)
+	if t := turnTrackerFrom(ctx); t != nil {
+		attrs := []attribute.KeyValue{
+			attribute.String("user.id", userID),
+			attribute.String("confirmation.id", actionID),
+		}
+		if action != nil {
+			attrs = append(attrs,
+				attribute.String("conversation.id", action.SessionID),
+				attribute.String("request.id", action.SessionID),
+				attribute.String("tool", action.Tool),
+			)
+		}
+		t.start("confirm_action", attrs...)
+		if err != nil {
+			t.event("confirmation failed", attribute.String("error", err.Error()))
+			t.end()
+		}
+	}
+	return action, err
+}
+
+// ---------------------------------------------------------------------------
+// tools, executor and model calls
+// ---------------------------------------------------------------------------
+
+// tracingMiddleware records a span for every tool call.
+func tracingMiddleware() toolMiddleware {
+	return func(tool core.Tool, next core.ToolHandler) core.ToolHandler {
+		return func(ctx context.Context, params *core.ToolParams) (*core.ToolResult, error) {
+			ctx, span := tracer().Start(spanParent(ctx), "tool "+tool.Name(), trace.WithAttributes(
+				attribute.String("tool", tool.Name()),
+				attribute.String("user.id", params.UserID),
+				attribute.String("request.id", params.RequestID),
+				attribute.String("confirmation.id", params.ConfirmationID),
+				attribute.Bool("tool.requires_confirmation", tool.RequiresConfirmation()),
+			))
+			defer span.End()
+
+			result, err := next(ctx, params)
+			switch {
+			case err != nil:
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			case result != nil && !result.Success:
+				span.SetStatus(codes.Error, result.Error)
+			}
+			return result, err
+		}
+	}
+}
+
+// tracingExecutor records a span for every executor call.
+type tracingExecutor struct {
+	next core.ToolExecutor
+}
+
+var _ core.ToolExecutor = (*tracingExecutor)(nil)
+
+func newTracingExecutor(next core.ToolExecutor) *tracingExecutor {
+	return &tracingExecutor{next: next}
+}
+
+func (e *tracingExecutor) span(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(spanParent(ctx), "executor "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func endExecutorSpan(span trace.Span, resp *core.ExecuteResponse, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case resp != nil && !resp.Success && !resp.RequiresConfirmation:
+		span.SetStatus(codes.Error, resp.Error)
+	}
+	span.End()
+}
+
+func requestAttrs(req *core.ExecuteRequest) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("tool", req.Tool),
+		attribute.String("user.id", req.UserID),
+		attribute.String("request.id", req.RequestID),
+	}
+}
+
+func (e *tracingExecutor) Execute(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	ctx, span := e.span(ctx, "Execute", requestAttrs(req)...)
+	resp, err := e.next.Execute(ctx, req)
+	endExecutorSpan(span, resp, err)
+	return resp, err
+}
+
+func (e *tracingExecutor) ExecuteWrite(ctx context.Context, req *core.ExecuteRequest) (*core.ExecuteResponse, error) {
+	ctx, span := e.span(ctx, "ExecuteWrite", requestAttrs(req)...)
+	resp, err := e.next.ExecuteWrite(ctx, req)
+	endExecutorSpan(span, resp, err)
+	return resp, err
+}
+
+func (e *tracingExecutor) Confirm(ctx context.Context, userID, confirmationID string) (*core.ExecuteResponse, error) {
+	ctx, span := e.span(ctx, "Confirm", attribute.String("user.id", userID), attribute.String("confirmation.id", confirmationID))
+	resp, err := e.next.Confirm(ctx, userID, confirmationID)
+	endExecutorSpan(span, resp, err)
+	return resp, err
+}
+
+func (e *tracingExecutor) Cancel(ctx context.Context, userID, confirmationID string) error {
+	ctx, span := e.span(ctx, "Cancel", attribute.String("user.id", userID), attribute.String("confirmation.id", confirmationID))
+	err := e.next.Cancel(ctx, userID, confirmationID)
+	endExecutorSpan(span, nil, err)
+	return err
+}
+
+// anthropicTracing is an Anthropic client middleware that records a span
+// for every API request. For streamed responses the span ends when the
+// stream has been read to the end.
+func anthropicTracing() option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		ctx, span := tracer().Start(spanParent(req.Context()), "anthropic.messages",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.path", req.URL.Path),
+			))
+		resp, err := next(req.WithContext(ctx))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			return resp, err
+		}
+		span.SetAttributes(
+			attribute.Int("http.response.status_code", resp.StatusCode),
+			attribute.String("anthropic.request_id", resp.Header.Get("Request-Id")),
+		)
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+		resp.Body = &spanEndingBody{ReadCloser: resp.Body, span: span}
+		return resp, nil
+	}
+}
+
+type spanEndingBody struct {
+	io.ReadCloser
+	span trace.Span
+	once sync.Once
+}
+
+func (b *spanEndingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.end(err)
+	}
+	return n, err
+}
+
+func (b *spanEndingBody) Close() error {
+	b.end(nil)
+	return b.ReadCloser.Close()
+}
+
+func (b *spanEndingBody) end(err error) {
+	b.once.Do(func() {
+		if err != nil && !errors.Is(err, io.EOF) {
+			b.span.RecordError(err)
+			b.span.SetStatus(codes.Error, err.Error())
+		}
+		b.span.End()
+	})
+}